@@ -8,11 +8,15 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"quiz_backend_core/internal/blobstore"
 	"quiz_backend_core/internal/config"
+	"quiz_backend_core/internal/idempotency"
 	"quiz_backend_core/internal/notifier"
+	"quiz_backend_core/internal/runner"
 	"quiz_backend_core/internal/service"
 	"quiz_backend_core/internal/storage"
 	transport "quiz_backend_core/internal/transport/http"
+	"quiz_backend_core/pkg/jwt"
 	"strings"
 	"syscall"
 	"time"
@@ -40,7 +44,42 @@ func LogLevel(lvl string) logrus.Level {
 	}
 }
 
+// newJWTVerifier loads keys of the jwt auth mode, keys from JWKS file are added to the configured ones
+func newJWTVerifier(cfg *config.Config) (*jwt.Verifier, error) {
+	keys := jwt.NewKeySet()
+
+	if cfg.JWTSecret != "" {
+		keys.AddSecret("", []byte(cfg.JWTSecret))
+	}
+
+	if cfg.JWTPublicKeyFile != "" {
+		key, err := jwt.LoadPEMPublicKey(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keys.AddPublicKey("", key)
+	}
+
+	if cfg.JWKSFile != "" {
+		if err := keys.LoadJWKS(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return jwt.NewVerifier(jwt.Options{
+		Algorithm:      cfg.JWTAlgorithm,
+		Keys:           keys,
+		Issuer:         cfg.JWTIssuer,
+		Audience:       cfg.JWTAudience,
+		Leeway:         cfg.JWTLeeway,
+		ExpiryOptional: cfg.JWTExpiryOptional,
+	})
+}
+
 func main() {
+	// the binary is also the sandbox helper of the code runner
+	runner.ExecHelper()
+
 	// context
 	mainCtx := context.Background()
 
@@ -75,11 +114,46 @@ func main() {
 	}
 
 	//notifier
-	notifier, err := notifier.NewNotifier(cfg.NotifierHost+":"+cfg.NotifierPort, &logger)
+	notifierClient, err := notifier.NewNotifier(cfg.NotifierHost+":"+cfg.NotifierPort, &logger)
 	if err != nil {
 		log.Fatal(fmt.Errorf("Unable to connect to notifier: %v\n", err))
 	}
-	defer notifier.Close()
+	defer notifierClient.Close()
+
+	// attachments
+	blobStore, err := blobstore.NewFileSystemStore(cfg.AttachmentsDir)
+	if err != nil {
+		log.Fatal(fmt.Errorf("Unable to open attachments storage: %v\n", err))
+	}
+
+	// runner of programming questions, the service works without it
+	codeRunner := runner.NewDisabledRunner()
+	if cfg.RunnerEnabled {
+		localRunner, err := runner.NewLocalRunner(runner.LocalConfig{
+			WorkDir:        cfg.RunnerWorkDir,
+			Timeout:        cfg.RunnerTimeout,
+			CompileTimeout: cfg.RunnerCompileTimeout,
+			MemoryLimit:    cfg.RunnerMemoryLimit,
+			ProcessLimit:   cfg.RunnerProcessLimit,
+			FileSizeLimit:  cfg.RunnerFileSizeLimit,
+			MaxParallel:    cfg.RunnerMaxParallel,
+			SandboxUID:     cfg.RunnerSandboxUID,
+			IsolateNetwork: cfg.RunnerIsolateNetwork,
+			GoBinary:       cfg.RunnerGoBinary,
+			PythonBinary:   cfg.RunnerPythonBinary,
+
+			CompileMemoryLimit:   cfg.RunnerCompileMemoryLimit,
+			CompileProcessLimit:  cfg.RunnerCompileProcessLimit,
+			CompileFileSizeLimit: cfg.RunnerCompileFileSizeLimit,
+		})
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("code runner is not available, program questions are disabled")
+		} else {
+			codeRunner = localRunner
+		}
+	}
 
 	// metrics
 	fieldKeys := []string{"method", "error"}
@@ -102,13 +176,46 @@ func main() {
 		RequestCounter:      requestCounter,
 		RequestLatencyMeter: requestLatencyMeter,
 		Logger:              &logger,
-		Notifier:            notifier,
+		Notifier:            notifierClient,
+		Config:              &cfg,
+		BlobStore:           blobStore,
+		CodeRunner:          codeRunner,
 	}
 
 	s := service.NewServices(deps)
 
+	// trash purge
+	purgeCtx, stopPurge := context.WithCancel(mainCtx)
+	defer stopPurge()
+	go service.NewTrashPurger(deps).Run(purgeCtx)
+	go idempotency.NewPurger(storages.IdempotencyKeys, cfg.IdempotencyKeyPurgeInterval, &logger).Run(purgeCtx)
+
+	// notifications of the outbox
+	dispatchCtx, stopDispatch := context.WithCancel(mainCtx)
+	defer stopDispatch()
+	go notifier.NewDispatcher(storages.Outbox, notifierClient, &logger, notifier.DispatcherConfig{
+		PollInterval: cfg.OutboxPollInterval,
+		BatchSize:    cfg.OutboxBatchSize,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+		RetryBackoff: cfg.OutboxRetryBackoff,
+		MaxBackoff:   cfg.OutboxMaxBackoff,
+		Retention:    cfg.OutboxRetention,
+	}).Run(dispatchCtx)
+
+	// authentication
+	var verifier *jwt.Verifier
+	switch cfg.AuthMode {
+	case config.AuthModeUpstream:
+	case config.AuthModeJWT:
+		if verifier, err = newJWTVerifier(&cfg); err != nil {
+			log.Fatal(fmt.Errorf("Unable to create JWT verifier: %v\n", err))
+		}
+	default:
+		log.Fatal(fmt.Errorf("Unknown auth mode: %q\n", cfg.AuthMode))
+	}
+
 	// handler
-	h := transport.MakeHTTPHandler(s, &logger, cfg.AppEnv == "development")
+	h := transport.MakeHTTPHandler(s, &logger, &cfg, verifier)
 
 	addr := cfg.ListenAddr
 	srv := &http.Server{
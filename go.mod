@@ -11,6 +11,7 @@ require (
 	github.com/proger567/quiz_protos v0.0.0-20250410082736-e8d6166e1f62
 	github.com/prometheus/client_golang v1.21.1
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/sys v0.29.0
 	google.golang.org/grpc v1.71.1
 )
 
@@ -31,7 +32,6 @@ require (
 	golang.org/x/crypto v0.32.0 // indirect
 	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 	google.golang.org/genproto v0.0.0-20210917145530-b395a37504d4 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
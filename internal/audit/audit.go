@@ -0,0 +1,26 @@
+// Package audit carries the author of changes from HTTP transport to storages, which write the audit log
+package audit
+
+import (
+	"context"
+	"quiz_backend_core/internal/dto"
+)
+
+// Actor made the change within the request
+type Actor struct {
+	UserID    int64
+	Role      dto.Role
+	RequestID string
+}
+
+type actorKey struct{}
+
+func NewContext(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// FromContext returns the actor put by NewContext, changes without actor are made by the service itself
+func FromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
@@ -0,0 +1,94 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"quiz_backend_core/internal/model"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// FileSystemStore keeps blobs in a local directory, blob "abcdef" is stored as <root>/ab/abcdef
+type FileSystemStore struct {
+	root string
+}
+
+func NewFileSystemStore(root string) (model.BlobStore, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &FileSystemStore{
+		root: root,
+	}, nil
+}
+
+func (s *FileSystemStore) Put(_ context.Context, key string, content io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	// write to temporary file first, so readers never see partial content
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileSystemStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+// Delete removes blob, missing blob is not an error
+func (s *FileSystemStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path allows only [a-z0-9.] keys, so they can not leave the root directory
+func (s *FileSystemStore) path(key string) (string, error) {
+	if len(key) < 3 || key[0] == '.' {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '.' {
+			return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+
+	return filepath.Join(s.root, key[:2], key), nil
+}
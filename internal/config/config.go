@@ -3,6 +3,12 @@ package config
 import (
 	"flag"
 	"github.com/caarlos0/env/v11"
+	"time"
+)
+
+const (
+	AuthModeUpstream = "upstream" // user id and role headers are filled by the auth gateway
+	AuthModeJWT      = "jwt"      // bearer tokens are verified by the service
 )
 
 type Config struct {
@@ -16,6 +22,56 @@ type Config struct {
 	DatabaseName     string `env:"DB_NAME" envDefault:"quiz"`
 	NotifierHost     string `env:"NOTIFIER_HOST" envDefault:"localhost"`
 	NotifierPort     string `env:"NOTIFIER_PORT" envDefault:"3200"`
+
+	DuplicateSimilarityThreshold float64 `env:"DUPLICATE_SIMILARITY_THRESHOLD" envDefault:"0.6"`
+
+	AttachmentsDir         string        `env:"ATTACHMENTS_DIR" envDefault:"./attachments"`
+	AttachmentsMaxSize     int64         `env:"ATTACHMENTS_MAX_SIZE" envDefault:"10485760"` // bytes
+	AttachmentsUnlinkedTTL time.Duration `env:"ATTACHMENTS_UNLINKED_TTL" envDefault:"24h"`  // uploads not linked to a question are purged after it
+
+	RunnerEnabled        bool          `env:"RUNNER_ENABLED" envDefault:"true"` // program questions are disabled without runner
+	RunnerWorkDir        string        `env:"RUNNER_WORK_DIR" envDefault:"./runner"`
+	RunnerTimeout        time.Duration `env:"RUNNER_TIMEOUT" envDefault:"2s"`               // per test
+	RunnerCompileTimeout time.Duration `env:"RUNNER_COMPILE_TIMEOUT" envDefault:"60s"`      // builds start with empty cache
+	RunnerMemoryLimit    int64         `env:"RUNNER_MEMORY_LIMIT" envDefault:"268435456"`   // bytes
+	RunnerProcessLimit   int           `env:"RUNNER_PROCESS_LIMIT" envDefault:"64"`         // processes and threads
+	RunnerFileSizeLimit  int64         `env:"RUNNER_FILE_SIZE_LIMIT" envDefault:"16777216"` // bytes of a written file
+	RunnerMaxParallel    int           `env:"RUNNER_MAX_PARALLEL" envDefault:"2"`
+	RunnerSandboxUID     int           `env:"RUNNER_SANDBOX_UID" envDefault:"20000"` // programs run as uids from it, one per parallel run
+	RunnerIsolateNetwork bool          `env:"RUNNER_ISOLATE_NETWORK" envDefault:"true"`
+	RunnerGoBinary       string        `env:"RUNNER_GO_BINARY" envDefault:"go"`
+	RunnerPythonBinary   string        `env:"RUNNER_PYTHON_BINARY" envDefault:"python3"`
+	SubmissionMaxSize    int           `env:"SUBMISSION_MAX_SIZE" envDefault:"65536"` // bytes of code
+
+	// compilers get more than programs, but they build untrusted code too, see runner.LocalConfig
+	RunnerCompileMemoryLimit   int64 `env:"RUNNER_COMPILE_MEMORY_LIMIT" envDefault:"4294967296"`   // bytes of address space
+	RunnerCompileProcessLimit  int   `env:"RUNNER_COMPILE_PROCESS_LIMIT" envDefault:"256"`         // processes and threads
+	RunnerCompileFileSizeLimit int64 `env:"RUNNER_COMPILE_FILE_SIZE_LIMIT" envDefault:"268435456"` // bytes of a written file
+
+	TrashRetention     time.Duration `env:"TRASH_RETENTION" envDefault:"720h"` // deleted items are purged after it
+	TrashPurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" envDefault:"1h"`
+
+	IdempotencyKeyTTL           time.Duration `env:"IDEMPOTENCY_KEY_TTL" envDefault:"24h"`
+	IdempotencyKeyPurgeInterval time.Duration `env:"IDEMPOTENCY_KEY_PURGE_INTERVAL" envDefault:"1h"` // expired keys are removed with it
+
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+	OutboxBatchSize    int           `env:"OUTBOX_BATCH_SIZE" envDefault:"100"`
+	OutboxMaxAttempts  int           `env:"OUTBOX_MAX_ATTEMPTS" envDefault:"10"`  // the event is dead-lettered after them
+	OutboxRetryBackoff time.Duration `env:"OUTBOX_RETRY_BACKOFF" envDefault:"5s"` // doubled after every failed attempt
+	OutboxMaxBackoff   time.Duration `env:"OUTBOX_MAX_BACKOFF" envDefault:"1h"`
+	OutboxRetention    time.Duration `env:"OUTBOX_RETENTION" envDefault:"168h"` // delivered events are purged after it
+
+	AuthMode          string        `env:"AUTH_MODE" envDefault:"upstream"`
+	JWTAlgorithm      string        `env:"JWT_ALGORITHM" envDefault:"HS256"` // HS256 or RS256
+	JWTSecret         string        `env:"JWT_SECRET"`                       // HS256 secret
+	JWTPublicKeyFile  string        `env:"JWT_PUBLIC_KEY_FILE"`              // PEM with RS256 public key
+	JWKSFile          string        `env:"JWT_JWKS_FILE"`                    // keys selected by kid
+	JWTIssuer         string        `env:"JWT_ISSUER"`                       // checked if set
+	JWTAudience       string        `env:"JWT_AUDIENCE"`                     // checked if set
+	JWTLeeway         time.Duration `env:"JWT_LEEWAY" envDefault:"30s"`
+	JWTExpiryOptional bool          `env:"JWT_EXPIRY_OPTIONAL" envDefault:"false"` // accept tokens without exp claim
+	JWTUserIDClaim    string        `env:"JWT_USER_ID_CLAIM" envDefault:"sub"`
+	JWTRoleClaim      string        `env:"JWT_ROLE_CLAIM" envDefault:"role"`
 }
 
 func (c *Config) Parse() error {
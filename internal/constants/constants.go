@@ -1,9 +0,0 @@
-package constants
-
-// TODO move to better place?
-type ContextVariables string
-
-const (
-	ContextVariablesUserID   = "user_id"
-	ContextVariablesUserRole = "user_role"
-)
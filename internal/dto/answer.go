@@ -0,0 +1,59 @@
+package dto
+
+// Variants and answers of question types graded by the service. They are stored in Question.Variants
+// and Question.Answer as json objects.
+
+// OrderingItem is an item of ordering question, Variants: {"items": [{"id": "a", "text": "..."}]}
+type OrderingItem struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type OrderingVariants struct {
+	Items []OrderingItem `json:"items"`
+}
+
+// OrderingAnswer is the right order of item ids, it is also the learner response
+type OrderingAnswer struct {
+	Order []string `json:"order"`
+}
+
+// NumericAnswer is accepted if the value is within the largest of tolerances. Units maps alternative
+// units to the factor converting them to Unit, e.g. Unit "m" and Units {"km": 1000}.
+type NumericAnswer struct {
+	Value             float64            `json:"value"`
+	AbsoluteTolerance float64            `json:"absolute_tolerance,omitempty"`
+	RelativeTolerance float64            `json:"relative_tolerance,omitempty"` // share of value, e.g. 0.01
+	Unit              string             `json:"unit,omitempty"`
+	Units             map[string]float64 `json:"units,omitempty"`
+}
+
+type NumericResponse struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+// ClozeBlank is a blank of cloze question, blanks are marked in text as {{name}}
+type ClozeBlank struct {
+	Accepted      []string `json:"accepted"`
+	CaseSensitive bool     `json:"case_sensitive,omitempty"`
+}
+
+type ClozeAnswer struct {
+	Blanks map[string]ClozeBlank `json:"blanks"`
+}
+
+// ClozeVariants are optional choices of blanks (drop-down lists)
+type ClozeVariants struct {
+	Options map[string][]string `json:"options,omitempty"`
+}
+
+type ClozeResponse struct {
+	Blanks map[string]string `json:"blanks"`
+}
+
+// GradeResult is a score from 0 to 1 of a learner response
+type GradeResult struct {
+	Score   float64 `json:"score"`
+	Correct bool    `json:"correct"`
+}
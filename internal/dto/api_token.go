@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// APITokenPrefix starts every api token, so tokens are told apart from jwt
+const APITokenPrefix = "qbt_"
+
+// APIToken authenticates a machine integration as the user with the role, limited by scopes
+type APIToken struct {
+	ID              int64    `json:"id,string"`
+	Name            string   `json:"name"`
+	UserID          int64    `json:"user_id,string"`
+	Role            Role     `json:"role"`
+	Scopes          []string `json:"scopes"`
+	Prefix          string   `json:"prefix"` // public id of the token, not a part of the secret
+	CreatedByUserID int64    `json:"created_by_user_id,string,omitempty"`
+	CreatedAt       string   `json:"created_at,omitempty"`
+	ExpiresAt       string   `json:"expires_at,omitempty"`
+	LastUsedAt      string   `json:"last_used_at,omitempty"`
+	RevokedAt       string   `json:"revoked_at,omitempty"`
+}
+
+type InputAPIToken struct {
+	Name      string    `json:"name"`
+	UserID    int64     `json:"user_id,string"`
+	Role      Role      `json:"role"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"` // zero means the token does not expire
+
+	CreatedByUserID int64  `json:"-"`
+	Hash            []byte `json:"-"`
+	Prefix          string `json:"-"`
+}
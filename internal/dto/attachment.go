@@ -0,0 +1,15 @@
+package dto
+
+// AttachmentReferencePrefix is used to reference attachment from question text or variants: attachment://<id>
+const AttachmentReferencePrefix = "attachment://"
+
+type Attachment struct {
+	ID            int64  `json:"id,string"`
+	QuestionID    int64  `json:"question_id,string,omitempty"`
+	FileName      string `json:"file_name"`
+	MimeType      string `json:"mime_type"`
+	Size          int64  `json:"size"`
+	StorageKey    string `json:"-"`
+	CreatorUserID int64  `json:"creator_user_id,string,omitempty"`
+	CreatedAt     string `json:"created_at,omitempty"`
+}
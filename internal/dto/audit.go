@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntity is the type of changed object, it is the name of its table
+type AuditEntity string
+
+const (
+	AuditEntityQuestion          AuditEntity = "question"
+	AuditEntityQuiz              AuditEntity = "quiz"
+	AuditEntitySubject           AuditEntity = "subject"
+	AuditEntitySubjectMaintainer AuditEntity = "subject_maintainer" // entity id is the subject id
+	AuditEntityAttachment        AuditEntity = "attachment"
+	AuditEntityAPIToken          AuditEntity = "api_token"
+)
+
+var AuditEntities = []AuditEntity{
+	AuditEntityQuestion,
+	AuditEntityQuiz,
+	AuditEntitySubject,
+	AuditEntitySubjectMaintainer,
+	AuditEntityAttachment,
+	AuditEntityAPIToken,
+}
+
+type AuditAction string
+
+const (
+	AuditActionCreate   AuditAction = "create"
+	AuditActionUpdate   AuditAction = "update"
+	AuditActionModerate AuditAction = "moderate"
+	AuditActionMove     AuditAction = "move"
+	AuditActionMerge    AuditAction = "merge"
+	AuditActionDelete   AuditAction = "delete" // moved to the trash
+	AuditActionRestore  AuditAction = "restore"
+	AuditActionPurge    AuditAction = "purge" // removed for good
+	AuditActionGrant    AuditAction = "grant"
+	AuditActionRevoke   AuditAction = "revoke"
+)
+
+// AuditRoleSystem is the actor role of changes made by the service itself
+const AuditRoleSystem Role = "system"
+
+// AuditEntry is a change of an object, Before and After are rows of the object table
+type AuditEntry struct {
+	ID          int64           `json:"id,string"`
+	ActorUserID int64           `json:"actor_user_id,string,omitempty"`
+	ActorRole   Role            `json:"actor_role"`
+	Action      AuditAction     `json:"action"`
+	EntityType  AuditEntity     `json:"entity_type"`
+	EntityID    int64           `json:"entity_id,string"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	RequestID   string          `json:"request_id,omitempty"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// AuditFilter selects audit entries, -1 ids and zero times mean "any"
+type AuditFilter struct {
+	EntityType  AuditEntity
+	EntityID    int64
+	ActorUserID int64
+	From        time.Time
+	To          time.Time
+	Limit       int
+	Offset      int
+}
+
+func NewAuditFilter() AuditFilter {
+	return AuditFilter{
+		EntityID:    -1,
+		ActorUserID: -1,
+	}
+}
+
+var (
+	ErrInvalidAuditEntity = newError(KindValidation, "invalid_audit_entity", "unknown audit entity type")
+	ErrInvalidTimeRange   = newError(KindValidation, "invalid_time_range", "time range start is after its end")
+)
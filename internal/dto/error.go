@@ -1,28 +1,123 @@
 package dto
 
-import "errors"
+import (
+	"fmt"
+	"strings"
+)
 
 //TODO make common package with errors? at least with common errors?
 
 // common
 var (
-	ErrInternalServerError = errors.New("internal server error") //TODO
-	ErrBadRouting          = errors.New("bad routing")
+	ErrInternalServerError = newError(KindInternal, "internal_server_error", "internal server error") //TODO
+	ErrBadRouting          = newError(KindBadRequest, "bad_routing", "bad routing")
+	ErrForbidden           = newError(KindForbidden, "forbidden", "forbidden")
+	ErrVersionMismatch     = newError(KindPreconditionFailed, "version_mismatch", "object was changed by someone else, reload it and retry")
+	ErrIfMatchRequired     = newError(KindPreconditionRequired, "if_match_required", "If-Match header with the object version is required")
+)
+
+// validation
+var (
+	ErrRequired          = newError(KindValidation, "required", "value is required")
+	ErrTooLong           = newError(KindValidation, "too_long", "value is too long")
+	ErrOutOfRange        = newError(KindValidation, "out_of_range", "value is out of range")
+	ErrNotUnique         = newError(KindValidation, "not_unique", "values must be unique")
+	ErrUnknownValue      = newError(KindValidation, "unknown_value", "value is unknown")
+	ErrReferenceNotFound = newError(KindValidation, "reference_not_found", "referenced object does not exist")
 )
 
 // subject
 var (
-	ErrSubjectAlreadyExists = errors.New("subject is already exist")
-	ErrSubjectNotFound      = errors.New("subject is not found")
+	ErrSubjectAlreadyExists  = newError(KindConflict, "subject_already_exists", "subject is already exist")
+	ErrSubjectNotFound       = newError(KindNotFound, "subject_not_found", "subject is not found")
+	ErrParentInTrash         = newError(KindConflict, "parent_in_trash", "parent subject is in the trash, restore it first")
+	ErrSubjectNotEmpty       = newError(KindConflict, "subject_not_empty", "subject has sub subjects or questions, use cascade or reassign mode")
+	ErrInvalidDeleteMode     = newError(KindValidation, "invalid_delete_mode", "unknown subject delete mode")
+	ErrInvalidReassignTarget = newError(KindValidation, "invalid_reassign_target", "questions can be reassigned only to an existing subject outside of the deleted subtree")
+	ErrSubjectCycle          = newError(KindConflict, "subject_cycle", "subject can not be moved or merged into its own subtree")
+	ErrMaintainerNotFound    = newError(KindNotFound, "maintainer_not_found", "user is not a maintainer of the subject")
 )
 
 // question
 var (
-	ErrQuestionAlreadyExists = errors.New("question is already exist")
-	ErrQuestionNotFound      = errors.New("question is not found")
+	ErrQuestionAlreadyExists = newError(KindConflict, "question_already_exists", "question is already exist")
+	ErrQuestionNotFound      = newError(KindNotFound, "question_not_found", "question is not found")
+	ErrEmptySearchQuery      = newError(KindValidation, "empty_search_query", "search query is empty")
+	ErrQuestionDuplicate     = newError(KindConflict, "question_duplicate", "question looks like a duplicate")
+
+	ErrInvalidDifficulty        = newError(KindValidation, "invalid_difficulty", "difficulty must be from 1 to 5")
+	ErrInvalidEstimatedTime     = newError(KindValidation, "invalid_estimated_time", "estimated time must be positive")
+	ErrInvalidBloomLevel        = newError(KindValidation, "invalid_bloom_level", "unknown bloom's taxonomy level")
+	ErrInvalidLearningObjective = newError(KindValidation, "invalid_learning_objective", "learning objective is invalid")
+	ErrUnknownCodeLanguage      = newError(KindValidation, "unknown_code_language", "code language is not supported")
+	ErrInvalidCodeBlock         = newError(KindValidation, "invalid_code_block", "code block is invalid")
+	ErrInvalidProgramAnswer     = newError(KindValidation, "invalid_program_answer", "program question must have language and at least one test")
+	ErrInvalidParametricAnswer  = newError(KindValidation, "invalid_parametric_answer", "parametric question definition is invalid")
+	ErrNotParametricQuestion    = newError(KindValidation, "not_parametric_question", "question is not parametric")
+	ErrInvalidQuestionAnswer    = newError(KindValidation, "invalid_question_answer", "variants or answer do not match question type")
+	ErrInvalidResponse          = newError(KindValidation, "invalid_response", "response does not match question type")
+	ErrQuestionNotGradable      = newError(KindValidation, "question_not_gradable", "question type can not be graded automatically")
+)
+
+// DuplicateQuestionError is returned by AddQuestion when similar questions exist and creation is not forced
+type DuplicateQuestionError struct {
+	Similar []SimilarQuestion
+}
+
+func (e *DuplicateQuestionError) Error() string {
+	ids := make([]string, len(e.Similar))
+	for i, q := range e.Similar {
+		ids[i] = fmt.Sprintf("%d (%.2f)", q.ID, q.Similarity)
+	}
+	return fmt.Sprintf("%s of: %s, use force=true to create it anyway", ErrQuestionDuplicate.Error(), strings.Join(ids, ", "))
+}
+
+func (e *DuplicateQuestionError) Unwrap() error {
+	return ErrQuestionDuplicate
+}
+
+// quiz
+var (
+	ErrNotEnoughQuestions = newError(KindValidation, "not_enough_questions", "not enough questions to assemble quiz")
+	ErrQuizNotFound       = newError(KindNotFound, "quiz_not_found", "quiz is not found")
+)
+
+// attachment
+var (
+	ErrAttachmentNotFound       = newError(KindNotFound, "attachment_not_found", "attachment is not found")
+	ErrAttachmentTooLarge       = newError(KindTooLarge, "attachment_too_large", "attachment is too large")
+	ErrAttachmentTypeNotAllowed = newError(KindUnsupportedMediaType, "attachment_type_not_allowed", "attachment type is not allowed")
+	ErrAttachmentEmpty          = newError(KindValidation, "attachment_empty", "attachment is empty")
+)
+
+// submission
+var (
+	ErrSubmissionNotFound        = newError(KindNotFound, "submission_not_found", "submission is not found")
+	ErrNotProgramQuestion        = newError(KindValidation, "not_program_question", "question does not accept program submissions")
+	ErrEmptySubmission           = newError(KindValidation, "empty_submission", "submitted code is empty")
+	ErrSubmissionTooLarge        = newError(KindTooLarge, "submission_too_large", "submitted code is too large")
+	ErrUnsupportedRunnerLanguage = newError(KindValidation, "unsupported_runner_language", "language is not supported by runner")
+	ErrRunnerBusy                = newError(KindUnavailable, "runner_busy", "runner is busy, try again later")
+	ErrRunnerDisabled            = newError(KindUnavailable, "runner_disabled", "programs can not be run on this server")
+)
+
+// tag
+var (
+	ErrInvalidTag = newError(KindValidation, "invalid_tag", "tag is invalid")
+)
+
+// api token
+var (
+	ErrAPITokenNotFound = newError(KindNotFound, "api_token_not_found", "api token is not found")
+	ErrInvalidAPIToken  = newError(KindUnauthorized, "invalid_api_token", "api token is invalid, expired or revoked")
+	ErrInvalidScope     = newError(KindValidation, "invalid_scope", "api token scope is unknown")
+	ErrInvalidRole      = newError(KindValidation, "invalid_role", "role is unknown")
+	ErrInvalidExpiry    = newError(KindValidation, "invalid_expiry", "api token expiry must be in the future")
+	ErrEmptyTokenName   = newError(KindValidation, "empty_token_name", "api token name is empty or too long")
+	ErrEmptyTokenUser   = newError(KindValidation, "empty_token_user", "api token user is not set")
 )
 
 // user //TODO delete?
 var (
-	ErrUserNotFound = errors.New("user is not found")
+	ErrUserNotFound = newError(KindNotFound, "user_not_found", "user is not found")
 )
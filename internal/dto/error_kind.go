@@ -0,0 +1,144 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+)
+
+// Kind is the class of an error which decides the HTTP status, errors.Is(err, KindNotFound) checks it
+type Kind string
+
+const (
+	KindInternal             Kind = "internal"
+	KindBadRequest           Kind = "bad_request" // request can not be parsed
+	KindValidation           Kind = "validation"  // request is parsed but its values are not accepted
+	KindUnauthorized         Kind = "unauthorized"
+	KindForbidden            Kind = "forbidden"
+	KindNotFound             Kind = "not_found"
+	KindConflict             Kind = "conflict"
+	KindPreconditionFailed   Kind = "precondition_failed"
+	KindPreconditionRequired Kind = "precondition_required"
+	KindTooLarge             Kind = "too_large"
+	KindUnsupportedMediaType Kind = "unsupported_media_type"
+	KindUnavailable          Kind = "unavailable"
+)
+
+func (k Kind) Error() string {
+	return string(k)
+}
+
+// Error is a domain error with a kind and a machine-readable code like "question_not_found"
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func newError(kind Kind, code string, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches the error itself and its kind
+func (e *Error) Is(target error) bool {
+	kind, ok := target.(Kind)
+	return ok && kind == e.Kind
+}
+
+// FieldError binds an error to a field of the request, e.g. "difficulty" or "code_blocks[1]"
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func NewFieldError(field string, err error) *FieldError {
+	return &FieldError{
+		Field: field,
+		Err:   err,
+	}
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors holds all violations found in a request
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, field := range e {
+		messages[i] = field.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, field := range e {
+		errs[i] = field
+	}
+	return errs
+}
+
+// kinds in order of precedence when the chain matches several of them
+var kinds = []Kind{
+	KindUnauthorized, KindForbidden, KindNotFound, KindConflict, KindPreconditionFailed, KindPreconditionRequired,
+	KindValidation, KindBadRequest, KindTooLarge, KindUnsupportedMediaType, KindUnavailable,
+}
+
+// KindOf returns the kind of the error, errors of unknown kind are internal
+func KindOf(err error) Kind {
+	for _, kind := range kinds {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+
+	return KindInternal
+}
+
+// CodeOf returns the code of the first domain error in the chain, the kind is used if there is none
+func CodeOf(err error) string {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Code
+	}
+
+	return string(KindOf(err))
+}
+
+// FieldsOf collects field errors from the chain including joined errors
+func FieldsOf(err error) []*FieldError {
+	var fields []*FieldError
+
+	var walk func(error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+			return
+		case *FieldError:
+			fields = append(fields, e)
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+
+	return fields
+}
@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyKeyMaxLength limits Idempotency-Key header
+const IdempotencyKeyMaxLength = 255
+
+// IdempotencyKey is a create request of the user identified by the client key, Response is empty until
+// the request is completed. Version of the object from the response is sent as ETag, 0 means none.
+type IdempotencyKey struct {
+	UserID      int64
+	Key         string
+	RequestHash []byte
+	Response    json.RawMessage
+	Version     int64
+	ExpiresAt   time.Time
+}
+
+var (
+	ErrInvalidIdempotencyKey    = newError(KindBadRequest, "invalid_idempotency_key", "Idempotency-Key must be 1-255 printable characters")
+	ErrIdempotencyKeyReused     = newError(KindValidation, "idempotency_key_reused", "Idempotency-Key was used with another request")
+	ErrIdempotencyKeyInProgress = newError(KindConflict, "idempotency_key_in_progress", "request with this Idempotency-Key is in progress")
+)
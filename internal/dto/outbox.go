@@ -0,0 +1,10 @@
+package dto
+
+// OutboxEvent is a notification waiting for delivery to the room of the notifier
+type OutboxEvent struct {
+	ID       int64
+	Room     string
+	Event    string
+	Data     string
+	Attempts int
+}
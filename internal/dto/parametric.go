@@ -0,0 +1,35 @@
+package dto
+
+// ParametricParameter is a random parameter, its value is Min + k*Step, not greater than Max
+type ParametricParameter struct {
+	Name string  `json:"name"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Step float64 `json:"step,omitempty"` // 0 - 1
+}
+
+// ParametricDefinition is the answer of a parametric question. Placeholders like {d} in text, code and
+// variants are replaced with parameter values. Distractors are expressions of wrong variants,
+// without them learner enters the number.
+type ParametricDefinition struct {
+	Parameters  []ParametricParameter `json:"parameters"`
+	Answer      string                `json:"answer"`
+	Precision   int                   `json:"precision,omitempty"` // decimal places of the answer
+	Tolerance   float64               `json:"tolerance,omitempty"` // allowed absolute error of entered answer
+	Distractors []string              `json:"distractors,omitempty"`
+}
+
+// RenderedQuestion is a parametric question with generated values, answer is filled for moderators only
+type RenderedQuestion struct {
+	QuestionID int64                  `json:"question_id,string"`
+	Seed       int64                  `json:"seed,string"`
+	Text       string                 `json:"text,omitempty"`
+	Code       string                 `json:"code,omitempty"`
+	Parameters map[string]float64     `json:"parameters"`
+	Variants   map[string]interface{} `json:"variants,omitempty"`
+	Answer     map[string]interface{} `json:"answer,omitempty"`
+}
+
+type ParametricCheckResult struct {
+	Correct bool `json:"correct"`
+}
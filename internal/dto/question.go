@@ -5,6 +5,8 @@ package dto
 type InputQuestion struct {
 	Text            string                 `json:"text,omitempty"`
 	Code            string                 `json:"code,omitempty"`
+	CodeLanguage    string                 `json:"code_language,omitempty"`
+	CodeBlocks      []CodeBlock            `json:"code_blocks,omitempty"`
 	Variants        map[string]interface{} `json:"variants"`
 	Answer          map[string]interface{} `json:"answer"`
 	TypeID          int64                  `json:"type_id,string,omitempty"`
@@ -13,32 +15,168 @@ type InputQuestion struct {
 	CreatorUserID   int64                  `json:"creator_user_id,string,omitempty"`
 	ModeratorUserID int64                  `json:"moderator_user_id,string,omitempty"`
 	ModeratedAt     string                 `json:"moderated_at,omitempty"`
+	Tags            []string               `json:"tags,omitempty"`
+
+	Difficulty         int        `json:"difficulty,omitempty"`     // 1-5, 0 - not set
+	EstimatedTime      int        `json:"estimated_time,omitempty"` // seconds, 0 - not set
+	BloomLevel         BloomLevel `json:"bloom_level,omitempty"`
+	LearningObjectives []string   `json:"learning_objectives,omitempty"`
+
+	// AttachmentIDs are uploaded attachments, attachments referenced from text/variants are added automatically
+	AttachmentIDs Int64Array `json:"attachment_ids,omitempty"`
+
+	SourceQuestionID int64 `json:"-"` // set by clone only
+
+	// Events are notifications written to the outbox together with the question
+	Events []OutboxEvent `json:"-"`
 }
 
 // internal/output types //TODO
 
 type Question struct {
-	ID          int64                  `json:"id,string,omitempty"`
-	Text        string                 `json:"text,omitempty"`
-	Code        string                 `json:"code,omitempty"`
-	Variants    map[string]interface{} `json:"variants"`
-	Answer      map[string]interface{} `json:"answer"`
-	Type        QuestionType           `json:"type,omitempty"`
-	Status      QuestionStatusName     `json:"status,omitempty"`
-	SubjectID   int64                  `json:"subject_id,string,omitempty"`
-	SubjectName string                 `json:"subject_name,omitempty"`
-	Creator     User                   `json:"creator,omitempty"`
-	Moderator   User                   `json:"moderator,omitempty"`
-	ModeratedAt string                 `json:"moderated_at,omitempty"`
-	CreatedAt   string                 `json:"created_at,omitempty"`
+	ID           int64                  `json:"id,string,omitempty"`
+	Text         string                 `json:"text,omitempty"`
+	Code         string                 `json:"code,omitempty"`
+	CodeLanguage string                 `json:"code_language,omitempty"`
+	CodeBlocks   []CodeBlock            `json:"code_blocks,omitempty"`
+	Variants     map[string]interface{} `json:"variants"`
+	Answer       map[string]interface{} `json:"answer"`
+	Type         QuestionType           `json:"type,omitempty"`
+	Status       QuestionStatusName     `json:"status,omitempty"`
+	SubjectID    int64                  `json:"subject_id,string,omitempty"`
+	SubjectName  string                 `json:"subject_name,omitempty"`
+	Creator      User                   `json:"creator,omitempty"`
+	Moderator    User                   `json:"moderator,omitempty"`
+	ModeratedAt  string                 `json:"moderated_at,omitempty"`
+	CreatedAt    string                 `json:"created_at,omitempty"`
+	Tags         []string               `json:"tags,omitempty"`
+
+	Difficulty         int        `json:"difficulty,omitempty"`
+	EstimatedTime      int        `json:"estimated_time,omitempty"`
+	BloomLevel         BloomLevel `json:"bloom_level,omitempty"`
+	LearningObjectives []string   `json:"learning_objectives,omitempty"`
+
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	SourceQuestionID int64 `json:"source_question_id,string,omitempty"`
+
+	DeletedAt string `json:"deleted_at,omitempty"` // set for questions in the trash
+	Version   int64  `json:"version"`              // sent back in If-Match to update, delete or restore the question
+}
+
+// CodeBlock is an additional code snippet of a question, e.g. several files of one program
+type CodeBlock struct {
+	Title    string `json:"title,omitempty"`
+	Language string `json:"language"`
+	Code     string `json:"code"`
 }
 
+type CodeToken struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// HighlightedCode is a tokenized code block ready for syntax highlighting on the client
+type HighlightedCode struct {
+	Title    string      `json:"title,omitempty"`
+	Language string      `json:"language"`
+	Tokens   []CodeToken `json:"tokens"`
+}
+
+// QuestionFilter is used for questions lists, -1 means "any"
+type QuestionFilter struct {
+	CreatorUserID int64
+	SubjectID     int64
+	StatusID      int64
+	TypeID        int64
+	Tags          []string // question must have all of them
+
+	IncludeSubSubjects bool // SubjectID matches the whole subtree
+	MinDifficulty      int
+	MaxDifficulty      int
+	BloomLevels        []BloomLevel
+
+	Deleted bool // questions from the trash instead of live ones
+}
+
+func NewQuestionFilter() QuestionFilter {
+	return QuestionFilter{
+		CreatorUserID: -1,
+		SubjectID:     -1,
+		StatusID:      -1,
+		TypeID:        -1,
+		MinDifficulty: -1,
+		MaxDifficulty: -1,
+	}
+}
+
+// QuestionSearchResult is a found question, headlines are fragments of text and code as escaped html
+// with matches wrapped in <mark>, they can be inserted into a page as is
+type QuestionSearchResult struct {
+	Question     Question `json:"question"`
+	Rank         float64  `json:"rank"`
+	Headline     string   `json:"headline,omitempty"`
+	CodeHeadline string   `json:"code_headline,omitempty"`
+}
+
+// SimilarQuestion is a short view of a question which looks like a duplicate
+type SimilarQuestion struct {
+	ID          int64   `json:"id,string"`
+	Text        string  `json:"text,omitempty"`
+	SubjectID   int64   `json:"subject_id,string,omitempty"`
+	SubjectName string  `json:"subject_name,omitempty"`
+	Similarity  float64 `json:"similarity,omitempty"`
+}
+
+type SimilarQuestionPair struct {
+	First      SimilarQuestion `json:"first"`
+	Second     SimilarQuestion `json:"second"`
+	Similarity float64         `json:"similarity"`
+}
+
+// DuplicateCluster is a group of questions connected by high text similarity
+type DuplicateCluster struct {
+	Questions     []SimilarQuestion `json:"questions"`
+	MaxSimilarity float64           `json:"max_similarity"`
+}
+
+// BloomLevel is a level of Bloom's taxonomy
+type BloomLevel string
+
+const (
+	BloomLevelRemember   BloomLevel = "remember"
+	BloomLevelUnderstand BloomLevel = "understand"
+	BloomLevelApply      BloomLevel = "apply"
+	BloomLevelAnalyze    BloomLevel = "analyze"
+	BloomLevelEvaluate   BloomLevel = "evaluate"
+	BloomLevelCreate     BloomLevel = "create"
+)
+
+var BloomLevels = []BloomLevel{
+	BloomLevelRemember,
+	BloomLevelUnderstand,
+	BloomLevelApply,
+	BloomLevelAnalyze,
+	BloomLevelEvaluate,
+	BloomLevelCreate,
+}
+
+const (
+	QuestionDifficultyMin = 1
+	QuestionDifficultyMax = 5
+)
+
 type QuestionTypeName string
 
 const (
 	QuestionTypeNameTest       = "Тест"
 	QuestionTypeNameComparison = "Сопоставление"
 	QuestionTypeNameText       = "Текст"
+	QuestionTypeNameProgram    = "Программа"
+	QuestionTypeNameParametric = "Параметрический"
+	QuestionTypeNameOrdering   = "Упорядочивание"
+	QuestionTypeNameNumeric    = "Числовой"
+	QuestionTypeNameCloze      = "Пропуски"
 )
 
 type QuestionType struct {
@@ -5,6 +5,21 @@ type InputQuiz struct {
 	Description   string     `json:"description,omitempty"`
 	CreatorUserID int64      `json:"creator_user_id,string,omitempty"`
 	QuestionIDs   Int64Array `json:"question_ids,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+
+	// Assembly is used to pick questions automatically if QuestionIDs is empty
+	Assembly *QuizAssembly `json:"assembly,omitempty"`
+}
+
+// QuizAssembly describes which approved questions can be used for a quiz
+type QuizAssembly struct {
+	SubjectID     int64        `json:"subject_id,string"` // with sub subjects
+	Count         int          `json:"count"`
+	MinDifficulty int          `json:"min_difficulty,omitempty"`
+	MaxDifficulty int          `json:"max_difficulty,omitempty"`
+	BloomLevels   []BloomLevel `json:"bloom_levels,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+	MaxTotalTime  int          `json:"max_total_time,omitempty"` // seconds, 0 - no limit
 }
 
 type Quiz struct {
@@ -15,4 +30,10 @@ type Quiz struct {
 	QuestionIDs Int64Array `json:"question_ids,omitempty"`
 	CreatedAt   string     `json:"created_at"`
 	UpdatedAt   string     `json:"updated_at"`
+	Tags        []string   `json:"tags,omitempty"`
+
+	SourceQuizID int64 `json:"source_quiz_id,string,omitempty"`
+
+	DeletedAt string `json:"deleted_at,omitempty"` // set for quizzes in the trash
+	Version   int64  `json:"version"`              // sent back in If-Match to delete or restore the quiz
 }
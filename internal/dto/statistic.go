@@ -8,6 +8,7 @@ type Statistic struct {
 	TestCountCreatedByCurrentUser     int                    `json:"test_count_created_by_current_user"`
 	QuestionCountCreatedByCurrentUser int                    `json:"question_count_created_by_current_user"`
 	QuestionCountsBySubject           []SubjectStatisticItem `json:"question_counts_by_subject,omitempty"`
+	TagCounts                         []Tag                  `json:"tag_counts,omitempty"`
 }
 
 type SubjectStatisticItem struct {
@@ -23,4 +23,73 @@ type Subject struct {
 	UpdatedAt             string `json:"updated_at"`
 	QuestionCount         int    `json:"question_count"`
 	ApprovedQuestionCount int    `json:"approved_question_count"`
+	DeletedAt             string `json:"deleted_at,omitempty"` // set for subjects in the trash
+	Version               int64  `json:"version"`              // sent back in If-Match to update, move, delete or restore the subject
+}
+
+// SubjectDeleteMode says what to do with the subtree content when a subject is deleted
+type SubjectDeleteMode string
+
+const (
+	SubjectDeleteRefuse   SubjectDeleteMode = "refuse"   // only a subject without sub subjects and questions is deleted
+	SubjectDeleteCascade  SubjectDeleteMode = "cascade"  // sub subjects and questions go to the trash with the subject
+	SubjectDeleteReassign SubjectDeleteMode = "reassign" // questions of the subtree are moved to another subject first
+)
+
+var SubjectDeleteModes = []SubjectDeleteMode{
+	SubjectDeleteRefuse,
+	SubjectDeleteCascade,
+	SubjectDeleteReassign,
+}
+
+// SubjectRef is a short view of a subject
+type SubjectRef struct {
+	ID       int64  `json:"id,string"`
+	Name     string `json:"name"`
+	ParentId int64  `json:"parent_id,string,omitempty"`
+}
+
+// QuizRef is a short view of a quiz
+type QuizRef struct {
+	ID   int64  `json:"id,string"`
+	Name string `json:"name"`
+}
+
+// SubjectDeletePreview lists everything affected by deletion of the subject
+type SubjectDeletePreview struct {
+	SubjectID   int64        `json:"subject_id,string"`
+	Descendants []SubjectRef `json:"descendants"`
+	QuestionIDs Int64Array   `json:"question_ids"`
+	Quizzes     []QuizRef    `json:"quizzes"` // quizzes which use questions of the subtree
+}
+
+// IsEmpty is true when the subject has neither sub subjects nor questions
+func (p SubjectDeletePreview) IsEmpty() bool {
+	return len(p.Descendants) == 0 && len(p.QuestionIDs) == 0
+}
+
+// SubjectTreeNode is a subject with question counts rolled up over its subtree
+type SubjectTreeNode struct {
+	ID          int64  `json:"id,string"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Active      bool   `json:"active"`
+	ParentId    int64  `json:"parent_id,string,omitempty"`
+
+	QuestionCount         int `json:"question_count"` // questions of the subject itself
+	TotalQuestionCount    int `json:"total_question_count"`
+	ApprovedQuestionCount int `json:"approved_question_count"`
+	PendingQuestionCount  int `json:"pending_question_count"`
+
+	Children []SubjectTreeNode `json:"children"`
+}
+
+// SubjectMaintainer is a user who can edit the subject and moderate its questions,
+// the grant is inherited by the whole subtree
+type SubjectMaintainer struct {
+	SubjectID       int64  `json:"subject_id,string"`
+	UserID          int64  `json:"user_id,string"`
+	GrantedByUserID int64  `json:"granted_by_user_id,string,omitempty"`
+	CreatedAt       string `json:"created_at,omitempty"`
+	Inherited       bool   `json:"inherited"` // granted on an ancestor subject
 }
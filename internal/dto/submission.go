@@ -0,0 +1,75 @@
+package dto
+
+// ProgramTest is a hidden test case of a program question, program gets Input on stdin
+// and must print ExpectedOutput (trailing spaces and empty lines are ignored)
+type ProgramTest struct {
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expected_output"`
+}
+
+// ProgramAnswer is the answer of a program question
+type ProgramAnswer struct {
+	Language string        `json:"language"`
+	Tests    []ProgramTest `json:"tests"`
+}
+
+type SubmissionStatus string
+
+const (
+	SubmissionStatusAccepted     SubmissionStatus = "accepted"      // all tests passed
+	SubmissionStatusPartial      SubmissionStatus = "partial"       // some tests passed
+	SubmissionStatusRejected     SubmissionStatus = "rejected"      // no tests passed
+	SubmissionStatusCompileError SubmissionStatus = "compile_error" // program was not built
+)
+
+type TestStatus string
+
+const (
+	TestStatusPassed       TestStatus = "passed"
+	TestStatusWrongAnswer  TestStatus = "wrong_answer"
+	TestStatusTimeout      TestStatus = "timeout"
+	TestStatusRuntimeError TestStatus = "runtime_error"
+)
+
+// TestResult is a result of one hidden test, expected output is not revealed
+type TestResult struct {
+	Index    int        `json:"index"`
+	Status   TestStatus `json:"status"`
+	Duration int64      `json:"duration_ms"`
+}
+
+type Submission struct {
+	ID           int64            `json:"id,string"`
+	QuestionID   int64            `json:"question_id,string"`
+	UserID       int64            `json:"user_id,string"`
+	Language     string           `json:"language"`
+	Code         string           `json:"code"`
+	Status       SubmissionStatus `json:"status"`
+	Score        float64          `json:"score"`
+	PassedTests  int              `json:"passed_tests"`
+	TotalTests   int              `json:"total_tests"`
+	CompileError string           `json:"compile_error,omitempty"`
+	Results      []TestResult     `json:"results"`
+	CreatedAt    string           `json:"created_at,omitempty"`
+}
+
+// RunRequest is a program to be built and run once per input
+type RunRequest struct {
+	Language string
+	Code     string
+	Inputs   []string
+}
+
+type RunOutput struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	TimedOut bool
+	Duration int64 // milliseconds
+}
+
+// RunResult contains one output per input of RunRequest, or compile error only
+type RunResult struct {
+	CompileError string
+	Outputs      []RunOutput
+}
@@ -0,0 +1,8 @@
+package dto
+
+type Tag struct {
+	ID            int64  `json:"id,string"`
+	Name          string `json:"name"`
+	QuestionCount int    `json:"question_count"`
+	QuizCount     int    `json:"quiz_count"`
+}
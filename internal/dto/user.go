@@ -1,5 +1,7 @@
 package dto
 
+import "slices"
+
 type Role string //TODO common type for all services
 
 const (
@@ -8,6 +10,14 @@ const (
 	RoleUser      = "Creator"
 )
 
+// Roles are all roles known to the service
+var Roles = []Role{RoleAdmin, RoleModerator, RoleUser}
+
+// IsKnown says whether the role is one of Roles
+func (r Role) IsKnown() bool {
+	return slices.Contains(Roles, r)
+}
+
 type User struct { //TODO common type for all services
 	ID        int    `json:"id,string,omitempty"`
 	Login     string `json:"login,omitempty"`
@@ -15,3 +25,11 @@ type User struct { //TODO common type for all services
 	Role      Role   `json:"role,omitempty"`
 	CreatedAt string `json:"created_at,omitempty"`
 }
+
+// Ownership says who owns a question or a quiz, rows in the trash are included
+type Ownership struct {
+	CreatorUserID int64
+	SubjectID     int64              // questions only
+	StatusName    QuestionStatusName // questions only
+	Deleted       bool
+}
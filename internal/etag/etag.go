@@ -0,0 +1,51 @@
+// Package etag carries the object version expected by If-Match from HTTP transport to storages
+package etag
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+type versionKey struct{}
+
+// NewContext returns ctx carrying the expected version, 0 means any version ("If-Match: *")
+func NewContext(ctx context.Context, version int64) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+// FromContext returns the version put by NewContext
+func FromContext(ctx context.Context) (int64, bool) {
+	version, ok := ctx.Value(versionKey{}).(int64)
+	return version, ok
+}
+
+// Format returns the strong ETag of the version
+func Format(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// Parse reads If-Match header, "*" gives 0. Weak tags are accepted since versions are exact anyway.
+func Parse(header string) (int64, bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, true
+	}
+
+	value, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err != nil {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// Matches says whether the current version satisfies the expected one
+func Matches(expected int64, current int64) bool {
+	return expected == 0 || expected == current
+}
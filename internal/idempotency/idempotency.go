@@ -0,0 +1,151 @@
+// Package idempotency lets clients retry create requests safely: the first response is stored with
+// Idempotency-Key of the user and returned to the repeated requests
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+	"time"
+	"unicode"
+)
+
+type keyKey struct{}
+
+// NewContext returns ctx carrying Idempotency-Key of the request
+func NewContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// FromContext returns the key put by NewContext
+func FromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(keyKey{}).(string)
+	return key, ok
+}
+
+// versioned is transport.Versioned: the response of a single versioned object, its version is sent as ETag
+type versioned interface {
+	EntityVersion() int64
+}
+
+// replayed is the stored response of a versioned object, it is sent with the stored ETag
+type replayed struct {
+	json.RawMessage
+	version int64
+}
+
+func (r replayed) EntityVersion() int64 {
+	return r.version
+}
+
+// Keeper stores responses of requests made with Idempotency-Key for ttl
+type Keeper struct {
+	storage model.IdempotencyKeysStorage
+	ttl     time.Duration
+	logger  *logrus.Logger
+}
+
+func NewKeeper(storage model.IdempotencyKeysStorage, ttl time.Duration, logger *logrus.Logger) *Keeper {
+	return &Keeper{
+		storage: storage,
+		ttl:     ttl,
+		logger:  logger,
+	}
+}
+
+// Middleware makes the endpoint idempotent for requests with the key, requests without it are passed as is.
+// Failed requests do not keep the key, so they may be retried with it.
+func (k *Keeper) Middleware() endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			key, ok := FromContext(ctx)
+			if !ok {
+				return next(ctx, request)
+			}
+			if !validKey(key) {
+				return nil, dto.ErrInvalidIdempotencyKey
+			}
+
+			principal, ok := policy.FromContext(ctx)
+			if !ok {
+				return nil, dto.ErrForbidden
+			}
+
+			payload, err := json.Marshal(request)
+			if err != nil {
+				return nil, err
+			}
+			hash := sha256.Sum256(payload)
+
+			reservation := dto.IdempotencyKey{
+				UserID:      principal.UserID,
+				Key:         key,
+				RequestHash: hash[:],
+				ExpiresAt:   time.Now().Add(k.ttl),
+			}
+
+			stored, reserved, err := k.storage.ReserveIdempotencyKey(ctx, reservation)
+			if err != nil {
+				return nil, err
+			}
+
+			if !reserved {
+				switch {
+				case !bytes.Equal(stored.RequestHash, reservation.RequestHash):
+					return nil, dto.ErrIdempotencyKeyReused
+				case stored.Response == nil:
+					return nil, dto.ErrIdempotencyKeyInProgress
+				case stored.Version != 0:
+					return replayed{RawMessage: stored.Response, version: stored.Version}, nil
+				default:
+					return stored.Response, nil
+				}
+			}
+
+			response, err := next(ctx, request)
+			if err != nil {
+				if releaseErr := k.storage.ReleaseIdempotencyKey(ctx, principal.UserID, key); releaseErr != nil {
+					k.logger.WithFields(logrus.Fields{
+						"key":   key,
+						"error": releaseErr,
+					}).Error("unable to release idempotency key")
+				}
+				return response, err
+			}
+
+			if reservation.Response, err = json.Marshal(response); err != nil {
+				return nil, err
+			}
+			if v, ok := response.(versioned); ok {
+				reservation.Version = v.EntityVersion()
+			}
+			// the object is created already, the client gets the response even if it is not stored
+			if err = k.storage.SaveIdempotentResponse(ctx, reservation); err != nil {
+				k.logger.WithFields(logrus.Fields{
+					"key":   key,
+					"error": err,
+				}).Error("unable to save idempotent response")
+			}
+
+			return response, nil
+		}
+	}
+}
+
+func validKey(key string) bool {
+	if key == "" || len(key) > dto.IdempotencyKeyMaxLength {
+		return false
+	}
+	for _, r := range key {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
+)
+
+// memoryStorage is model.IdempotencyKeysStorage in memory, keys never expire
+type memoryStorage struct {
+	keys map[string]dto.IdempotencyKey
+}
+
+func (m *memoryStorage) ReserveIdempotencyKey(_ context.Context, key dto.IdempotencyKey) (dto.IdempotencyKey, bool, error) {
+	if stored, ok := m.keys[key.Key]; ok {
+		return stored, false, nil
+	}
+	m.keys[key.Key] = key
+	return key, true, nil
+}
+
+func (m *memoryStorage) SaveIdempotentResponse(_ context.Context, key dto.IdempotencyKey) error {
+	m.keys[key.Key] = key
+	return nil
+}
+
+func (m *memoryStorage) ReleaseIdempotencyKey(_ context.Context, _ int64, key string) error {
+	delete(m.keys, key)
+	return nil
+}
+
+func (m *memoryStorage) PurgeIdempotencyKeys(context.Context) (int64, error) {
+	return 0, nil
+}
+
+type createResponse struct {
+	ID      int64 `json:"id"`
+	Version int64 `json:"-"`
+}
+
+func (r createResponse) EntityVersion() int64 { return r.Version }
+
+func TestMiddlewareReplaysResponse(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	storage := &memoryStorage{keys: map[string]dto.IdempotencyKey{}}
+	keeper := NewKeeper(storage, time.Hour, logger)
+
+	calls := 0
+	create := keeper.Middleware()(func(_ context.Context, request interface{}) (interface{}, error) {
+		calls++
+		if request == "fail" {
+			return nil, dto.ErrForbidden
+		}
+		return createResponse{ID: int64(calls), Version: 7}, nil
+	})
+
+	ctx := policy.NewContext(context.Background(), policy.Principal{UserID: 1, Role: dto.RoleUser})
+	ctx = NewContext(ctx, "key-1")
+
+	first, err := create(ctx, "question")
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+
+	second, err := create(ctx, "question")
+	if err != nil {
+		t.Fatalf("repeated request: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("endpoint is called %d times, want 1", calls)
+	}
+
+	v, ok := second.(interface{ EntityVersion() int64 })
+	if !ok || v.EntityVersion() != 7 {
+		t.Errorf("replayed response %#v has no version 7", second)
+	}
+
+	firstBody, _ := json.Marshal(first)
+	secondBody, err := json.Marshal(second)
+	if err != nil || string(firstBody) != string(secondBody) {
+		t.Errorf("replayed body %s (%v), want %s", secondBody, err, firstBody)
+	}
+
+	if _, err = create(ctx, "other question"); !errors.Is(err, dto.ErrIdempotencyKeyReused) {
+		t.Errorf("reused key: got %v, want %v", err, dto.ErrIdempotencyKeyReused)
+	}
+
+	failCtx := NewContext(ctx, "key-2")
+	if _, err = create(failCtx, "fail"); !errors.Is(err, dto.ErrForbidden) {
+		t.Fatalf("failed request: got %v", err)
+	}
+	if _, ok := storage.keys["key-2"]; ok {
+		t.Errorf("key of the failed request is kept")
+	}
+}
@@ -0,0 +1,49 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+// Purger removes expired idempotency keys every interval, it does not depend on the trash purge
+type Purger struct {
+	storage  model.IdempotencyKeysStorage
+	logger   *logrus.Logger
+	interval time.Duration
+}
+
+func NewPurger(storage model.IdempotencyKeysStorage, interval time.Duration, logger *logrus.Logger) *Purger {
+	return &Purger{
+		storage:  storage,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Run purges expired keys every interval until ctx is done
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		keys, err := p.storage.PurgeIdempotencyKeys(ctx)
+		switch {
+		case err != nil:
+			p.logger.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("unable to purge idempotency keys")
+		case keys != 0:
+			p.logger.WithFields(logrus.Fields{
+				"keys": keys,
+			}).Debug("expired idempotency keys purged")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
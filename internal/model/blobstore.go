@@ -0,0 +1,13 @@
+package model
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore keeps binary content (attachments) by key
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,12 @@
+package model
+
+import (
+	"context"
+	"quiz_backend_core/internal/dto"
+)
+
+// CodeRunner builds and runs untrusted programs in isolation
+type CodeRunner interface {
+	Languages() []string
+	Run(ctx context.Context, request dto.RunRequest) (dto.RunResult, error)
+}
@@ -2,36 +2,98 @@ package model
 
 import (
 	"context"
+	"io"
 	"quiz_backend_core/internal/dto"
 )
 
 type Subjects interface {
 	GetSubjects(ctx context.Context) ([]dto.Subject, error)
-	AddSubject(ctx context.Context, subject dto.Subject) (int64, error)
-	UpdateSubject(ctx context.Context, subject dto.Subject) error
-	DeleteSubjectByID(ctx context.Context, id int64) error
+	GetSubjectTree(ctx context.Context, rootID int64, depth int) ([]dto.SubjectTreeNode, error)
+	GetSubjectByID(ctx context.Context, id int64) (dto.Subject, error)
+	AddSubject(ctx context.Context, userID int64, subject dto.Subject) (int64, error)
+	UpdateSubject(ctx context.Context, userID int64, subject dto.Subject) error
+	DeleteSubjectByID(ctx context.Context, userID int64, id int64, mode dto.SubjectDeleteMode, targetSubjectID int64) (dto.SubjectDeletePreview, error)
+	GetSubjectDeletePreview(ctx context.Context, id int64) (dto.SubjectDeletePreview, error)
+	MoveSubject(ctx context.Context, userID int64, id int64, parentID int64) error
+	MergeSubjects(ctx context.Context, userID int64, sourceID int64, targetID int64) error
+	GetDeletedSubjects(ctx context.Context) ([]dto.Subject, error)
+	RestoreSubject(ctx context.Context, userID int64, id int64) error
+
+	GetSubjectMaintainers(ctx context.Context, subjectID int64) ([]dto.SubjectMaintainer, error)
+	GrantSubjectMaintainer(ctx context.Context, userID int64, subjectID int64, maintainerUserID int64) error
+	RevokeSubjectMaintainer(ctx context.Context, userID int64, subjectID int64, maintainerUserID int64) error
 
 	GetStatistic(ctx context.Context, userId int64, userRole dto.Role) (dto.Statistic, error)
 }
 
 type Questions interface {
-	GetQuestions(ctx context.Context, creatorUserID, subjectId, statusID int64) ([]dto.Question, error)
+	GetQuestions(ctx context.Context, filter dto.QuestionFilter) ([]dto.Question, error)
+	SearchQuestions(ctx context.Context, search string, filter dto.QuestionFilter, limit, offset int) ([]dto.QuestionSearchResult, error)
+	GetQuestionByID(ctx context.Context, questionID int64) (dto.Question, error)
 	GetQuestionTypes(ctx context.Context) ([]dto.QuestionType, error)
 	GetQuestionStatuses(ctx context.Context) ([]dto.QuestionStatus, error)
-	AddQuestion(ctx context.Context, question dto.InputQuestion) (int64, error)
+	AddQuestion(ctx context.Context, question dto.InputQuestion, force bool) (int64, []dto.SimilarQuestion, error)
 	UpdateQuestionByID(ctx context.Context, questionID int64, question dto.InputQuestion) error
 	ModerateQuestion(ctx context.Context, ID int64, approve bool, comment string) error
 	DeleteQuestion(ctx context.Context, ID int64) error
+	GetDeletedQuestions(ctx context.Context, filter dto.QuestionFilter) ([]dto.Question, error)
+	RestoreQuestion(ctx context.Context, ID int64) error
+
+	GetQuestionCode(ctx context.Context, questionID int64) ([]dto.HighlightedCode, error)
+	RenderQuestion(ctx context.Context, questionID int64, seed int64) (dto.RenderedQuestion, error)
+	CheckParametricAnswer(ctx context.Context, questionID int64, seed int64, value float64) (dto.ParametricCheckResult, error)
+	GradeAnswer(ctx context.Context, questionID int64, response map[string]interface{}) (dto.GradeResult, error)
+	CloneQuestion(ctx context.Context, questionID int64) (int64, error)
+	GetDuplicateClusters(ctx context.Context, subjectID int64, threshold float64) ([]dto.DuplicateCluster, error)
 }
 
 type Quizzes interface {
-	GetQuizzes(ctx context.Context, creatorUserID int64) ([]dto.Quiz, error)
+	GetQuizzes(ctx context.Context, creatorUserID int64, tags []string) ([]dto.Quiz, error)
 	GetQuestionsByQuizID(ctx context.Context, quizID int64) ([]dto.Question, error)
 	GetQuizByID(ctx context.Context, quizID int64) (dto.Quiz, error)
 	AddQuiz(ctx context.Context, quiz dto.InputQuiz) (int64, error)
 	DeleteQuizByID(ctx context.Context, quizID int64) error
+	GetDeletedQuizzes(ctx context.Context, creatorUserID int64) ([]dto.Quiz, error)
+	RestoreQuiz(ctx context.Context, quizID int64) error
+	CloneQuiz(ctx context.Context, userID int64, quizID int64, name string) (int64, error)
+}
+
+type Tags interface {
+	GetTags(ctx context.Context, prefix string, limit int) ([]dto.Tag, error)
+}
+
+type Attachments interface {
+	UploadAttachment(ctx context.Context, attachment dto.Attachment, content []byte) (dto.Attachment, error)
+	GetAttachment(ctx context.Context, attachmentID int64) (dto.Attachment, io.ReadCloser, error)
+}
+
+type Submissions interface {
+	SubmitProgram(ctx context.Context, submission dto.Submission) (dto.Submission, error)
+	GetSubmissionByID(ctx context.Context, userID int64, userRole dto.Role, submissionID int64) (dto.Submission, error)
+	GetSubmissions(ctx context.Context, userID int64, questionID int64) ([]dto.Submission, error)
+}
+
+type APITokens interface {
+	CreateAPIToken(ctx context.Context, userID int64, input dto.InputAPIToken) (dto.APIToken, string, error)
+	GetAPITokens(ctx context.Context, userID int64) ([]dto.APIToken, error)
+	RevokeAPIToken(ctx context.Context, tokenID int64) error
+	AuthenticateAPIToken(ctx context.Context, secret string) (dto.APIToken, error)
+}
+
+type Audit interface {
+	GetAuditEntries(ctx context.Context, filter dto.AuditFilter) ([]dto.AuditEntry, error)
 }
 
 type SubjectsMiddleware func(Subjects) Subjects
 
 type QuestionsMiddleware func(Questions) Questions
+
+type TagsMiddleware func(Tags) Tags
+
+type AttachmentsMiddleware func(Attachments) Attachments
+
+type SubmissionsMiddleware func(Submissions) Submissions
+
+type APITokensMiddleware func(APITokens) APITokens
+
+type AuditMiddleware func(Audit) Audit
@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"quiz_backend_core/internal/dto"
+	"time"
 )
 
 type Pinger interface {
@@ -11,27 +12,99 @@ type Pinger interface {
 
 type SubjectsStorage interface {
 	GetSubjects(ctx context.Context) ([]dto.Subject, error)
+	GetSubjectTree(ctx context.Context, rootID int64, depth int) ([]dto.SubjectTreeNode, error)
 	AddSubject(ctx context.Context, subject dto.Subject) (int64, error)
 	UpdateSubject(ctx context.Context, subject dto.Subject) error
-	DeleteSubjectByID(ctx context.Context, id int64) error
+	DeleteSubjectByID(ctx context.Context, id int64, mode dto.SubjectDeleteMode, targetSubjectID int64) (dto.SubjectDeletePreview, error)
+	GetSubjectDeletePreview(ctx context.Context, id int64) (dto.SubjectDeletePreview, error)
+	MoveSubject(ctx context.Context, id int64, parentID int64) error
+	MergeSubjects(ctx context.Context, sourceID int64, targetID int64) error
 	GetStatistic(ctx context.Context, userId int64) (dto.Statistic, error)
+
+	GetSubjectByID(ctx context.Context, id int64) (dto.Subject, error)
+	IsSubjectMaintainer(ctx context.Context, subjectID int64, userID int64) (bool, error)
+	GetSubjectMaintainers(ctx context.Context, subjectID int64) ([]dto.SubjectMaintainer, error)
+	AddSubjectMaintainer(ctx context.Context, maintainer dto.SubjectMaintainer) error
+	DeleteSubjectMaintainer(ctx context.Context, subjectID int64, userID int64) error
+
+	GetDeletedSubjects(ctx context.Context) ([]dto.Subject, error)
+	RestoreSubject(ctx context.Context, id int64) error
+	PurgeSubjects(ctx context.Context, retention time.Duration) (int64, error)
 }
 
 type QuestionsStorage interface {
-	GetQuestions(ctx context.Context, creatorUserID, subjectID, statusID int64) ([]dto.Question, error)
+	GetQuestions(ctx context.Context, filter dto.QuestionFilter) ([]dto.Question, error)
+	SearchQuestions(ctx context.Context, search string, filter dto.QuestionFilter, limit, offset int) ([]dto.QuestionSearchResult, error)
 	GetQuestionByID(ctx context.Context, questionID int64) (dto.Question, error)
+	GetQuestionOwnership(ctx context.Context, questionID int64) (dto.Ownership, error)
+	GetMissingQuestionIDs(ctx context.Context, questionIDs []int64) ([]int64, error)
+	FindSimilarQuestions(ctx context.Context, subjectID int64, text string, threshold float64, limit int) ([]dto.SimilarQuestion, error)
+	GetSimilarQuestionPairs(ctx context.Context, subjectID int64, threshold float64) ([]dto.SimilarQuestionPair, error)
 	GetQuestionTypes(ctx context.Context) ([]dto.QuestionType, error)
 	GetQuestionStatuses(ctx context.Context) ([]dto.QuestionStatus, error)
 	AddQuestion(ctx context.Context, question dto.InputQuestion) (int64, error)
 	UpdateQuestionByID(ctx context.Context, ID int64, question dto.InputQuestion) error
 	UpdateQuestionStatus(ctx context.Context, ID int64, status dto.QuestionStatusName) error
 	DeleteQuestion(ctx context.Context, ID int64) error
+
+	RestoreQuestion(ctx context.Context, ID int64) error
+	PurgeQuestions(ctx context.Context, retention time.Duration) ([]dto.Attachment, error)
 }
 
 type QuizzesStorage interface {
-	GetQuizzes(ctx context.Context, creatorUserID int64) ([]dto.Quiz, error)
+	GetQuizzes(ctx context.Context, creatorUserID int64, tags []string) ([]dto.Quiz, error)
 	GetQuestionsByQuizID(ctx context.Context, quizID int64) ([]dto.Question, error)
 	GetQuizByID(ctx context.Context, quizID int64) (dto.Quiz, error)
+	GetQuizOwnership(ctx context.Context, quizID int64) (dto.Ownership, error)
 	AddQuiz(ctx context.Context, quiz dto.InputQuiz) (int64, error)
 	DeleteQuizByID(ctx context.Context, quizID int64) error
+	CloneQuiz(ctx context.Context, sourceQuizID int64, creatorUserID int64, name string) (int64, error)
+
+	GetDeletedQuizzes(ctx context.Context, creatorUserID int64) ([]dto.Quiz, error)
+	RestoreQuiz(ctx context.Context, quizID int64) error
+	PurgeQuizzes(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type TagsStorage interface {
+	GetTags(ctx context.Context, prefix string, limit int) ([]dto.Tag, error)
+}
+
+type AttachmentsStorage interface {
+	AddAttachment(ctx context.Context, attachment dto.Attachment) (int64, error)
+	GetAttachmentByID(ctx context.Context, attachmentID int64) (dto.Attachment, error)
+	GetAttachmentsByQuestionID(ctx context.Context, questionID int64) ([]dto.Attachment, error)
+	DeleteAttachment(ctx context.Context, attachmentID int64) error
+	PurgeUnlinkedAttachments(ctx context.Context, ttl time.Duration) ([]dto.Attachment, error)
+}
+
+type SubmissionsStorage interface {
+	AddSubmission(ctx context.Context, submission dto.Submission) (int64, error)
+	GetSubmissionByID(ctx context.Context, submissionID int64) (dto.Submission, error)
+	GetSubmissions(ctx context.Context, userID int64, questionID int64) ([]dto.Submission, error)
+}
+
+type APITokensStorage interface {
+	AddAPIToken(ctx context.Context, token dto.InputAPIToken) (int64, error)
+	GetAPITokenByID(ctx context.Context, tokenID int64) (dto.APIToken, error)
+	GetAPITokens(ctx context.Context, userID int64) ([]dto.APIToken, error)
+	RevokeAPIToken(ctx context.Context, tokenID int64) error
+	UseAPIToken(ctx context.Context, hash []byte) (dto.APIToken, error)
+}
+
+type IdempotencyKeysStorage interface {
+	ReserveIdempotencyKey(ctx context.Context, key dto.IdempotencyKey) (dto.IdempotencyKey, bool, error)
+	SaveIdempotentResponse(ctx context.Context, key dto.IdempotencyKey) error
+	ReleaseIdempotencyKey(ctx context.Context, userID int64, key string) error
+	PurgeIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+type OutboxStorage interface {
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]dto.OutboxEvent, error)
+	MarkOutboxEventDelivered(ctx context.Context, id int64) error
+	MarkOutboxEventFailed(ctx context.Context, id int64, lastError string, retryAfter time.Duration, dead bool) error
+	PurgeOutboxEvents(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type AuditStorage interface {
+	GetAuditEntries(ctx context.Context, filter dto.AuditFilter) ([]dto.AuditEntry, error)
 }
@@ -0,0 +1,164 @@
+package notifier
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+const (
+	deliveryTimeout = 5 * time.Second // per event
+	purgeInterval   = time.Hour
+)
+
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int           // the event is dead-lettered after them
+	RetryBackoff time.Duration // delay after the first failed attempt, doubled after every next one
+	MaxBackoff   time.Duration
+	Retention    time.Duration // delivered events are purged after it
+}
+
+// Dispatcher delivers events of the outbox to the notifier. Delivery is at least once: the event is sent again
+// if it is not marked delivered, e.g. the dispatcher is stopped while sending.
+type Dispatcher struct {
+	storage  model.OutboxStorage
+	notifier model.Notifier
+	logger   *logrus.Logger
+	cfg      DispatcherConfig
+	lease    time.Duration
+}
+
+func NewDispatcher(storage model.OutboxStorage, notifier model.Notifier, logger *logrus.Logger, cfg DispatcherConfig) *Dispatcher {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+
+	return &Dispatcher{
+		storage:  storage,
+		notifier: notifier,
+		logger:   logger,
+		cfg:      cfg,
+		// claimed batch is not given to another dispatcher while it can be sent by this one
+		lease: time.Duration(cfg.BatchSize)*deliveryTimeout + cfg.PollInterval,
+	}
+}
+
+// Run delivers pending events every poll interval until ctx is done, full batches are followed by the next one at once
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	purgeTicker := time.NewTicker(purgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		claimed, err := d.Dispatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.logger.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("unable to dispatch outbox events")
+		}
+
+		if claimed == d.cfg.BatchSize && err == nil {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-purgeTicker.C:
+			d.purge(ctx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends one batch of due events, returns the number of claimed events
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	events, err := d.storage.ClaimOutboxEvents(ctx, d.cfg.BatchSize, d.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err = d.deliver(ctx, event); err != nil {
+			return len(events), err
+		}
+	}
+
+	return len(events), nil
+}
+
+// deliver sends the event, failed event is scheduled for retry or dead-lettered. Error is returned
+// only if the result can't be saved.
+func (d *Dispatcher) deliver(ctx context.Context, event dto.OutboxEvent) error {
+	sendCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	err := d.notifier.Notify(sendCtx, event.Room, event.Event, event.Data)
+	cancel()
+
+	if err == nil {
+		return d.storage.MarkOutboxEventDelivered(ctx, event.ID)
+	}
+
+	if ctx.Err() != nil {
+		// stopped while sending, the event is claimed again after the lease
+		return ctx.Err()
+	}
+
+	attempts := event.Attempts + 1
+	dead := attempts >= d.cfg.MaxAttempts
+	fields := logrus.Fields{
+		"event_id": event.ID,
+		"room":     event.Room,
+		"event":    event.Event,
+		"attempts": attempts,
+		"error":    err,
+	}
+	if dead {
+		d.logger.WithFields(fields).Error("outbox event is dead-lettered")
+	} else {
+		d.logger.WithFields(fields).Warn("unable to deliver outbox event")
+	}
+
+	return d.storage.MarkOutboxEventFailed(ctx, event.ID, err.Error(), d.backoff(attempts), dead)
+}
+
+// backoff is the delay after the given number of failed attempts
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.RetryBackoff
+	for i := 1; i < attempts; i++ {
+		if delay >= d.cfg.MaxBackoff/2 {
+			return d.cfg.MaxBackoff
+		}
+		delay *= 2
+	}
+	return min(delay, d.cfg.MaxBackoff)
+}
+
+func (d *Dispatcher) purge(ctx context.Context) {
+	purged, err := d.storage.PurgeOutboxEvents(ctx, d.cfg.Retention)
+	if err != nil {
+		d.logger.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("unable to purge outbox events")
+		return
+	}
+
+	if purged != 0 {
+		d.logger.WithFields(logrus.Fields{
+			"events": purged,
+		}).Info("delivered outbox events purged")
+	}
+}
@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-kit/kit/endpoint"
+	"quiz_backend_core/internal/dto"
+	"slices"
+)
+
+// Principal is the authenticated user who performs the request
+type Principal struct {
+	UserID int64
+	Role   dto.Role
+	Scopes []string // set for api tokens only, nil means the role alone decides
+}
+
+// IsStaff says whether the principal is admin or moderator
+func (p Principal) IsStaff() bool {
+	return p.Role == dto.RoleAdmin || p.Role == dto.RoleModerator
+}
+
+type principalKey struct{}
+
+// NewContext returns ctx carrying the principal
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal put by NewContext
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Action is an operation of the API checked by the policy
+type Action string
+
+// Resource is implemented by requests addressing a single question or quiz, used by ownership rules
+type Resource interface {
+	ResourceID() int64
+}
+
+// APITokenOwner is implemented by requests listing or creating api tokens of the user, the role is empty for lists
+type APITokenOwner interface {
+	APITokenOwner() (userID int64, role dto.Role)
+}
+
+// SubjectsResource is implemented by requests changing subjects, the principal must maintain every returned subject,
+// id <= 0 is the top level which only staff maintains
+type SubjectsResource interface {
+	SubjectIDs() []int64
+}
+
+// SubjectMove is implemented by requests which may put the subject under another parent,
+// the principal must maintain the new parent
+type SubjectMove interface {
+	MovedSubjectID() int64
+	NewParentID() int64
+}
+
+// Rule says whether the principal may perform an action with the request
+type Rule func(ctx context.Context, principal Principal, request interface{}) (bool, error)
+
+// Policy maps every action to its rule, actions without a rule are denied
+type Policy struct {
+	rules map[Action]Rule
+}
+
+func NewPolicy(rules map[Action]Rule) *Policy {
+	return &Policy{
+		rules: rules,
+	}
+}
+
+// Authorize returns ErrForbidden if the principal from ctx may not perform the action
+func (p *Policy) Authorize(ctx context.Context, action Action, request interface{}) error {
+	principal, ok := FromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: %s: unknown user", dto.ErrForbidden, action)
+	}
+
+	rule, ok := p.rules[action]
+	if !ok {
+		return fmt.Errorf("%w: %s: no rule", dto.ErrForbidden, action)
+	}
+
+	if principal.Scopes != nil && !slices.ContainsFunc(principal.Scopes, func(scope string) bool {
+		return ScopeAllows(scope, action)
+	}) {
+		return fmt.Errorf("%w: token scopes do not allow %s", dto.ErrForbidden, action)
+	}
+
+	allowed, err := rule(ctx, principal, request)
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return fmt.Errorf("%w: %s is not allowed to %s", dto.ErrForbidden, principal.Role, action)
+	}
+
+	return nil
+}
+
+// Middleware checks the action before the endpoint is called
+func (p *Policy) Middleware(action Action) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if err := p.Authorize(ctx, action, request); err != nil {
+				return nil, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package policy
+
+import (
+	"context"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"slices"
+)
+
+// subjects
+const (
+	SubjectsRead      Action = "subjects:read"
+	SubjectsWrite     Action = "subjects:write"
+	SubjectsTrash     Action = "subjects:trash"
+	SubjectsStatistic Action = "subjects:statistic"
+)
+
+// questions
+const (
+	QuestionsRead       Action = "questions:read"
+	QuestionsCreate     Action = "questions:create"
+	QuestionsUpdate     Action = "questions:update"
+	QuestionsModerate   Action = "questions:moderate"
+	QuestionsDelete     Action = "questions:delete"
+	QuestionsRestore    Action = "questions:restore"
+	QuestionsTrash      Action = "questions:trash"
+	QuestionsDuplicates Action = "questions:duplicates"
+	QuestionsRender     Action = "questions:render" // answers are hidden from users by the service
+)
+
+// quizzes
+const (
+	QuizzesRead    Action = "quizzes:read"
+	QuizzesCreate  Action = "quizzes:create"
+	QuizzesDelete  Action = "quizzes:delete"
+	QuizzesRestore Action = "quizzes:restore"
+	QuizzesTrash   Action = "quizzes:trash" // users see their own deleted quizzes only, see decodeGetDeletedQuizzesRequest
+)
+
+// tags, attachments and submissions
+const (
+	TagsRead          Action = "tags:read"
+	AttachmentsRead   Action = "attachments:read"
+	AttachmentsUpload Action = "attachments:upload"
+	SubmissionsCreate Action = "submissions:create"
+	SubmissionsRead   Action = "submissions:read" // other's submissions are hidden by the service
+)
+
+// api tokens and audit log
+const (
+	TokensManage Action = "tokens:manage" // users manage their own tokens, admins tokens of everyone
+	AuditRead    Action = "audit:read"
+)
+
+// DefaultPolicy is the access policy of the API
+func DefaultPolicy(questions model.QuestionsStorage, quizzes model.QuizzesStorage, subjects model.SubjectsStorage, tokens model.APITokensStorage) *Policy {
+	staff := Roles(dto.RoleAdmin, dto.RoleModerator)
+	questionMaintainer := QuestionSubjectMaintainer(questions, subjects)
+	subjectMaintainer := SubjectMaintainer(subjects)
+
+	return NewPolicy(map[Action]Rule{
+		SubjectsRead:      Authenticated(),
+		SubjectsWrite:     Any(staff, subjectMaintainer),
+		SubjectsTrash:     staff,
+		SubjectsStatistic: Authenticated(),
+
+		QuestionsRead:       Authenticated(),
+		QuestionsCreate:     Authenticated(),
+		QuestionsUpdate:     Any(staff, questionMaintainer, OwnDraftQuestion(questions)),
+		QuestionsModerate:   Any(staff, questionMaintainer),
+		QuestionsDelete:     Any(staff, questionMaintainer, OwnDraftQuestion(questions)),
+		QuestionsRestore:    Any(staff, questionMaintainer, OwnDraftQuestion(questions)),
+		QuestionsTrash:      staff,
+		QuestionsDuplicates: staff,
+		QuestionsRender:     Authenticated(),
+
+		QuizzesRead:    Authenticated(),
+		QuizzesCreate:  Authenticated(),
+		QuizzesDelete:  Any(staff, OwnQuiz(quizzes)),
+		QuizzesRestore: Any(staff, OwnQuiz(quizzes)),
+		QuizzesTrash:   Authenticated(),
+
+		TagsRead:          Authenticated(),
+		AttachmentsRead:   Authenticated(),
+		AttachmentsUpload: Authenticated(),
+		SubmissionsCreate: Authenticated(),
+		SubmissionsRead:   Authenticated(),
+
+		TokensManage: Any(Roles(dto.RoleAdmin), OwnAPIToken(tokens)),
+		AuditRead:    Roles(dto.RoleAdmin),
+	})
+}
+
+// Authenticated allows any known user
+func Authenticated() Rule {
+	return func(_ context.Context, principal Principal, _ interface{}) (bool, error) {
+		return principal.UserID > 0 && principal.Role != "", nil
+	}
+}
+
+// Roles allows users with one of roles
+func Roles(roles ...dto.Role) Rule {
+	return func(_ context.Context, principal Principal, _ interface{}) (bool, error) {
+		return slices.Contains(roles, principal.Role), nil
+	}
+}
+
+// Any allows if one of rules allows, rules are checked in order
+func Any(rules ...Rule) Rule {
+	return func(ctx context.Context, principal Principal, request interface{}) (bool, error) {
+		for _, rule := range rules {
+			allowed, err := rule(ctx, principal, request)
+			if err != nil {
+				return false, err
+			}
+			if allowed {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// OwnDraftQuestion allows the creator of the question until it is approved
+func OwnDraftQuestion(questions model.QuestionsStorage) Rule {
+	return func(ctx context.Context, principal Principal, request interface{}) (bool, error) {
+		resource, ok := request.(Resource)
+		if !ok {
+			return false, nil
+		}
+
+		ownership, err := questions.GetQuestionOwnership(ctx, resource.ResourceID())
+		if err != nil {
+			return false, err
+		}
+
+		return ownership.CreatorUserID == principal.UserID && ownership.StatusName != dto.QuestionStatusNameApproved, nil
+	}
+}
+
+// QuestionSubjectMaintainer allows maintainers of the subject of the question
+func QuestionSubjectMaintainer(questions model.QuestionsStorage, subjects model.SubjectsStorage) Rule {
+	return func(ctx context.Context, principal Principal, request interface{}) (bool, error) {
+		resource, ok := request.(Resource)
+		if !ok {
+			return false, nil
+		}
+
+		ownership, err := questions.GetQuestionOwnership(ctx, resource.ResourceID())
+		if err != nil {
+			return false, err
+		}
+
+		if ownership.SubjectID <= 0 {
+			return false, nil
+		}
+
+		return subjects.IsSubjectMaintainer(ctx, ownership.SubjectID, principal.UserID)
+	}
+}
+
+// SubjectMaintainer allows maintainers of all subjects of the request and, if the request moves the subject,
+// of its new parent. Requests without subjects and requests touching the top level are denied.
+func SubjectMaintainer(subjects model.SubjectsStorage) Rule {
+	return func(ctx context.Context, principal Principal, request interface{}) (bool, error) {
+		resource, ok := request.(SubjectsResource)
+		if !ok {
+			return false, nil
+		}
+
+		maintainsAll := func(subjectIDs []int64) (bool, error) {
+			if principal.UserID <= 0 || len(subjectIDs) == 0 {
+				return false, nil
+			}
+
+			for _, subjectID := range subjectIDs {
+				if subjectID <= 0 {
+					return false, nil
+				}
+
+				maintainer, err := subjects.IsSubjectMaintainer(ctx, subjectID, principal.UserID)
+				if err != nil || !maintainer {
+					return false, err
+				}
+			}
+			return true, nil
+		}
+
+		allowed, err := maintainsAll(resource.SubjectIDs())
+		if err != nil || !allowed {
+			return false, err
+		}
+
+		move, ok := request.(SubjectMove)
+		if !ok {
+			return true, nil
+		}
+
+		current, err := subjects.GetSubjectByID(ctx, move.MovedSubjectID())
+		if err != nil {
+			return false, err
+		}
+		if current.ParentId == move.NewParentID() {
+			return true, nil
+		}
+
+		return maintainsAll([]int64{move.NewParentID()})
+	}
+}
+
+// OwnQuiz allows the creator of the quiz
+func OwnQuiz(quizzes model.QuizzesStorage) Rule {
+	return func(ctx context.Context, principal Principal, request interface{}) (bool, error) {
+		resource, ok := request.(Resource)
+		if !ok {
+			return false, nil
+		}
+
+		ownership, err := quizzes.GetQuizOwnership(ctx, resource.ResourceID())
+		if err != nil {
+			return false, err
+		}
+
+		return ownership.CreatorUserID == principal.UserID, nil
+	}
+}
+
+// OwnAPIToken allows users to list, create and revoke their own api tokens, created tokens get the role of the user
+func OwnAPIToken(tokens model.APITokensStorage) Rule {
+	return func(ctx context.Context, principal Principal, request interface{}) (bool, error) {
+		switch r := request.(type) {
+		case APITokenOwner:
+			userID, role := r.APITokenOwner()
+			return userID == principal.UserID && (role == "" || role == principal.Role), nil
+		case Resource:
+			token, err := tokens.GetAPITokenByID(ctx, r.ResourceID())
+			if err != nil {
+				return false, err
+			}
+			return token.UserID == principal.UserID, nil
+		default:
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+)
+
+const (
+	ownerID      = 1
+	maintainerID = 2
+	otherID      = 3
+)
+
+var (
+	owner      = Principal{UserID: ownerID, Role: dto.RoleUser}
+	maintainer = Principal{UserID: maintainerID, Role: dto.RoleUser}
+	other      = Principal{UserID: otherID, Role: dto.RoleUser}
+	moderator  = Principal{UserID: otherID, Role: dto.RoleModerator}
+	admin      = Principal{UserID: otherID, Role: dto.RoleAdmin}
+)
+
+// subjectsStorage is the tree 1 -> 2 -> {3, 5} and a separate root 4, maintainer maintains the subtree of 2
+type subjectsStorage struct {
+	model.SubjectsStorage
+}
+
+var parents = map[int64]int64{1: 0, 2: 1, 3: 2, 4: 0, 5: 2}
+
+func (subjectsStorage) IsSubjectMaintainer(_ context.Context, subjectID int64, userID int64) (bool, error) {
+	if userID != maintainerID {
+		return false, nil
+	}
+	for id := subjectID; id > 0; id = parents[id] {
+		if id == 2 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (subjectsStorage) GetSubjectByID(_ context.Context, id int64) (dto.Subject, error) {
+	parent, ok := parents[id]
+	if !ok {
+		return dto.Subject{}, dto.ErrSubjectNotFound
+	}
+	return dto.Subject{ID: id, ParentId: parent}, nil
+}
+
+// questionsStorage has an approved question 1 and a draft 2 of owner in subject 3, and a question 3 without subject
+type questionsStorage struct {
+	model.QuestionsStorage
+}
+
+func (questionsStorage) GetQuestionOwnership(_ context.Context, questionID int64) (dto.Ownership, error) {
+	switch questionID {
+	case 1:
+		return dto.Ownership{CreatorUserID: ownerID, SubjectID: 3, StatusName: dto.QuestionStatusNameApproved}, nil
+	case 2:
+		return dto.Ownership{CreatorUserID: ownerID, SubjectID: 3, StatusName: dto.QuestionStatusNameCreated}, nil
+	case 3:
+		return dto.Ownership{CreatorUserID: ownerID}, nil
+	}
+	return dto.Ownership{}, dto.ErrQuestionNotFound
+}
+
+type quizzesStorage struct {
+	model.QuizzesStorage
+}
+
+func (quizzesStorage) GetQuizOwnership(_ context.Context, quizID int64) (dto.Ownership, error) {
+	if quizID != 1 {
+		return dto.Ownership{}, dto.ErrQuizNotFound
+	}
+	return dto.Ownership{CreatorUserID: ownerID}, nil
+}
+
+type tokensStorage struct {
+	model.APITokensStorage
+}
+
+func (tokensStorage) GetAPITokenByID(_ context.Context, tokenID int64) (dto.APIToken, error) {
+	return dto.APIToken{ID: tokenID, UserID: ownerID}, nil
+}
+
+// requests of the tests
+type (
+	resource      int64
+	subjectsWrite []int64
+	subjectMove   struct {
+		subjectIDs []int64
+		id         int64
+		parentID   int64
+	}
+	tokenOwner struct {
+		userID int64
+		role   dto.Role
+	}
+)
+
+func (r resource) ResourceID() int64 { return int64(r) }
+
+func (r subjectsWrite) SubjectIDs() []int64 { return r }
+
+func (r subjectMove) SubjectIDs() []int64   { return r.subjectIDs }
+func (r subjectMove) MovedSubjectID() int64 { return r.id }
+func (r subjectMove) NewParentID() int64    { return r.parentID }
+
+func (r tokenOwner) APITokenOwner() (int64, dto.Role) { return r.userID, r.role }
+
+func newTestPolicy() *Policy {
+	return DefaultPolicy(questionsStorage{}, quizzesStorage{}, subjectsStorage{}, tokensStorage{})
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	p := newTestPolicy()
+
+	tests := []struct {
+		name      string
+		principal Principal
+		action    Action
+		request   interface{}
+		allowed   bool
+	}{
+		{"user reads subjects", other, SubjectsRead, nil, true},
+		{"anonymous reads subjects", Principal{}, SubjectsRead, nil, false},
+		{"user without role", Principal{UserID: otherID}, SubjectsRead, nil, false},
+
+		// new subjects: the parent is returned, 0 for the top level
+		{"staff creates top level subject", moderator, SubjectsWrite, subjectsWrite{0}, true},
+		{"user creates top level subject", other, SubjectsWrite, subjectsWrite{0}, false},
+		{"maintainer creates top level subject", maintainer, SubjectsWrite, subjectsWrite{0}, false},
+		{"maintainer creates subject in maintained subtree", maintainer, SubjectsWrite, subjectsWrite{3}, true},
+		{"maintainer creates subject in other tree", maintainer, SubjectsWrite, subjectsWrite{4}, false},
+		{"user creates subject", other, SubjectsWrite, subjectsWrite{3}, false},
+		{"request without subjects", maintainer, SubjectsWrite, subjectsWrite{}, false},
+		{"request without subjects by staff", admin, SubjectsWrite, subjectsWrite{}, true},
+		{"not a subjects request", maintainer, SubjectsWrite, resource(3), false},
+
+		// merge and reassign need both subjects
+		{"maintainer merges within subtree", maintainer, SubjectsWrite, subjectsWrite{3, 2}, true},
+		{"maintainer merges into other tree", maintainer, SubjectsWrite, subjectsWrite{3, 4}, false},
+
+		// moves need the new parent too
+		{"maintainer keeps parent", maintainer, SubjectsWrite, subjectMove{[]int64{3}, 3, 2}, true},
+		{"maintainer moves within subtree", maintainer, SubjectsWrite, subjectMove{[]int64{3}, 3, 5}, true},
+		{"maintainer moves to other tree", maintainer, SubjectsWrite, subjectMove{[]int64{3}, 3, 4}, false},
+		{"maintainer moves to top level", maintainer, SubjectsWrite, subjectMove{[]int64{3}, 3, 0}, false},
+		{"maintainer moves maintained root out", maintainer, SubjectsWrite, subjectMove{[]int64{2}, 2, 4}, false},
+		{"staff moves to top level", moderator, SubjectsWrite, subjectMove{[]int64{3}, 3, 0}, true},
+		{"user moves", other, SubjectsWrite, subjectMove{[]int64{3}, 3, 2}, false},
+
+		{"staff sees subjects trash", moderator, SubjectsTrash, nil, true},
+		{"maintainer sees subjects trash", maintainer, SubjectsTrash, nil, false},
+
+		// questions
+		{"user creates question", other, QuestionsCreate, nil, true},
+		{"creator updates draft", owner, QuestionsUpdate, resource(2), true},
+		{"creator updates approved question", owner, QuestionsUpdate, resource(1), false},
+		{"maintainer updates approved question", maintainer, QuestionsUpdate, resource(1), true},
+		{"user updates draft of other", other, QuestionsUpdate, resource(2), false},
+		{"maintainer of nothing updates question without subject", maintainer, QuestionsUpdate, resource(3), false},
+		{"creator moderates own draft", owner, QuestionsModerate, resource(2), false},
+		{"maintainer moderates", maintainer, QuestionsModerate, resource(2), true},
+		{"moderator moderates", moderator, QuestionsModerate, resource(2), true},
+		{"creator deletes draft", owner, QuestionsDelete, resource(2), true},
+		{"user sees questions trash", owner, QuestionsTrash, nil, false},
+
+		// quizzes
+		{"creator deletes quiz", owner, QuizzesDelete, resource(1), true},
+		{"user deletes quiz of other", other, QuizzesDelete, resource(1), false},
+		{"admin deletes quiz", admin, QuizzesDelete, resource(1), true},
+
+		// api tokens
+		{"user lists own tokens", owner, TokensManage, tokenOwner{ownerID, ""}, true},
+		{"user lists tokens of other", other, TokensManage, tokenOwner{ownerID, ""}, false},
+		{"user creates token with own role", owner, TokensManage, tokenOwner{ownerID, dto.RoleUser}, true},
+		{"user creates token with higher role", owner, TokensManage, tokenOwner{ownerID, dto.RoleAdmin}, false},
+		{"user revokes own token", owner, TokensManage, resource(10), true},
+		{"user revokes token of other", other, TokensManage, resource(10), false},
+		{"admin creates token of other", admin, TokensManage, tokenOwner{ownerID, dto.RoleAdmin}, true},
+
+		{"moderator reads audit log", moderator, AuditRead, nil, false},
+		{"admin reads audit log", admin, AuditRead, nil, true},
+		{"action without rule", admin, Action("questions:unknown"), nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewContext(context.Background(), tt.principal)
+			err := p.Authorize(ctx, tt.action, tt.request)
+			if tt.allowed && err != nil {
+				t.Errorf("got %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, dto.ErrForbidden) {
+				t.Errorf("got %v, want %v", err, dto.ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	p := newTestPolicy()
+
+	if err := p.Authorize(context.Background(), SubjectsRead, nil); !errors.Is(err, dto.ErrForbidden) {
+		t.Errorf("without principal: got %v, want %v", err, dto.ErrForbidden)
+	}
+
+	// storage errors are not hidden behind ErrForbidden
+	ctx := NewContext(context.Background(), other)
+	if err := p.Authorize(ctx, QuestionsUpdate, resource(404)); !errors.Is(err, dto.ErrQuestionNotFound) {
+		t.Errorf("unknown question: got %v, want %v", err, dto.ErrQuestionNotFound)
+	}
+
+	tests := []struct {
+		scopes  []string
+		action  Action
+		allowed bool
+	}{
+		{nil, QuestionsCreate, true},
+		{[]string{"*"}, QuestionsCreate, true},
+		{[]string{"questions:read"}, QuestionsRead, true},
+		{[]string{"questions:read"}, QuestionsTrash, false}, // the rule denies it to users
+		{[]string{"questions:read"}, QuestionsCreate, false},
+		{[]string{"questions:create"}, QuestionsCreate, true},
+		{[]string{"quizzes:write"}, QuestionsCreate, false},
+		{[]string{"quizzes:write", "questions:*"}, QuestionsCreate, true},
+		{[]string{}, QuestionsRead, false},
+	}
+
+	for _, tt := range tests {
+		principal := other
+		principal.Scopes = tt.scopes
+		err := p.Authorize(NewContext(context.Background(), principal), tt.action, nil)
+		if tt.allowed != (err == nil) {
+			t.Errorf("scopes %v, %s: got %v, want allowed %v", tt.scopes, tt.action, err, tt.allowed)
+		}
+	}
+}
+
+func TestAny(t *testing.T) {
+	errStorage := errors.New("storage is down")
+	deny := func(context.Context, Principal, interface{}) (bool, error) { return false, nil }
+	fail := func(context.Context, Principal, interface{}) (bool, error) { return false, errStorage }
+	allow := func(context.Context, Principal, interface{}) (bool, error) { return true, nil }
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		allowed bool
+		err     error
+	}{
+		{"no rules", Any(), false, nil},
+		{"deny", Any(deny, deny), false, nil},
+		{"allow", Any(deny, allow), true, nil},
+		{"allowed before error", Any(allow, fail), true, nil},
+		{"error", Any(deny, fail, allow), false, errStorage},
+	}
+
+	for _, tt := range tests {
+		allowed, err := tt.rule(context.Background(), other, nil)
+		if allowed != tt.allowed || !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, %v, want %v, %v", tt.name, allowed, err, tt.allowed, tt.err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"slices"
+	"strings"
+)
+
+// readVerbs are actions which do not change anything, they are allowed by "<resource>:read" scope
+var readVerbs = []string{"read", "trash", "statistic", "duplicates", "render"}
+
+// ScopeAllows says whether api token scope covers the action. Scope is "*", an action like
+// "questions:update", "<resource>:*" or "<resource>:write" for every action of the resource
+// and "<resource>:read" for its read actions.
+func ScopeAllows(scope string, action Action) bool {
+	if scope == "*" || scope == string(action) {
+		return true
+	}
+
+	resource, verb, ok := strings.Cut(scope, ":")
+	actionResource, actionVerb, _ := strings.Cut(string(action), ":")
+	if !ok || resource != actionResource {
+		return false
+	}
+
+	switch verb {
+	case "*", "write":
+		return true
+	case "read":
+		return slices.Contains(readVerbs, actionVerb)
+	}
+
+	return false
+}
+
+// ValidScope says whether the scope is "*" or names an action or a resource known by the policy
+func (p *Policy) ValidScope(scope string) bool {
+	if scope == "*" {
+		return true
+	}
+
+	resource, verb, ok := strings.Cut(scope, ":")
+	if !ok {
+		return false
+	}
+
+	for action := range p.rules {
+		if string(action) == scope {
+			return true
+		}
+		if actionResource, _, _ := strings.Cut(string(action), ":"); actionResource == resource {
+			if verb == "*" || verb == "write" || verb == "read" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
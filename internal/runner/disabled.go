@@ -0,0 +1,23 @@
+package runner
+
+import (
+	"context"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+)
+
+// NewDisabledRunner creates runner of the server which can not run programs: no languages are supported,
+// so program questions can not be saved, and submissions are rejected
+func NewDisabledRunner() model.CodeRunner {
+	return disabledRunner{}
+}
+
+type disabledRunner struct{}
+
+func (disabledRunner) Languages() []string {
+	return nil
+}
+
+func (disabledRunner) Run(ctx context.Context, request dto.RunRequest) (dto.RunResult, error) {
+	return dto.RunResult{}, dto.ErrRunnerDisabled
+}
@@ -0,0 +1,398 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	outputLimit       = 64 << 10 // bytes of stdout/stderr kept per run
+	compileErrorLimit = 8 << 10
+	killWaitDelay     = time.Second
+)
+
+type LocalConfig struct {
+	WorkDir        string
+	Timeout        time.Duration // per test
+	CompileTimeout time.Duration
+	MemoryLimit    int64 // bytes of data segment (heap) per process, 0 - unlimited
+	ProcessLimit   int   // processes and threads of a program, 0 - unlimited
+	FileSizeLimit  int64 // bytes of a file written by a program, 0 - unlimited
+	MaxParallel    int
+	SandboxUID     int // parallel runs use users (and groups) SandboxUID...SandboxUID+MaxParallel-1
+	IsolateNetwork bool
+	GoBinary       string
+	PythonBinary   string
+
+	// limits of compilers: they need much more than programs, but the code they compile is untrusted too
+	CompileMemoryLimit   int64 // bytes of address space per process, 0 - unlimited
+	CompileProcessLimit  int   // 0 - unlimited
+	CompileFileSizeLimit int64 // 0 - unlimited
+}
+
+// NewLocalRunner creates runner which builds and runs programs as subprocesses of the service.
+// Every run gets its own unprivileged user, private working, temporary and build cache directories and
+// resource limits (cpu, memory, processes, file size). Every process gets its own process group (killed on
+// timeout) and, if required, empty network namespace. Processes left by the program are killed after the run.
+// The service must run as root on Linux.
+func NewLocalRunner(cfg LocalConfig) (model.CodeRunner, error) {
+	if cfg.SandboxUID <= 0 {
+		return nil, errors.New("sandbox uid is not set")
+	}
+
+	// sandbox users can pass through the directory to their run directories but can not list it
+	if err := os.MkdirAll(cfg.WorkDir, 0o711); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(cfg.WorkDir, 0o711); err != nil {
+		return nil, err
+	}
+
+	workDir, err := filepath.Abs(cfg.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	cfg.WorkDir = workDir
+
+	if cfg.MaxParallel <= 0 {
+		cfg.MaxParallel = 1
+	}
+
+	// programs are started by the service binary which sets limits as the sandbox user, see ExecHelper
+	helper, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &localRunner{
+		cfg:    cfg,
+		helper: helper,
+		slots:  make(chan int, cfg.MaxParallel),
+	}
+	for slot := 0; slot < cfg.MaxParallel; slot++ {
+		r.slots <- slot
+	}
+
+	// languages without toolchain in the image are not offered
+	for language, binary := range map[string]string{"go": cfg.GoBinary, "python": cfg.PythonBinary} {
+		if _, err = exec.LookPath(binary); err == nil {
+			r.languages = append(r.languages, language)
+		}
+	}
+	if len(r.languages) == 0 {
+		return nil, errors.New("toolchains of supported languages are not found")
+	}
+	slices.Sort(r.languages)
+
+	// fail fast if isolation is not available on this system (e.g. the service is not root
+	// or network namespaces are not allowed)
+	probe := program{argv: []string{"true"}, env: baseEnv(workDir)}
+	output, err := r.execute(context.Background(), r.sandboxOf(0), workDir, probe, "", cfg.CompileTimeout, r.programLimits())
+	if err == nil && output.ExitCode != 0 {
+		err = fmt.Errorf("exit code %d: %s", output.ExitCode, strings.TrimSpace(output.Stderr))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sandbox is not available: %w", err)
+	}
+
+	return r, nil
+}
+
+type localRunner struct {
+	cfg       LocalConfig
+	helper    string   // path of the service binary
+	slots     chan int // free slots of parallel runs
+	languages []string
+}
+
+// sandbox is the user of one runner slot, parallel runs can not touch each other
+type sandbox struct {
+	uid int
+	gid int
+}
+
+// limits of a program, 0 - unlimited
+type limits struct {
+	memory       int64 // bytes of data segment
+	addressSpace int64 // bytes of virtual memory
+	processes    int   // processes and threads of the sandbox user
+	fileSize     int64 // bytes of a written file
+}
+
+func (r *localRunner) sandboxOf(slot int) sandbox {
+	return sandbox{uid: r.cfg.SandboxUID + slot, gid: r.cfg.SandboxUID + slot}
+}
+
+func (r *localRunner) programLimits() limits {
+	return limits{
+		memory:    r.cfg.MemoryLimit,
+		processes: r.cfg.ProcessLimit,
+		fileSize:  r.cfg.FileSizeLimit,
+	}
+}
+
+// compileLimits keep a build of hostile code from exhausting memory, processes or disk of the host
+func (r *localRunner) compileLimits() limits {
+	return limits{
+		addressSpace: r.cfg.CompileMemoryLimit,
+		processes:    r.cfg.CompileProcessLimit,
+		fileSize:     r.cfg.CompileFileSizeLimit,
+	}
+}
+
+// program is a prepared (compiled) program in the working directory
+type program struct {
+	argv []string
+	env  []string
+}
+
+func (r *localRunner) Languages() []string {
+	return r.languages
+}
+
+func (r *localRunner) Run(ctx context.Context, request dto.RunRequest) (dto.RunResult, error) {
+	var result dto.RunResult
+
+	if !slices.Contains(r.languages, request.Language) {
+		return result, dto.ErrUnsupportedRunnerLanguage
+	}
+
+	var slot int
+	select {
+	case slot = <-r.slots:
+		defer func() { r.slots <- slot }()
+	case <-ctx.Done():
+		return result, dto.ErrRunnerBusy
+	}
+	sb := r.sandboxOf(slot)
+
+	dir, err := os.MkdirTemp(r.cfg.WorkDir, "run-")
+	if err != nil {
+		return result, err
+	}
+	defer r.cleanup(sb, dir)
+
+	// the run directory belongs to the sandbox user, nobody else can read it
+	if err = os.Chown(dir, sb.uid, sb.gid); err != nil {
+		return result, err
+	}
+	if err = os.Mkdir(filepath.Join(dir, "tmp"), 0o700); err != nil {
+		return result, err
+	}
+	if err = os.Chown(filepath.Join(dir, "tmp"), sb.uid, sb.gid); err != nil {
+		return result, err
+	}
+
+	var prog program
+	switch request.Language {
+	case "go":
+		prog, result.CompileError, err = r.prepareGo(ctx, sb, dir, request.Code)
+	case "python":
+		prog, result.CompileError, err = r.preparePython(ctx, sb, dir, request.Code)
+	default:
+		return result, dto.ErrUnsupportedRunnerLanguage
+	}
+	if err != nil || result.CompileError != "" {
+		return result, err
+	}
+
+	result.Outputs = make([]dto.RunOutput, 0, len(request.Inputs))
+	for _, input := range request.Inputs {
+		output, err := r.execute(ctx, sb, dir, prog, input, r.cfg.Timeout, r.programLimits())
+		if err != nil {
+			return result, err
+		}
+		result.Outputs = append(result.Outputs, output)
+	}
+
+	return result, nil
+}
+
+// cleanup kills processes left by the program and removes files of the run
+func (r *localRunner) cleanup(sb sandbox, dir string) {
+	killProcessesOf(r.helper, sb)
+	removeFilesOf(sb.uid)
+	_ = os.RemoveAll(dir)
+}
+
+// writeFile writes a file of the run readable by the sandbox user only
+func writeFile(sb sandbox, path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+	return os.Chown(path, sb.uid, sb.gid)
+}
+
+func (r *localRunner) prepareGo(ctx context.Context, sb sandbox, dir, code string) (program, string, error) {
+	if err := writeFile(sb, filepath.Join(dir, "main.go"), []byte(code)); err != nil {
+		return program{}, "", err
+	}
+	if err := writeFile(sb, filepath.Join(dir, "go.mod"), []byte("module submission\n\ngo 1.21\n")); err != nil {
+		return program{}, "", err
+	}
+
+	// build cache is private to the run (a shared one could be poisoned), modules can not be downloaded
+	env := append(baseEnv(dir),
+		"GOCACHE="+filepath.Join(dir, "gocache"),
+		"GOPATH="+filepath.Join(dir, "gopath"),
+		"GOPROXY=off",
+		"GOFLAGS=-mod=mod",
+		"GOTOOLCHAIN=local",
+		"CGO_ENABLED=0",
+	)
+	build := program{
+		argv: []string{r.cfg.GoBinary, "build", "-o", "prog", "."},
+		env:  env,
+	}
+
+	output, err := r.execute(ctx, sb, dir, build, "", r.cfg.CompileTimeout, r.compileLimits())
+	if err != nil {
+		return program{}, "", err
+	}
+	if compileError := compileErrorOf(output, dir); compileError != "" {
+		return program{}, compileError, nil
+	}
+
+	return program{
+		argv: []string{filepath.Join(dir, "prog")},
+		env:  append(baseEnv(dir), "GOMAXPROCS=1"),
+	}, "", nil
+}
+
+func (r *localRunner) preparePython(ctx context.Context, sb sandbox, dir, code string) (program, string, error) {
+	if err := writeFile(sb, filepath.Join(dir, "main.py"), []byte(code)); err != nil {
+		return program{}, "", err
+	}
+
+	env := append(baseEnv(dir), "PYTHONDONTWRITEBYTECODE=1")
+
+	// syntax errors are reported as compile errors
+	check := program{
+		argv: []string{r.cfg.PythonBinary, "-I", "-m", "py_compile", "main.py"},
+		env:  env,
+	}
+	output, err := r.execute(ctx, sb, dir, check, "", r.cfg.CompileTimeout, r.programLimits())
+	if err != nil {
+		return program{}, "", err
+	}
+	if compileError := compileErrorOf(output, dir); compileError != "" {
+		return program{}, compileError, nil
+	}
+
+	return program{
+		argv: []string{r.cfg.PythonBinary, "-I", "main.py"},
+		env:  env,
+	}, "", nil
+}
+
+// execute runs the program once in dir as the sandbox user, error is returned only if the program can not be started
+func (r *localRunner) execute(ctx context.Context, sb sandbox, dir string, prog program, input string, timeout time.Duration, l limits) (dto.RunOutput, error) {
+	var output dto.RunOutput
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	attr, err := sandboxAttr(sb, r.cfg.IsolateNetwork)
+	if err != nil {
+		return output, err
+	}
+
+	// limits are set by the helper right before exec, so they apply to the program only;
+	// cpu time can not exceed the timeout much even if the program is not killed by it
+	cpuSeconds := int64(math.Ceil(timeout.Seconds())) + 1
+	args := helperArgs(l, cpuSeconds, prog.argv)
+
+	stdout := &limitedBuffer{limit: outputLimit}
+	stderr := &limitedBuffer{limit: outputLimit}
+
+	cmd := exec.CommandContext(runCtx, r.helper, args...)
+	cmd.Dir = dir
+	cmd.Env = prog.env
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = attr
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	cmd.WaitDelay = killWaitDelay
+
+	begin := time.Now()
+	err = cmd.Run()
+	output.Duration = time.Since(begin).Milliseconds()
+	output.Stdout = stdout.String()
+	output.Stderr = stderr.String()
+	output.TimedOut = errors.Is(runCtx.Err(), context.DeadlineExceeded)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		output.ExitCode = exitErr.ExitCode()
+	case output.TimedOut || errors.Is(err, exec.ErrWaitDelay):
+		output.ExitCode = -1
+	default:
+		return output, fmt.Errorf("unable to run program: %w", err)
+	}
+
+	return output, nil
+}
+
+// compileErrorOf returns build diagnostics without paths of the working directory
+func compileErrorOf(output dto.RunOutput, dir string) string {
+	if output.ExitCode == 0 && !output.TimedOut {
+		return ""
+	}
+	if output.TimedOut {
+		return "compilation timed out"
+	}
+
+	message := strings.ReplaceAll(output.Stderr+output.Stdout, dir+string(filepath.Separator), "")
+	if len(message) > compileErrorLimit {
+		message = message[:compileErrorLimit]
+	}
+	if strings.TrimSpace(message) == "" {
+		message = fmt.Sprintf("compilation failed with exit code %d", output.ExitCode)
+	}
+	return message
+}
+
+// baseEnv is a minimal environment, service variables (database password etc.) are not inherited
+func baseEnv(dir string) []string {
+	return []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"TMPDIR=" + filepath.Join(dir, "tmp"),
+		"LANG=C.UTF-8",
+	}
+}
+
+// limitedBuffer keeps first limit bytes and silently drops the rest, so a program can not exhaust service memory
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if rest := b.limit - b.buf.Len(); rest > 0 {
+		if len(p) > rest {
+			b.buf.Write(p[:rest])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
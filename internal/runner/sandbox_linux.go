@@ -0,0 +1,158 @@
+//go:build linux
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	execCommand = "sandbox-exec" // argv[1] of the service binary started to set limits and exec the program
+	killCommand = "sandbox-kill" // argv[1] of the service binary started to kill all processes of the sandbox user
+)
+
+// sharedTmpDirs are writable by everyone, files of the sandbox user are removed from them after every run
+var sharedTmpDirs = []string{os.TempDir(), "/var/tmp", "/dev/shm"}
+
+// ExecHelper must be called first in main. If the binary is started by the runner as a sandbox helper, it does its
+// job as the sandbox user and never returns: sets resource limits and replaces itself with the program,
+// or kills all processes left by the program.
+func ExecHelper() {
+	if len(os.Args) < 2 {
+		return
+	}
+
+	var err error
+	switch os.Args[1] {
+	case execCommand:
+		err = execWithLimits(os.Args[2:])
+	case killCommand:
+		// everything the caller may signal, i.e. processes of the sandbox user, except the caller itself
+		if err = syscall.Kill(-1, syscall.SIGKILL); err == nil || errors.Is(err, syscall.ESRCH) {
+			os.Exit(0)
+		}
+	default:
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+	os.Exit(127)
+}
+
+// helperArgs are arguments of the exec helper for the program
+func helperArgs(l limits, cpuSeconds int64, argv []string) []string {
+	args := []string{
+		execCommand,
+		strconv.FormatInt(cpuSeconds, 10),
+		strconv.FormatInt(l.memory, 10),
+		strconv.FormatInt(l.addressSpace, 10),
+		strconv.Itoa(l.processes),
+		strconv.FormatInt(l.fileSize, 10),
+	}
+	return append(args, argv...)
+}
+
+// execWithLimits sets limits from args made by helperArgs and execs the program, 0 means unlimited
+func execWithLimits(args []string) error {
+	resources := []int{unix.RLIMIT_CPU, unix.RLIMIT_DATA, unix.RLIMIT_AS, unix.RLIMIT_NPROC, unix.RLIMIT_FSIZE}
+	if len(args) <= len(resources) {
+		return errors.New("not enough arguments")
+	}
+
+	for i, resource := range resources {
+		value, err := strconv.ParseUint(args[i], 10, 64)
+		if err != nil {
+			return err
+		}
+		if value == 0 {
+			continue
+		}
+		if err = unix.Setrlimit(resource, &unix.Rlimit{Cur: value, Max: value}); err != nil {
+			return fmt.Errorf("setrlimit %d: %w", resource, err)
+		}
+	}
+
+	// crashed programs do not leave core files
+	if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{}); err != nil {
+		return err
+	}
+
+	argv := args[len(resources):]
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+
+	return syscall.Exec(path, argv, os.Environ())
+}
+
+// sandboxAttr runs the process as the sandbox user without supplementary groups in its own process group and,
+// if required, in a new network namespace: the process has loopback only, so it can not reach the network.
+// The user can not read environment of the service in /proc, signal it or read its files (work and attachments
+// directories are not accessible to others), so the service must run as root to switch to the user.
+func sandboxAttr(sb sandbox, isolateNetwork bool) (*syscall.SysProcAttr, error) {
+	attr := &syscall.SysProcAttr{
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGKILL,
+		Credential: &syscall.Credential{
+			Uid:    uint32(sb.uid),
+			Gid:    uint32(sb.gid),
+			Groups: []uint32{},
+		},
+	}
+
+	if isolateNetwork {
+		attr.Cloneflags = syscall.CLONE_NEWNET
+	}
+
+	return attr, nil
+}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// killProcessesOf kills all processes of the sandbox user, e.g. started in a new session by the program
+func killProcessesOf(helper string, sb sandbox) {
+	attr, err := sandboxAttr(sb, false)
+	if err != nil {
+		return
+	}
+
+	kill := exec.Command(helper, killCommand)
+	kill.Env = []string{}
+	kill.SysProcAttr = attr
+	_ = kill.Run()
+}
+
+// removeFilesOf removes files of the user from shared temporary directories
+func removeFilesOf(uid int) {
+	for _, root := range sharedTmpDirs {
+		_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || path == root {
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) == uid {
+				_ = os.RemoveAll(path)
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+var errSandboxUnsupported = errors.New("sandbox of programs is supported on linux only")
+
+// ExecHelper must be called first in main, there are no sandbox helpers on this system
+func ExecHelper() {}
+
+func helperArgs(l limits, cpuSeconds int64, argv []string) []string {
+	return argv
+}
+
+func sandboxAttr(sb sandbox, isolateNetwork bool) (*syscall.SysProcAttr, error) {
+	return nil, errSandboxUnsupported
+}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+func killProcessesOf(helper string, sb sandbox) {}
+
+func removeFilesOf(uid int) {}
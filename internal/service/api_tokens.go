@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+	"quiz_backend_core/internal/service/middleware"
+	"strings"
+	"time"
+)
+
+const (
+	apiTokenSecretSize = 32
+	apiTokenIDSize     = 4 // random bytes of the public id, it is shown as the token prefix
+	apiTokenNameMaxLen = 100
+)
+
+type apiTokensService struct {
+	storage model.APITokensStorage
+	policy  *policy.Policy
+	logger  *logrus.Logger
+}
+
+func NewAPITokensService(deps Deps, p *policy.Policy) model.APITokens {
+	var svc model.APITokens = apiTokensService{
+		storage: deps.Storages.APITokens,
+		policy:  p,
+		logger:  deps.Logger,
+	}
+
+	// middleware services
+	svc = middleware.LoggingAPITokensMiddleware(deps.Logger)(svc)
+	svc = middleware.InstrumentingAPITokensMiddleware(deps.RequestCounter, deps.RequestLatencyMeter)(svc)
+
+	return svc
+}
+
+// CreateAPIToken issues token for the user, the secret is returned once and only its hash is stored
+func (s apiTokensService) CreateAPIToken(ctx context.Context, userID int64, input dto.InputAPIToken) (dto.APIToken, string, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len(input.Name) > apiTokenNameMaxLen {
+		return dto.APIToken{}, "", dto.NewFieldError("name", dto.ErrEmptyTokenName)
+	}
+
+	if input.UserID <= 0 {
+		return dto.APIToken{}, "", dto.NewFieldError("user_id", dto.ErrEmptyTokenUser)
+	}
+
+	if !input.Role.IsKnown() {
+		return dto.APIToken{}, "", dto.NewFieldError("role", fmt.Errorf("%w: %q", dto.ErrInvalidRole, input.Role))
+	}
+
+	if len(input.Scopes) == 0 {
+		return dto.APIToken{}, "", dto.NewFieldError("scopes", fmt.Errorf("%w: at least one scope is required", dto.ErrInvalidScope))
+	}
+	for _, scope := range input.Scopes {
+		if !s.policy.ValidScope(scope) {
+			return dto.APIToken{}, "", dto.NewFieldError("scopes", fmt.Errorf("%w: %q", dto.ErrInvalidScope, scope))
+		}
+	}
+
+	if !input.ExpiresAt.IsZero() && !input.ExpiresAt.After(time.Now()) {
+		return dto.APIToken{}, "", dto.NewFieldError("expires_at", dto.ErrInvalidExpiry)
+	}
+
+	secret, prefix, err := newAPITokenSecret()
+	if err != nil {
+		return dto.APIToken{}, "", err
+	}
+
+	input.CreatedByUserID = userID
+	input.Hash = hashAPIToken(secret)
+	input.Prefix = prefix
+
+	tokenID, err := s.storage.AddAPIToken(ctx, input)
+	if err != nil {
+		return dto.APIToken{}, "", err
+	}
+
+	token, err := s.storage.GetAPITokenByID(ctx, tokenID)
+	if err != nil {
+		return dto.APIToken{}, "", err
+	}
+
+	return token, secret, nil
+}
+
+// GetAPITokens returns tokens of the user, -1 means tokens of all users
+func (s apiTokensService) GetAPITokens(ctx context.Context, userID int64) ([]dto.APIToken, error) {
+	return s.storage.GetAPITokens(ctx, userID)
+}
+
+func (s apiTokensService) RevokeAPIToken(ctx context.Context, tokenID int64) error {
+	return s.storage.RevokeAPIToken(ctx, tokenID)
+}
+
+// AuthenticateAPIToken returns active token by its secret and records its use
+func (s apiTokensService) AuthenticateAPIToken(ctx context.Context, secret string) (dto.APIToken, error) {
+	if !strings.HasPrefix(secret, dto.APITokenPrefix) || len(secret) <= len(dto.APITokenPrefix) {
+		return dto.APIToken{}, dto.ErrInvalidAPIToken
+	}
+
+	return s.storage.UseAPIToken(ctx, hashAPIToken(secret))
+}
+
+// newAPITokenSecret returns "qbt_<public id>_<secret>" and its prefix with the public id, so the token can be
+// recognized in lists without revealing any part of the secret
+func newAPITokenSecret() (secret string, prefix string, err error) {
+	id := make([]byte, apiTokenIDSize)
+	if _, err = rand.Read(id); err != nil {
+		return "", "", err
+	}
+
+	b := make([]byte, apiTokenSecretSize)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	prefix = dto.APITokenPrefix + hex.EncodeToString(id)
+	return prefix + "_" + base64.RawURLEncoding.EncodeToString(b), prefix, nil
+}
+
+// hashAPIToken is plain sha256, the secret has enough entropy to not need a slow hash
+func hashAPIToken(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+	"quiz_backend_core/internal/service/middleware"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// allowedAttachmentTypes maps sniffed mime type to the file extension used in storage key
+var allowedAttachmentTypes = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/bmp":       ".bmp",
+	"audio/mpeg":      ".mp3",
+	"audio/wave":      ".wav",
+	"audio/aiff":      ".aiff",
+	"audio/basic":     ".au",
+	"audio/midi":      ".mid",
+	"application/ogg": ".ogg",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+}
+
+const attachmentFileNameMaxLength = 255
+
+var attachmentReferenceRegexp = regexp.MustCompile(regexp.QuoteMeta(dto.AttachmentReferencePrefix) + `(\d+)`)
+
+type attachmentsService struct {
+	storage   model.AttachmentsStorage
+	questions model.QuestionsStorage
+	subjects  model.SubjectsStorage
+	blobs     model.BlobStore
+	logger    *logrus.Logger
+	maxSize   int64
+}
+
+func NewAttachmentsService(deps Deps) model.Attachments {
+	var svc model.Attachments = attachmentsService{
+		storage:   deps.Storages.Attachments,
+		questions: deps.Storages.Questions,
+		subjects:  deps.Storages.Subjects,
+		blobs:     deps.BlobStore,
+		logger:    deps.Logger,
+		maxSize:   deps.Config.AttachmentsMaxSize,
+	}
+
+	// middleware services
+	svc = middleware.LoggingAttachmentsMiddleware(deps.Logger)(svc)
+	svc = middleware.InstrumentingAttachmentsMiddleware(deps.RequestCounter, deps.RequestLatencyMeter)(svc)
+
+	return svc
+}
+
+// UploadAttachment validates size and content type (sniffed from content, not the declared one),
+// stores content in the blob store and registers attachment without question
+func (s attachmentsService) UploadAttachment(ctx context.Context, attachment dto.Attachment, content []byte) (dto.Attachment, error) {
+	if len(content) == 0 {
+		return attachment, dto.ErrAttachmentEmpty
+	}
+
+	if int64(len(content)) > s.maxSize {
+		return attachment, dto.ErrAttachmentTooLarge
+	}
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(content))
+	if err != nil {
+		return attachment, dto.ErrAttachmentTypeNotAllowed
+	}
+
+	extension, ok := allowedAttachmentTypes[mimeType]
+	if !ok {
+		return attachment, dto.ErrAttachmentTypeNotAllowed
+	}
+
+	key, err := newStorageKey(extension)
+	if err != nil {
+		return attachment, err
+	}
+
+	attachment.FileName = sanitizeFileName(attachment.FileName, extension)
+	attachment.MimeType = mimeType
+	attachment.Size = int64(len(content))
+	attachment.StorageKey = key
+
+	if err = s.blobs.Put(ctx, key, bytes.NewReader(content)); err != nil {
+		return attachment, err
+	}
+
+	if attachment.ID, err = s.storage.AddAttachment(ctx, attachment); err != nil {
+		if errDelete := s.blobs.Delete(ctx, key); errDelete != nil {
+			s.logger.WithFields(logrus.Fields{
+				"key":   key,
+				"error": errDelete,
+			}).Error("unable to delete blob of not registered attachment")
+		}
+		return attachment, err
+	}
+
+	return attachment, nil
+}
+
+// GetAttachment returns the attachment to users who can read its question (see canReadQuestion),
+// uploads which are not linked to a question yet are returned to their uploaders and staff only
+func (s attachmentsService) GetAttachment(ctx context.Context, attachmentID int64) (dto.Attachment, io.ReadCloser, error) {
+	principal, ok := policy.FromContext(ctx)
+	if !ok {
+		return dto.Attachment{}, nil, fmt.Errorf("%w: unknown user", dto.ErrForbidden)
+	}
+
+	attachment, err := s.storage.GetAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		return attachment, nil, err
+	}
+
+	readable := principal.IsStaff() || attachment.CreatorUserID == principal.UserID
+	if !readable && attachment.QuestionID != 0 {
+		ownership, err := s.questions.GetQuestionOwnership(ctx, attachment.QuestionID)
+		if err != nil {
+			return dto.Attachment{}, nil, err
+		}
+
+		if readable, err = canReadQuestion(ctx, s.subjects, principal, ownership); err != nil {
+			return dto.Attachment{}, nil, err
+		}
+	}
+	if !readable {
+		return dto.Attachment{}, nil, fmt.Errorf("%w: attachment %d", dto.ErrForbidden, attachmentID)
+	}
+
+	content, err := s.blobs.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		return attachment, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// removeAttachments deletes attachments rows and blobs, errors are only logged,
+// because the main operation is already done
+func removeAttachments(ctx context.Context, storage model.AttachmentsStorage, blobs model.BlobStore, logger *logrus.Logger, attachments []dto.Attachment) {
+	for _, attachment := range attachments {
+		// the row removed by someone else still leaves the blob to delete
+		if err := storage.DeleteAttachment(ctx, attachment.ID); err != nil && !errors.Is(err, dto.ErrAttachmentNotFound) {
+			logger.WithFields(logrus.Fields{
+				"attachmentID": attachment.ID,
+				"error":        err,
+			}).Error("unable to delete orphaned attachment")
+			continue
+		}
+
+		if err := blobs.Delete(ctx, attachment.StorageKey); err != nil {
+			logger.WithFields(logrus.Fields{
+				"attachmentID": attachment.ID,
+				"key":          attachment.StorageKey,
+				"error":        err,
+			}).Error("unable to delete orphaned attachment blob")
+		}
+	}
+}
+
+// referencedAttachmentIDs returns explicit attachment ids of the question
+// and ids referenced as attachment://<id> from text, code and variants
+func referencedAttachmentIDs(question dto.InputQuestion) dto.Int64Array {
+	ids := slices.Clone(question.AttachmentIDs)
+
+	addReferences := func(text string) {
+		for _, match := range attachmentReferenceRegexp.FindAllStringSubmatch(text, -1) {
+			if id, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			addReferences(v)
+		case map[string]interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+
+	addReferences(question.Text)
+	addReferences(question.Code)
+	walk(question.Variants)
+
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+func newStorageKey(extension string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random) + extension, nil
+}
+
+func sanitizeFileName(fileName, extension string) string {
+	fileName = strings.TrimSpace(filepath.Base(strings.ReplaceAll(fileName, "\\", "/")))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		fileName = "attachment" + extension
+	}
+
+	if utf8.RuneCountInString(fileName) > attachmentFileNameMaxLength {
+		fileName = string([]rune(fileName)[:attachmentFileNameMaxLength])
+	}
+	return fileName
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+)
+
+// blobStore serves empty blobs and keeps keys of deleted ones
+type blobStore struct {
+	model.BlobStore
+	deleted *[]string
+}
+
+func (blobStore) Open(context.Context, string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func (s blobStore) Delete(_ context.Context, key string) error {
+	*s.deleted = append(*s.deleted, key)
+	return nil
+}
+
+func TestGetAttachmentChecksQuestion(t *testing.T) {
+	const (
+		uploaderID   = 10
+		maintainerID = 20
+		learnerID    = 30
+	)
+
+	attachments := map[int64]dto.Attachment{
+		1: {ID: 1, QuestionID: 1, CreatorUserID: uploaderID},
+		2: {ID: 2, CreatorUserID: uploaderID}, // not linked yet
+	}
+	question := func(status dto.QuestionStatusName) dto.Question {
+		return dto.Question{ID: 1, Status: status, SubjectID: 5, Creator: dto.User{ID: uploaderID}}
+	}
+
+	learner := policy.Principal{UserID: learnerID, Role: dto.RoleUser}
+
+	tests := []struct {
+		name         string
+		principal    policy.Principal
+		question     dto.Question
+		attachmentID int64
+		want         error
+	}{
+		{"approved question", learner, question(dto.QuestionStatusNameApproved), 1, nil},
+		{"draft of other", learner, question(dto.QuestionStatusNameCreated), 1, dto.ErrForbidden},
+		{"own draft", policy.Principal{UserID: uploaderID, Role: dto.RoleUser}, question(dto.QuestionStatusNameCreated), 1, nil},
+		{"draft in maintained subject", policy.Principal{UserID: maintainerID, Role: dto.RoleUser}, question(dto.QuestionStatusNameCreated), 1, nil},
+		{"draft by moderator", policy.Principal{UserID: learnerID, Role: dto.RoleModerator}, question(dto.QuestionStatusNameCreated), 1, nil},
+		{"own upload", policy.Principal{UserID: uploaderID, Role: dto.RoleUser}, question(dto.QuestionStatusNameApproved), 2, nil},
+		{"upload of other", learner, question(dto.QuestionStatusNameApproved), 2, dto.ErrForbidden},
+		{"upload by admin", policy.Principal{UserID: learnerID, Role: dto.RoleAdmin}, question(dto.QuestionStatusNameApproved), 2, nil},
+		{"unknown attachment", learner, question(dto.QuestionStatusNameApproved), 3, dto.ErrAttachmentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := attachmentsService{
+				storage:   attachmentsStorage{attachments: attachments},
+				questions: questionsStorage{question: tt.question},
+				subjects:  subjectsStorage{maintainers: map[int64][]int64{5: {maintainerID}}},
+				blobs:     blobStore{},
+			}
+
+			ctx := policy.NewContext(context.Background(), tt.principal)
+			_, content, err := s.GetAttachment(ctx, tt.attachmentID)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if (content != nil) != (tt.want == nil) {
+				t.Errorf("content is returned: %v, want %v", content != nil, tt.want == nil)
+			}
+		})
+	}
+}
+
+// trashStorages have nothing in the trash
+type trashStorages struct {
+	model.QuizzesStorage
+	model.SubjectsStorage
+}
+
+func (trashStorages) PurgeQuizzes(context.Context, time.Duration) (int64, error) { return 0, nil }
+
+func (trashStorages) PurgeSubjects(context.Context, time.Duration) (int64, error) { return 0, nil }
+
+func TestPurgeRemovesUnlinkedAttachments(t *testing.T) {
+	var deleted []string
+	p := TrashPurger{
+		subjects:    trashStorages{},
+		questions:   questionsStorage{},
+		quizzes:     trashStorages{},
+		attachments: attachmentsStorage{unlinked: []dto.Attachment{{ID: 1, StorageKey: "a.png"}, {ID: 2, StorageKey: "b.png"}}},
+		blobs:       blobStore{deleted: &deleted},
+		logger:      logrus.New(),
+	}
+
+	if err := p.Purge(context.Background()); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+
+	if !slices.Equal(deleted, []string{"a.png", "b.png"}) {
+		t.Errorf("deleted blobs %v, want blobs of unlinked attachments", deleted)
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/service/middleware"
+	"slices"
+)
+
+const (
+	auditDefaultLimit = 50
+	auditMaxLimit     = 500
+)
+
+type auditService struct {
+	storage model.AuditStorage
+}
+
+func NewAuditService(deps Deps) model.Audit {
+	var svc model.Audit = auditService{
+		storage: deps.Storages.Audit,
+	}
+
+	// middleware services
+	svc = middleware.LoggingAuditMiddleware(deps.Logger)(svc)
+	svc = middleware.InstrumentingAuditMiddleware(deps.RequestCounter, deps.RequestLatencyMeter)(svc)
+
+	return svc
+}
+
+func (s auditService) GetAuditEntries(ctx context.Context, filter dto.AuditFilter) ([]dto.AuditEntry, error) {
+	if filter.EntityType != "" && !slices.Contains(dto.AuditEntities, filter.EntityType) {
+		return nil, dto.NewFieldError("entity_type", fmt.Errorf("%w: %q", dto.ErrInvalidAuditEntity, filter.EntityType))
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return nil, dto.NewFieldError("from", dto.ErrInvalidTimeRange)
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = auditDefaultLimit
+	}
+	if filter.Limit > auditMaxLimit {
+		filter.Limit = auditMaxLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.storage.GetAuditEntries(ctx, filter)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
+	"strconv"
+)
+
+// CloneQuestion creates a copy of the question owned by the user. Attachments are copied too, so the copy
+// can be edited independently. Status and validation of the copy follow the rules of AddQuestion.
+// Drafts and declined questions, as well as questions with hidden answers (see redactAnswers), can be
+// cloned by their creators and users who can moderate them only, otherwise the copy would reveal them.
+func (s questionsService) CloneQuestion(ctx context.Context, questionID int64) (int64, error) {
+	principal, ok := policy.FromContext(ctx)
+	if !ok {
+		return -1, fmt.Errorf("%w: unknown user", dto.ErrForbidden)
+	}
+	userID, userRole := principal.UserID, principal.Role
+
+	source, err := s.storage.GetQuestionByID(ctx, questionID)
+	if err != nil {
+		return -1, err
+	}
+
+	moderator, err := canMaintainSubject(ctx, s.subjectsStorage, userID, userRole, source.SubjectID)
+	if err != nil {
+		return -1, err
+	}
+
+	if source.Status != dto.QuestionStatusNameApproved && int64(source.Creator.ID) != userID && !moderator {
+		return -1, fmt.Errorf("%w: question %d is not approved", dto.ErrForbidden, questionID)
+	}
+
+	visible := []dto.Question{source}
+	if err = redactAnswers(ctx, s.subjectsStorage, visible); err != nil {
+		return -1, err
+	}
+	if source.Answer != nil && visible[0].Answer == nil {
+		return -1, fmt.Errorf("%w: answer of question %d is hidden", dto.ErrForbidden, questionID)
+	}
+
+	sourceAttachments, err := s.attachmentsStorage.GetAttachmentsByQuestionID(ctx, questionID)
+	if err != nil {
+		return -1, err
+	}
+
+	copies, err := s.copyAttachments(ctx, userID, sourceAttachments)
+	if err != nil {
+		return -1, err
+	}
+
+	ids := make(map[int64]int64, len(copies))
+	question := dto.InputQuestion{
+		Text:               source.Text,
+		Code:               source.Code,
+		CodeLanguage:       source.CodeLanguage,
+		CodeBlocks:         source.CodeBlocks,
+		Variants:           source.Variants,
+		Answer:             source.Answer,
+		TypeID:             int64(source.Type.ID),
+		SubjectID:          source.SubjectID,
+		CreatorUserID:      userID,
+		Tags:               source.Tags,
+		Difficulty:         source.Difficulty,
+		EstimatedTime:      source.EstimatedTime,
+		BloomLevel:         source.BloomLevel,
+		LearningObjectives: source.LearningObjectives,
+		SourceQuestionID:   source.ID,
+	}
+	for i, attachment := range sourceAttachments {
+		ids[attachment.ID] = copies[i].ID
+		question.AttachmentIDs = append(question.AttachmentIDs, copies[i].ID)
+	}
+
+	// references attachment://<id> point to the copies
+	question.Text = rewriteAttachmentReferences(question.Text, ids).(string)
+	question.Code = rewriteAttachmentReferences(question.Code, ids).(string)
+	if variants, ok := rewriteAttachmentReferences(question.Variants, ids).(map[string]interface{}); ok {
+		question.Variants = variants
+	}
+	question.AttachmentIDs = referencedAttachmentIDs(question)
+
+	if question, err = s.validateClone(ctx, question); err != nil {
+		removeAttachments(ctx, s.attachmentsStorage, s.blobs, s.logger, copies)
+		return -1, err
+	}
+
+	question = applyModerationRules(question, userID, moderator)
+	if !moderator {
+		question.Events = moderatorsEvents("AddQuestion")
+	}
+
+	id, err := s.storage.AddQuestion(ctx, question)
+	if err != nil {
+		removeAttachments(ctx, s.attachmentsStorage, s.blobs, s.logger, copies)
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// validateClone checks the copy like a new question, the source could be saved under older rules
+func (s questionsService) validateClone(ctx context.Context, question dto.InputQuestion) (dto.InputQuestion, error) {
+	tags, err := normalizeTags(question.Tags)
+	if err != nil {
+		return question, err
+	}
+	question.Tags = tags
+
+	if question, err = validateQuestionMetadata(question); err != nil {
+		return question, err
+	}
+
+	if question, err = validateQuestionCode(question); err != nil {
+		return question, err
+	}
+
+	return question, s.validateQuestionAnswer(ctx, question)
+}
+
+// copyAttachments copies blobs and registers them as new attachments without question,
+// on error already made copies are removed
+func (s questionsService) copyAttachments(ctx context.Context, userID int64, attachments []dto.Attachment) ([]dto.Attachment, error) {
+	copies := make([]dto.Attachment, 0, len(attachments))
+
+	copyAttachment := func(attachment dto.Attachment) (dto.Attachment, error) {
+		key, err := newStorageKey(filepath.Ext(attachment.StorageKey))
+		if err != nil {
+			return attachment, err
+		}
+
+		content, err := s.blobs.Open(ctx, attachment.StorageKey)
+		if err != nil {
+			return attachment, err
+		}
+		defer content.Close()
+
+		if err = s.blobs.Put(ctx, key, content); err != nil {
+			return attachment, err
+		}
+
+		attachment.ID = 0
+		attachment.QuestionID = 0
+		attachment.StorageKey = key
+		attachment.CreatorUserID = userID
+		if attachment.ID, err = s.attachmentsStorage.AddAttachment(ctx, attachment); err != nil {
+			if errDelete := s.blobs.Delete(ctx, key); errDelete != nil {
+				s.logger.WithFields(logrus.Fields{
+					"key":   key,
+					"error": errDelete,
+				}).Error("unable to delete blob of not registered attachment")
+			}
+			return attachment, err
+		}
+		return attachment, nil
+	}
+
+	for _, attachment := range attachments {
+		attachmentCopy, err := copyAttachment(attachment)
+		if err != nil {
+			removeAttachments(ctx, s.attachmentsStorage, s.blobs, s.logger, copies)
+			return nil, err
+		}
+		copies = append(copies, attachmentCopy)
+	}
+
+	return copies, nil
+}
+
+// rewriteAttachmentReferences replaces attachment://<old id> with attachment://<new id> in all strings of value
+func rewriteAttachmentReferences(value interface{}, ids map[int64]int64) interface{} {
+	switch v := value.(type) {
+	case string:
+		return attachmentReferenceRegexp.ReplaceAllStringFunc(v, func(reference string) string {
+			id, err := strconv.ParseInt(reference[len(dto.AttachmentReferencePrefix):], 10, 64)
+			if newID, ok := ids[id]; err == nil && ok {
+				return dto.AttachmentReferencePrefix + strconv.FormatInt(newID, 10)
+			}
+			return reference
+		})
+	case map[string]interface{}:
+		rewritten := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rewritten[key] = rewriteAttachmentReferences(item, ids)
+		}
+		return rewritten
+	case []interface{}:
+		rewritten := make([]interface{}, len(v))
+		for i, item := range v {
+			rewritten[i] = rewriteAttachmentReferences(item, ids)
+		}
+		return rewritten
+	}
+	return value
+}
+
+// CloneQuiz creates a copy of the quiz with the same questions owned by the user
+func (s quizzesService) CloneQuiz(ctx context.Context, userID int64, quizID int64, name string) (int64, error) {
+	return s.storage.CloneQuiz(ctx, quizID, userID, name)
+}
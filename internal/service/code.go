@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/pkg/highlight"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	codeBlocksMax         = 10
+	codeBlockTitleMaxSize = 200
+)
+
+// validateQuestionCode checks code languages of the question, language names are normalized
+// (e.g. "golang" -> "go"). Code without language is kept as is and rendered as plain text.
+func validateQuestionCode(question dto.InputQuestion) (dto.InputQuestion, error) {
+	if question.CodeLanguage != "" {
+		language, ok := highlight.Normalize(question.CodeLanguage)
+		if !ok {
+			return question, dto.NewFieldError("code_language", dto.ErrUnknownCodeLanguage)
+		}
+		question.CodeLanguage = language
+	}
+
+	if len(question.CodeBlocks) > codeBlocksMax {
+		return question, dto.NewFieldError("code_blocks", dto.ErrInvalidCodeBlock)
+	}
+
+	blocks := make([]dto.CodeBlock, 0, len(question.CodeBlocks))
+	for _, block := range question.CodeBlocks {
+		if strings.TrimSpace(block.Code) == "" {
+			return question, dto.NewFieldError("code_blocks", dto.ErrInvalidCodeBlock)
+		}
+
+		block.Title = strings.TrimSpace(block.Title)
+		if utf8.RuneCountInString(block.Title) > codeBlockTitleMaxSize {
+			return question, dto.NewFieldError("code_blocks", dto.ErrInvalidCodeBlock)
+		}
+
+		language, ok := highlight.Normalize(block.Language)
+		if !ok {
+			return question, dto.NewFieldError("code_blocks", dto.ErrUnknownCodeLanguage)
+		}
+		block.Language = language
+
+		blocks = append(blocks, block)
+	}
+	question.CodeBlocks = blocks
+
+	return question, nil
+}
+
+// GetQuestionCode returns tokenized question code: main code goes first, then code blocks
+func (s questionsService) GetQuestionCode(ctx context.Context, questionID int64) ([]dto.HighlightedCode, error) {
+	question, err := s.storage.GetQuestionByID(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var code = []dto.HighlightedCode{}
+	if question.Code != "" {
+		code = append(code, highlightCode("", question.CodeLanguage, question.Code))
+	}
+	for _, block := range question.CodeBlocks {
+		code = append(code, highlightCode(block.Title, block.Language, block.Code))
+	}
+
+	return code, nil
+}
+
+func highlightCode(title, language, code string) dto.HighlightedCode {
+	language, ok := highlight.Normalize(language)
+	if !ok {
+		language = highlight.PlainText
+	}
+
+	tokens := highlight.Tokenize(language, code)
+	result := dto.HighlightedCode{
+		Title:    title,
+		Language: language,
+		Tokens:   make([]dto.CodeToken, 0, len(tokens)),
+	}
+	for _, token := range tokens {
+		result.Tokens = append(result.Tokens, dto.CodeToken{Type: string(token.Type), Text: token.Text})
+	}
+
+	return result
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
+	"sort"
+)
+
+func (s questionsService) GetDuplicateClusters(ctx context.Context, subjectID int64, threshold float64) ([]dto.DuplicateCluster, error) {
+	if principal, ok := policy.FromContext(ctx); !ok || !principal.IsStaff() {
+		return nil, dto.ErrForbidden
+	}
+
+	if threshold <= 0 || threshold > 1 {
+		threshold = s.duplicateThreshold
+	}
+
+	pairs, err := s.storage.GetSimilarQuestionPairs(ctx, subjectID, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	return clusterDuplicates(pairs), nil
+}
+
+// clusterDuplicates joins similar pairs into connected groups (union-find),
+// clusters are ordered by max similarity, questions inside a cluster by id
+func clusterDuplicates(pairs []dto.SimilarQuestionPair) []dto.DuplicateCluster {
+	parent := map[int64]int64{}
+	questions := map[int64]dto.SimilarQuestion{}
+
+	var find func(id int64) int64
+	find = func(id int64) int64 {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+
+	for _, pair := range pairs {
+		for _, q := range []dto.SimilarQuestion{pair.First, pair.Second} {
+			if _, ok := parent[q.ID]; !ok {
+				parent[q.ID] = q.ID
+				questions[q.ID] = q
+			}
+		}
+		if a, b := find(pair.First.ID), find(pair.Second.ID); a != b {
+			parent[b] = a
+		}
+	}
+
+	byRoot := map[int64]*dto.DuplicateCluster{}
+	for id, q := range questions {
+		root := find(id)
+		if byRoot[root] == nil {
+			byRoot[root] = &dto.DuplicateCluster{}
+		}
+		byRoot[root].Questions = append(byRoot[root].Questions, q)
+	}
+
+	for _, pair := range pairs {
+		cluster := byRoot[find(pair.First.ID)]
+		if pair.Similarity > cluster.MaxSimilarity {
+			cluster.MaxSimilarity = pair.Similarity
+		}
+	}
+
+	clusters := make([]dto.DuplicateCluster, 0, len(byRoot))
+	for _, cluster := range byRoot {
+		sort.Slice(cluster.Questions, func(i, j int) bool {
+			return cluster.Questions[i].ID < cluster.Questions[j].ID
+		})
+		clusters = append(clusters, *cluster)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].MaxSimilarity != clusters[j].MaxSimilarity {
+			return clusters[i].MaxSimilarity > clusters[j].MaxSimilarity
+		}
+		return clusters[i].Questions[0].ID < clusters[j].Questions[0].ID
+	})
+
+	return clusters
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"quiz_backend_core/internal/dto"
+	"strings"
+)
+
+// gradeProgram compares outputs of the program with expected outputs of hidden tests.
+// Score is a share of passed tests.
+func gradeProgram(submission dto.Submission, tests []dto.ProgramTest, run dto.RunResult) dto.Submission {
+	submission.TotalTests = len(tests)
+	submission.PassedTests = 0
+	submission.Score = 0
+	submission.Results = make([]dto.TestResult, 0, len(tests))
+
+	if run.CompileError != "" {
+		submission.Status = dto.SubmissionStatusCompileError
+		submission.CompileError = run.CompileError
+		return submission
+	}
+
+	for i, test := range tests {
+		result := dto.TestResult{Index: i}
+
+		if i >= len(run.Outputs) {
+			result.Status = dto.TestStatusRuntimeError
+			submission.Results = append(submission.Results, result)
+			continue
+		}
+
+		output := run.Outputs[i]
+		result.Duration = output.Duration
+		switch {
+		case output.TimedOut:
+			result.Status = dto.TestStatusTimeout
+		case output.ExitCode != 0:
+			result.Status = dto.TestStatusRuntimeError
+		case normalizeOutput(output.Stdout) != normalizeOutput(test.ExpectedOutput):
+			result.Status = dto.TestStatusWrongAnswer
+		default:
+			result.Status = dto.TestStatusPassed
+			submission.PassedTests++
+		}
+		submission.Results = append(submission.Results, result)
+	}
+
+	if submission.TotalTests != 0 {
+		submission.Score = float64(submission.PassedTests) / float64(submission.TotalTests)
+	}
+
+	switch {
+	case submission.PassedTests == submission.TotalTests:
+		submission.Status = dto.SubmissionStatusAccepted
+	case submission.PassedTests > 0:
+		submission.Status = dto.SubmissionStatusPartial
+	default:
+		submission.Status = dto.SubmissionStatusRejected
+	}
+
+	return submission
+}
+
+// normalizeOutput ignores trailing spaces of lines, trailing empty lines and CRLF line endings
+func normalizeOutput(output string) string {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+)
+
+// canMaintainSubject says whether the user can edit the subject and moderate its questions:
+// admins and moderators can everywhere, maintainers in the subtrees granted to them
+func canMaintainSubject(ctx context.Context, storage model.SubjectsStorage, userID int64, userRole dto.Role, subjectID int64) (bool, error) {
+	if userRole == dto.RoleAdmin || userRole == dto.RoleModerator {
+		return true, nil
+	}
+
+	if subjectID <= 0 {
+		return false, nil
+	}
+
+	return storage.IsSubjectMaintainer(ctx, subjectID, userID)
+}
+
+func (s subjectsService) GetSubjectMaintainers(ctx context.Context, subjectID int64) ([]dto.SubjectMaintainer, error) {
+	return s.storage.GetSubjectMaintainers(ctx, subjectID)
+}
+
+// GrantSubjectMaintainer makes maintainerUserID a maintainer of the subject, only maintainers of the subject can grant
+// (checked by the policy)
+func (s subjectsService) GrantSubjectMaintainer(ctx context.Context, userID int64, subjectID int64, maintainerUserID int64) error {
+	return s.storage.AddSubjectMaintainer(ctx, dto.SubjectMaintainer{
+		SubjectID:       subjectID,
+		UserID:          maintainerUserID,
+		GrantedByUserID: userID,
+	})
+}
+
+// RevokeSubjectMaintainer removes the grant made on the subject itself, inherited grants are revoked on ancestors
+func (s subjectsService) RevokeSubjectMaintainer(ctx context.Context, userID int64, subjectID int64, maintainerUserID int64) error {
+	return s.storage.DeleteSubjectMaintainer(ctx, subjectID, maintainerUserID)
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-kit/kit/metrics"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func InstrumentingAPITokensMiddleware(requestCount metrics.Counter, requestLatency metrics.Histogram) model.APITokensMiddleware {
+	return func(next model.APITokens) model.APITokens {
+		return instrumentingAPITokensMiddleware{
+			requestCount,
+			requestLatency,
+			next,
+		}
+	}
+}
+
+type instrumentingAPITokensMiddleware struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           model.APITokens
+}
+
+func (im instrumentingAPITokensMiddleware) CreateAPIToken(ctx context.Context, userID int64, input dto.InputAPIToken) (token dto.APIToken, secret string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "createAPIToken", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	token, secret, err = im.next.CreateAPIToken(ctx, userID, input)
+	return
+}
+
+func (im instrumentingAPITokensMiddleware) GetAPITokens(ctx context.Context, userID int64) (tokens []dto.APIToken, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getAPITokens", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	tokens, err = im.next.GetAPITokens(ctx, userID)
+	return
+}
+
+func (im instrumentingAPITokensMiddleware) RevokeAPIToken(ctx context.Context, tokenID int64) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "revokeAPIToken", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	err = im.next.RevokeAPIToken(ctx, tokenID)
+	return
+}
+
+func (im instrumentingAPITokensMiddleware) AuthenticateAPIToken(ctx context.Context, secret string) (token dto.APIToken, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "authenticateAPIToken", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	token, err = im.next.AuthenticateAPIToken(ctx, secret)
+	return
+}
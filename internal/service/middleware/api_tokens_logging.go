@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func LoggingAPITokensMiddleware(logger *logrus.Logger) model.APITokensMiddleware {
+	return func(next model.APITokens) model.APITokens {
+		return &loggingAPITokensMiddleware{
+			next:   next,
+			logger: logger,
+		}
+	}
+}
+
+type loggingAPITokensMiddleware struct {
+	next   model.APITokens
+	logger *logrus.Logger
+}
+
+// secrets are never logged
+func (mw loggingAPITokensMiddleware) CreateAPIToken(ctx context.Context, userID int64, input dto.InputAPIToken) (token dto.APIToken, secret string, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":          time.Since(begin).Milliseconds(),
+			"error":         err,
+			"user_id":       userID,
+			"token_user_id": input.UserID,
+			"role":          input.Role,
+			"scopes":        input.Scopes,
+			"token_id":      token.ID,
+		}).Info("method == CreateAPIToken")
+	}(time.Now())
+	return mw.next.CreateAPIToken(ctx, userID, input)
+}
+
+func (mw loggingAPITokensMiddleware) GetAPITokens(ctx context.Context, userID int64) (tokens []dto.APIToken, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":    time.Since(begin).Milliseconds(),
+			"error":   err,
+			"user_id": userID,
+		}).Info("method == GetAPITokens")
+	}(time.Now())
+	return mw.next.GetAPITokens(ctx, userID)
+}
+
+func (mw loggingAPITokensMiddleware) RevokeAPIToken(ctx context.Context, tokenID int64) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":     time.Since(begin).Milliseconds(),
+			"error":    err,
+			"token_id": tokenID,
+		}).Info("method == RevokeAPIToken")
+	}(time.Now())
+	return mw.next.RevokeAPIToken(ctx, tokenID)
+}
+
+func (mw loggingAPITokensMiddleware) AuthenticateAPIToken(ctx context.Context, secret string) (token dto.APIToken, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":     time.Since(begin).Milliseconds(),
+			"error":    err,
+			"token_id": token.ID,
+			"user_id":  token.UserID,
+		}).Info("method == AuthenticateAPIToken")
+	}(time.Now())
+	return mw.next.AuthenticateAPIToken(ctx, secret)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-kit/kit/metrics"
+	"io"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func InstrumentingAttachmentsMiddleware(requestCount metrics.Counter, requestLatency metrics.Histogram) model.AttachmentsMiddleware {
+	return func(next model.Attachments) model.Attachments {
+		return instrumentingAttachmentsMiddleware{
+			requestCount,
+			requestLatency,
+			next,
+		}
+	}
+}
+
+type instrumentingAttachmentsMiddleware struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           model.Attachments
+}
+
+func (im instrumentingAttachmentsMiddleware) UploadAttachment(ctx context.Context, attachment dto.Attachment, content []byte) (result dto.Attachment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "uploadAttachment", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	result, err = im.next.UploadAttachment(ctx, attachment, content)
+	return
+}
+
+func (im instrumentingAttachmentsMiddleware) GetAttachment(ctx context.Context, attachmentID int64) (attachment dto.Attachment, content io.ReadCloser, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getAttachment", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	attachment, content, err = im.next.GetAttachment(ctx, attachmentID)
+	return
+}
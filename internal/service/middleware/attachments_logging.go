@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"io"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func LoggingAttachmentsMiddleware(logger *logrus.Logger) model.AttachmentsMiddleware {
+	return func(next model.Attachments) model.Attachments {
+		return &loggingAttachmentsMiddleware{
+			next:   next,
+			logger: logger,
+		}
+	}
+}
+
+type loggingAttachmentsMiddleware struct {
+	next   model.Attachments
+	logger *logrus.Logger
+}
+
+func (mw loggingAttachmentsMiddleware) UploadAttachment(ctx context.Context, attachment dto.Attachment, content []byte) (result dto.Attachment, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":     time.Since(begin).Milliseconds(),
+			"error":    err,
+			"fileName": attachment.FileName,
+			"size":     len(content),
+		}).Info("method == UploadAttachment")
+	}(time.Now())
+	return mw.next.UploadAttachment(ctx, attachment, content)
+}
+
+func (mw loggingAttachmentsMiddleware) GetAttachment(ctx context.Context, attachmentID int64) (attachment dto.Attachment, content io.ReadCloser, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":  time.Since(begin).Milliseconds(),
+			"error": err,
+			"id":    attachmentID,
+		}).Info("method == GetAttachment")
+	}(time.Now())
+	return mw.next.GetAttachment(ctx, attachmentID)
+}
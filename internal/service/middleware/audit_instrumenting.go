@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-kit/kit/metrics"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func InstrumentingAuditMiddleware(requestCount metrics.Counter, requestLatency metrics.Histogram) model.AuditMiddleware {
+	return func(next model.Audit) model.Audit {
+		return instrumentingAuditMiddleware{
+			requestCount,
+			requestLatency,
+			next,
+		}
+	}
+}
+
+type instrumentingAuditMiddleware struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           model.Audit
+}
+
+func (im instrumentingAuditMiddleware) GetAuditEntries(ctx context.Context, filter dto.AuditFilter) (entries []dto.AuditEntry, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getAuditEntries", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	entries, err = im.next.GetAuditEntries(ctx, filter)
+	return
+}
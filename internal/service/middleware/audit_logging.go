@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func LoggingAuditMiddleware(logger *logrus.Logger) model.AuditMiddleware {
+	return func(next model.Audit) model.Audit {
+		return &loggingAuditMiddleware{
+			next:   next,
+			logger: logger,
+		}
+	}
+}
+
+type loggingAuditMiddleware struct {
+	next   model.Audit
+	logger *logrus.Logger
+}
+
+func (mw loggingAuditMiddleware) GetAuditEntries(ctx context.Context, filter dto.AuditFilter) (entries []dto.AuditEntry, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":          time.Since(begin).Milliseconds(),
+			"error":         err,
+			"entity_type":   filter.EntityType,
+			"entity_id":     filter.EntityID,
+			"actor_user_id": filter.ActorUserID,
+			"count":         len(entries),
+		}).Info("method == GetAuditEntries")
+	}(time.Now())
+	return mw.next.GetAuditEntries(ctx, filter)
+}
@@ -25,13 +25,23 @@ type instrumentingQuestionsMiddleware struct {
 	next           model.Questions
 }
 
-func (im instrumentingQuestionsMiddleware) GetQuestions(ctx context.Context, creatorUserID, subjectID, statusID int64) (questions []dto.Question, err error) {
+func (im instrumentingQuestionsMiddleware) GetQuestions(ctx context.Context, filter dto.QuestionFilter) (questions []dto.Question, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "getQuestions", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	questions, err = im.next.GetQuestions(ctx, creatorUserID, subjectID, statusID)
+	questions, err = im.next.GetQuestions(ctx, filter)
+	return
+}
+
+func (im instrumentingQuestionsMiddleware) SearchQuestions(ctx context.Context, search string, filter dto.QuestionFilter, limit, offset int) (results []dto.QuestionSearchResult, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "searchQuestions", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	results, err = im.next.SearchQuestions(ctx, search, filter, limit, offset)
 	return
 }
 
@@ -55,13 +65,13 @@ func (im instrumentingQuestionsMiddleware) GetQuestionStatuses(ctx context.Conte
 	return
 }
 
-func (im instrumentingQuestionsMiddleware) AddQuestion(ctx context.Context, questionAdd dto.InputQuestion) (id int64, err error) {
+func (im instrumentingQuestionsMiddleware) AddQuestion(ctx context.Context, questionAdd dto.InputQuestion, force bool) (id int64, similar []dto.SimilarQuestion, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "addQuestion", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	id, err = im.next.AddQuestion(ctx, questionAdd)
+	id, similar, err = im.next.AddQuestion(ctx, questionAdd, force)
 	return
 }
 
@@ -94,3 +104,93 @@ func (im instrumentingQuestionsMiddleware) DeleteQuestion(ctx context.Context, q
 	err = im.next.DeleteQuestion(ctx, questionID)
 	return
 }
+
+func (im instrumentingQuestionsMiddleware) GetDuplicateClusters(ctx context.Context, subjectID int64, threshold float64) (clusters []dto.DuplicateCluster, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getDuplicateClusters", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	clusters, err = im.next.GetDuplicateClusters(ctx, subjectID, threshold)
+	return
+}
+
+func (im instrumentingQuestionsMiddleware) GetQuestionByID(ctx context.Context, questionID int64) (question dto.Question, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getQuestionByID", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	question, err = im.next.GetQuestionByID(ctx, questionID)
+	return
+}
+
+func (im instrumentingQuestionsMiddleware) GetQuestionCode(ctx context.Context, questionID int64) (code []dto.HighlightedCode, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getQuestionCode", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	code, err = im.next.GetQuestionCode(ctx, questionID)
+	return
+}
+
+func (im instrumentingQuestionsMiddleware) RenderQuestion(ctx context.Context, questionID int64, seed int64) (rendered dto.RenderedQuestion, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "renderQuestion", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	rendered, err = im.next.RenderQuestion(ctx, questionID, seed)
+	return
+}
+
+func (im instrumentingQuestionsMiddleware) CheckParametricAnswer(ctx context.Context, questionID int64, seed int64, value float64) (result dto.ParametricCheckResult, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "checkParametricAnswer", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	result, err = im.next.CheckParametricAnswer(ctx, questionID, seed, value)
+	return
+}
+
+func (im instrumentingQuestionsMiddleware) GradeAnswer(ctx context.Context, questionID int64, response map[string]interface{}) (result dto.GradeResult, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "gradeAnswer", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	result, err = im.next.GradeAnswer(ctx, questionID, response)
+	return
+}
+
+func (im instrumentingQuestionsMiddleware) CloneQuestion(ctx context.Context, questionID int64) (id int64, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "cloneQuestion", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	id, err = im.next.CloneQuestion(ctx, questionID)
+	return
+}
+
+func (im instrumentingQuestionsMiddleware) GetDeletedQuestions(ctx context.Context, filter dto.QuestionFilter) (questions []dto.Question, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getDeletedQuestions", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	questions, err = im.next.GetDeletedQuestions(ctx, filter)
+	return
+}
+
+func (im instrumentingQuestionsMiddleware) RestoreQuestion(ctx context.Context, questionID int64) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "restoreQuestion", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	err = im.next.RestoreQuestion(ctx, questionID)
+	return
+}
@@ -22,14 +22,26 @@ type loggingQuestionsMiddleware struct {
 	logger *logrus.Logger
 }
 
-func (mw loggingQuestionsMiddleware) GetQuestions(ctx context.Context, creatorUserID, subjectId, statusID int64) (question []dto.Question, err error) {
+func (mw loggingQuestionsMiddleware) GetQuestions(ctx context.Context, filter dto.QuestionFilter) (question []dto.Question, err error) {
 	defer func(begin time.Time) {
 		mw.logger.WithFields(logrus.Fields{
 			"took":  time.Since(begin).Milliseconds(),
 			"error": err,
 		}).Info("method == GetQuestions")
 	}(time.Now())
-	return mw.next.GetQuestions(ctx, creatorUserID, subjectId, statusID)
+	return mw.next.GetQuestions(ctx, filter)
+}
+
+func (mw loggingQuestionsMiddleware) SearchQuestions(ctx context.Context, search string, filter dto.QuestionFilter, limit, offset int) (results []dto.QuestionSearchResult, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":   time.Since(begin).Milliseconds(),
+			"error":  err,
+			"search": search,
+			"found":  len(results),
+		}).Info("method == SearchQuestions")
+	}(time.Now())
+	return mw.next.SearchQuestions(ctx, search, filter, limit, offset)
 }
 
 func (mw loggingQuestionsMiddleware) GetQuestionTypes(ctx context.Context) (types []dto.QuestionType, err error) {
@@ -52,7 +64,7 @@ func (mw loggingQuestionsMiddleware) GetQuestionStatuses(ctx context.Context) (t
 	return mw.next.GetQuestionStatuses(ctx)
 }
 
-func (mw loggingQuestionsMiddleware) AddQuestion(ctx context.Context, question dto.InputQuestion) (id int64, err error) {
+func (mw loggingQuestionsMiddleware) AddQuestion(ctx context.Context, question dto.InputQuestion, force bool) (id int64, similar []dto.SimilarQuestion, err error) {
 	defer func(begin time.Time) {
 		mw.logger.WithFields(logrus.Fields{
 			"took":  time.Since(begin).Milliseconds(),
@@ -60,9 +72,11 @@ func (mw loggingQuestionsMiddleware) AddQuestion(ctx context.Context, question d
 			//"userID":   ctx.Value(ContextVariablesUserID),
 			//"userRole": ctx.Value(ContextVariablesUserRole),
 			"question": question,
+			"force":    force,
+			"similar":  len(similar),
 		}).Info("method == AddQuestion")
 	}(time.Now())
-	return mw.next.AddQuestion(ctx, question)
+	return mw.next.AddQuestion(ctx, question, force)
 }
 
 func (mw loggingQuestionsMiddleware) UpdateQuestionByID(ctx context.Context, questionID int64, question dto.InputQuestion) (err error) {
@@ -96,3 +110,108 @@ func (mw loggingQuestionsMiddleware) DeleteQuestion(ctx context.Context, questio
 	}(time.Now())
 	return mw.next.DeleteQuestion(ctx, questionID)
 }
+
+func (mw loggingQuestionsMiddleware) GetDuplicateClusters(ctx context.Context, subjectID int64, threshold float64) (clusters []dto.DuplicateCluster, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":      time.Since(begin).Milliseconds(),
+			"error":     err,
+			"subjectID": subjectID,
+			"threshold": threshold,
+			"clusters":  len(clusters),
+		}).Info("method == GetDuplicateClusters")
+	}(time.Now())
+	return mw.next.GetDuplicateClusters(ctx, subjectID, threshold)
+}
+
+func (mw loggingQuestionsMiddleware) GetQuestionByID(ctx context.Context, questionID int64) (question dto.Question, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":       time.Since(begin).Milliseconds(),
+			"error":      err,
+			"questionID": questionID,
+		}).Info("method == GetQuestionByID")
+	}(time.Now())
+	return mw.next.GetQuestionByID(ctx, questionID)
+}
+
+func (mw loggingQuestionsMiddleware) GetQuestionCode(ctx context.Context, questionID int64) (code []dto.HighlightedCode, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":       time.Since(begin).Milliseconds(),
+			"error":      err,
+			"questionID": questionID,
+		}).Info("method == GetQuestionCode")
+	}(time.Now())
+	return mw.next.GetQuestionCode(ctx, questionID)
+}
+
+func (mw loggingQuestionsMiddleware) RenderQuestion(ctx context.Context, questionID int64, seed int64) (rendered dto.RenderedQuestion, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":       time.Since(begin).Milliseconds(),
+			"error":      err,
+			"questionID": questionID,
+			"seed":       seed,
+		}).Info("method == RenderQuestion")
+	}(time.Now())
+	return mw.next.RenderQuestion(ctx, questionID, seed)
+}
+
+func (mw loggingQuestionsMiddleware) CheckParametricAnswer(ctx context.Context, questionID int64, seed int64, value float64) (result dto.ParametricCheckResult, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":       time.Since(begin).Milliseconds(),
+			"error":      err,
+			"questionID": questionID,
+			"seed":       seed,
+			"correct":    result.Correct,
+		}).Info("method == CheckParametricAnswer")
+	}(time.Now())
+	return mw.next.CheckParametricAnswer(ctx, questionID, seed, value)
+}
+
+func (mw loggingQuestionsMiddleware) GradeAnswer(ctx context.Context, questionID int64, response map[string]interface{}) (result dto.GradeResult, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":       time.Since(begin).Milliseconds(),
+			"error":      err,
+			"questionID": questionID,
+			"score":      result.Score,
+		}).Info("method == GradeAnswer")
+	}(time.Now())
+	return mw.next.GradeAnswer(ctx, questionID, response)
+}
+
+func (mw loggingQuestionsMiddleware) CloneQuestion(ctx context.Context, questionID int64) (id int64, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":       time.Since(begin).Milliseconds(),
+			"error":      err,
+			"questionID": questionID,
+			"cloneID":    id,
+		}).Info("method == CloneQuestion")
+	}(time.Now())
+	return mw.next.CloneQuestion(ctx, questionID)
+}
+
+func (mw loggingQuestionsMiddleware) GetDeletedQuestions(ctx context.Context, filter dto.QuestionFilter) (questions []dto.Question, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":  time.Since(begin).Milliseconds(),
+			"error": err,
+		}).Info("method == GetDeletedQuestions")
+	}(time.Now())
+	return mw.next.GetDeletedQuestions(ctx, filter)
+}
+
+func (mw loggingQuestionsMiddleware) RestoreQuestion(ctx context.Context, questionID int64) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":       time.Since(begin).Milliseconds(),
+			"error":      err,
+			"questionID": questionID,
+		}).Info("method == RestoreQuestion")
+	}(time.Now())
+	return mw.next.RestoreQuestion(ctx, questionID)
+}
@@ -32,14 +32,14 @@ func (mw loggingSubjectsMiddleware) GetSubjects(ctx context.Context) (subjects [
 	return mw.next.GetSubjects(ctx)
 }
 
-func (mw loggingSubjectsMiddleware) AddSubject(ctx context.Context, subject dto.Subject) (id int64, err error) {
+func (mw loggingSubjectsMiddleware) AddSubject(ctx context.Context, userID int64, subject dto.Subject) (id int64, err error) {
 	defer func(begin time.Time) {
 		mw.logger.WithFields(logrus.Fields{
 			"took":  time.Since(begin).Milliseconds(),
 			"error": err,
 		}).Info("method == AddSubject")
 	}(time.Now())
-	return mw.next.AddSubject(ctx, subject)
+	return mw.next.AddSubject(ctx, userID, subject)
 }
 
 func (mw loggingSubjectsMiddleware) GetStatistic(ctx context.Context, userId int64, userRole dto.Role) (statistic dto.Statistic, err error) {
@@ -52,22 +52,139 @@ func (mw loggingSubjectsMiddleware) GetStatistic(ctx context.Context, userId int
 	return mw.next.GetStatistic(ctx, userId, userRole)
 }
 
-func (mw loggingSubjectsMiddleware) UpdateSubject(ctx context.Context, subject dto.Subject) (err error) {
+func (mw loggingSubjectsMiddleware) UpdateSubject(ctx context.Context, userID int64, subject dto.Subject) (err error) {
 	defer func(begin time.Time) {
 		mw.logger.WithFields(logrus.Fields{
 			"took":  time.Since(begin).Milliseconds(),
 			"error": err,
 		}).Info("method == UpdateSubject")
 	}(time.Now())
-	return mw.next.UpdateSubject(ctx, subject)
+	return mw.next.UpdateSubject(ctx, userID, subject)
 }
 
-func (mw loggingSubjectsMiddleware) DeleteSubjectByID(ctx context.Context, subjectID int64) (err error) {
+func (mw loggingSubjectsMiddleware) DeleteSubjectByID(ctx context.Context, userID int64, subjectID int64, mode dto.SubjectDeleteMode, targetSubjectID int64) (preview dto.SubjectDeletePreview, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":            time.Since(begin).Milliseconds(),
+			"error":           err,
+			"subjectID":       subjectID,
+			"mode":            mode,
+			"targetSubjectID": targetSubjectID,
+		}).Info("method == DeleteSubjectByID")
+	}(time.Now())
+	return mw.next.DeleteSubjectByID(ctx, userID, subjectID, mode, targetSubjectID)
+}
+
+func (mw loggingSubjectsMiddleware) GetSubjectByID(ctx context.Context, subjectID int64) (subject dto.Subject, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":      time.Since(begin).Milliseconds(),
+			"error":     err,
+			"subjectID": subjectID,
+		}).Info("method == GetSubjectByID")
+	}(time.Now())
+	return mw.next.GetSubjectByID(ctx, subjectID)
+}
+
+func (mw loggingSubjectsMiddleware) GetSubjectDeletePreview(ctx context.Context, subjectID int64) (preview dto.SubjectDeletePreview, err error) {
 	defer func(begin time.Time) {
 		mw.logger.WithFields(logrus.Fields{
 			"took":  time.Since(begin).Milliseconds(),
 			"error": err,
-		}).Info("method == DeleteSubjectByID")
+		}).Info("method == GetSubjectDeletePreview")
+	}(time.Now())
+	return mw.next.GetSubjectDeletePreview(ctx, subjectID)
+}
+
+func (mw loggingSubjectsMiddleware) GetDeletedSubjects(ctx context.Context) (subjects []dto.Subject, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":  time.Since(begin).Milliseconds(),
+			"error": err,
+		}).Info("method == GetDeletedSubjects")
+	}(time.Now())
+	return mw.next.GetDeletedSubjects(ctx)
+}
+
+func (mw loggingSubjectsMiddleware) RestoreSubject(ctx context.Context, userID int64, subjectID int64) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":  time.Since(begin).Milliseconds(),
+			"error": err,
+		}).Info("method == RestoreSubject")
+	}(time.Now())
+	return mw.next.RestoreSubject(ctx, userID, subjectID)
+}
+
+func (mw loggingSubjectsMiddleware) MoveSubject(ctx context.Context, userID int64, subjectID int64, parentID int64) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":      time.Since(begin).Milliseconds(),
+			"error":     err,
+			"subjectID": subjectID,
+			"parentID":  parentID,
+		}).Info("method == MoveSubject")
+	}(time.Now())
+	return mw.next.MoveSubject(ctx, userID, subjectID, parentID)
+}
+
+func (mw loggingSubjectsMiddleware) MergeSubjects(ctx context.Context, userID int64, sourceID int64, targetID int64) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":     time.Since(begin).Milliseconds(),
+			"error":    err,
+			"sourceID": sourceID,
+			"targetID": targetID,
+		}).Info("method == MergeSubjects")
+	}(time.Now())
+	return mw.next.MergeSubjects(ctx, userID, sourceID, targetID)
+}
+
+func (mw loggingSubjectsMiddleware) GetSubjectTree(ctx context.Context, rootID int64, depth int) (tree []dto.SubjectTreeNode, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":   time.Since(begin).Milliseconds(),
+			"error":  err,
+			"rootID": rootID,
+			"depth":  depth,
+		}).Info("method == GetSubjectTree")
+	}(time.Now())
+	return mw.next.GetSubjectTree(ctx, rootID, depth)
+}
+
+func (mw loggingSubjectsMiddleware) GetSubjectMaintainers(ctx context.Context, subjectID int64) (maintainers []dto.SubjectMaintainer, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":      time.Since(begin).Milliseconds(),
+			"error":     err,
+			"subjectID": subjectID,
+		}).Info("method == GetSubjectMaintainers")
+	}(time.Now())
+	return mw.next.GetSubjectMaintainers(ctx, subjectID)
+}
+
+func (mw loggingSubjectsMiddleware) GrantSubjectMaintainer(ctx context.Context, userID int64, subjectID int64, maintainerUserID int64) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":             time.Since(begin).Milliseconds(),
+			"error":            err,
+			"userID":           userID,
+			"subjectID":        subjectID,
+			"maintainerUserID": maintainerUserID,
+		}).Info("method == GrantSubjectMaintainer")
+	}(time.Now())
+	return mw.next.GrantSubjectMaintainer(ctx, userID, subjectID, maintainerUserID)
+}
+
+func (mw loggingSubjectsMiddleware) RevokeSubjectMaintainer(ctx context.Context, userID int64, subjectID int64, maintainerUserID int64) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":             time.Since(begin).Milliseconds(),
+			"error":            err,
+			"userID":           userID,
+			"subjectID":        subjectID,
+			"maintainerUserID": maintainerUserID,
+		}).Info("method == RevokeSubjectMaintainer")
 	}(time.Now())
-	return mw.next.DeleteSubjectByID(ctx, subjectID)
+	return mw.next.RevokeSubjectMaintainer(ctx, userID, subjectID, maintainerUserID)
 }
@@ -35,13 +35,13 @@ func (im instrumentingSubjectsMiddleware) GetSubjects(ctx context.Context) (subj
 	return
 }
 
-func (im instrumentingSubjectsMiddleware) AddSubject(ctx context.Context, subject dto.Subject) (id int64, err error) {
+func (im instrumentingSubjectsMiddleware) AddSubject(ctx context.Context, userID int64, subject dto.Subject) (id int64, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "AddSubject", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	id, err = im.next.AddSubject(ctx, subject)
+	id, err = im.next.AddSubject(ctx, userID, subject)
 	return
 }
 
@@ -55,22 +55,122 @@ func (im instrumentingSubjectsMiddleware) GetStatistic(ctx context.Context, user
 	return
 }
 
-func (im instrumentingSubjectsMiddleware) UpdateSubject(ctx context.Context, subject dto.Subject) (err error) {
+func (im instrumentingSubjectsMiddleware) UpdateSubject(ctx context.Context, userID int64, subject dto.Subject) (err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "UpdateSubject", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	err = im.next.UpdateSubject(ctx, subject)
+	err = im.next.UpdateSubject(ctx, userID, subject)
 	return
 }
 
-func (im instrumentingSubjectsMiddleware) DeleteSubjectByID(ctx context.Context, subjectID int64) (err error) {
+func (im instrumentingSubjectsMiddleware) DeleteSubjectByID(ctx context.Context, userID int64, subjectID int64, mode dto.SubjectDeleteMode, targetSubjectID int64) (preview dto.SubjectDeletePreview, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "DeleteSubject", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	err = im.next.DeleteSubjectByID(ctx, subjectID)
+	preview, err = im.next.DeleteSubjectByID(ctx, userID, subjectID, mode, targetSubjectID)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) GetSubjectByID(ctx context.Context, subjectID int64) (subject dto.Subject, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GetSubjectByID", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	subject, err = im.next.GetSubjectByID(ctx, subjectID)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) GetSubjectDeletePreview(ctx context.Context, subjectID int64) (preview dto.SubjectDeletePreview, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GetSubjectDeletePreview", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	preview, err = im.next.GetSubjectDeletePreview(ctx, subjectID)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) GetDeletedSubjects(ctx context.Context) (subjects []dto.Subject, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GetDeletedSubjects", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	subjects, err = im.next.GetDeletedSubjects(ctx)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) RestoreSubject(ctx context.Context, userID int64, subjectID int64) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "RestoreSubject", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	err = im.next.RestoreSubject(ctx, userID, subjectID)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) MoveSubject(ctx context.Context, userID int64, subjectID int64, parentID int64) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "MoveSubject", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	err = im.next.MoveSubject(ctx, userID, subjectID, parentID)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) MergeSubjects(ctx context.Context, userID int64, sourceID int64, targetID int64) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "MergeSubjects", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	err = im.next.MergeSubjects(ctx, userID, sourceID, targetID)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) GetSubjectTree(ctx context.Context, rootID int64, depth int) (tree []dto.SubjectTreeNode, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getSubjectTree", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	tree, err = im.next.GetSubjectTree(ctx, rootID, depth)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) GetSubjectMaintainers(ctx context.Context, subjectID int64) (maintainers []dto.SubjectMaintainer, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GetSubjectMaintainers", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	maintainers, err = im.next.GetSubjectMaintainers(ctx, subjectID)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) GrantSubjectMaintainer(ctx context.Context, userID int64, subjectID int64, maintainerUserID int64) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GrantSubjectMaintainer", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	err = im.next.GrantSubjectMaintainer(ctx, userID, subjectID, maintainerUserID)
+	return
+}
+
+func (im instrumentingSubjectsMiddleware) RevokeSubjectMaintainer(ctx context.Context, userID int64, subjectID int64, maintainerUserID int64) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "RevokeSubjectMaintainer", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	err = im.next.RevokeSubjectMaintainer(ctx, userID, subjectID, maintainerUserID)
 	return
 }
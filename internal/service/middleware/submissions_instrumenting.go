@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-kit/kit/metrics"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func InstrumentingSubmissionsMiddleware(requestCount metrics.Counter, requestLatency metrics.Histogram) model.SubmissionsMiddleware {
+	return func(next model.Submissions) model.Submissions {
+		return instrumentingSubmissionsMiddleware{
+			requestCount,
+			requestLatency,
+			next,
+		}
+	}
+}
+
+type instrumentingSubmissionsMiddleware struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           model.Submissions
+}
+
+func (im instrumentingSubmissionsMiddleware) SubmitProgram(ctx context.Context, submission dto.Submission) (result dto.Submission, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "submitProgram", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	result, err = im.next.SubmitProgram(ctx, submission)
+	return
+}
+
+func (im instrumentingSubmissionsMiddleware) GetSubmissionByID(ctx context.Context, userID int64, userRole dto.Role, submissionID int64) (submission dto.Submission, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getSubmissionByID", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	submission, err = im.next.GetSubmissionByID(ctx, userID, userRole, submissionID)
+	return
+}
+
+func (im instrumentingSubmissionsMiddleware) GetSubmissions(ctx context.Context, userID int64, questionID int64) (submissions []dto.Submission, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getSubmissions", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	submissions, err = im.next.GetSubmissions(ctx, userID, questionID)
+	return
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func LoggingSubmissionsMiddleware(logger *logrus.Logger) model.SubmissionsMiddleware {
+	return func(next model.Submissions) model.Submissions {
+		return &loggingSubmissionsMiddleware{
+			next:   next,
+			logger: logger,
+		}
+	}
+}
+
+type loggingSubmissionsMiddleware struct {
+	next   model.Submissions
+	logger *logrus.Logger
+}
+
+func (mw loggingSubmissionsMiddleware) SubmitProgram(ctx context.Context, submission dto.Submission) (result dto.Submission, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":       time.Since(begin).Milliseconds(),
+			"error":      err,
+			"questionID": submission.QuestionID,
+			"userID":     submission.UserID,
+			"status":     result.Status,
+			"passed":     result.PassedTests,
+			"total":      result.TotalTests,
+		}).Info("method == SubmitProgram")
+	}(time.Now())
+	return mw.next.SubmitProgram(ctx, submission)
+}
+
+func (mw loggingSubmissionsMiddleware) GetSubmissionByID(ctx context.Context, userID int64, userRole dto.Role, submissionID int64) (submission dto.Submission, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":  time.Since(begin).Milliseconds(),
+			"error": err,
+			"id":    submissionID,
+		}).Info("method == GetSubmissionByID")
+	}(time.Now())
+	return mw.next.GetSubmissionByID(ctx, userID, userRole, submissionID)
+}
+
+func (mw loggingSubmissionsMiddleware) GetSubmissions(ctx context.Context, userID int64, questionID int64) (submissions []dto.Submission, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":       time.Since(begin).Milliseconds(),
+			"error":      err,
+			"questionID": questionID,
+		}).Info("method == GetSubmissions")
+	}(time.Now())
+	return mw.next.GetSubmissions(ctx, userID, questionID)
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-kit/kit/metrics"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func InstrumentingTagsMiddleware(requestCount metrics.Counter, requestLatency metrics.Histogram) model.TagsMiddleware {
+	return func(next model.Tags) model.Tags {
+		return instrumentingTagsMiddleware{
+			requestCount,
+			requestLatency,
+			next,
+		}
+	}
+}
+
+type instrumentingTagsMiddleware struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           model.Tags
+}
+
+func (im instrumentingTagsMiddleware) GetTags(ctx context.Context, prefix string, limit int) (tags []dto.Tag, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "getTags", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	tags, err = im.next.GetTags(ctx, prefix, limit)
+	return
+}
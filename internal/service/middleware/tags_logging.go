@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+func LoggingTagsMiddleware(logger *logrus.Logger) model.TagsMiddleware {
+	return func(next model.Tags) model.Tags {
+		return &loggingTagsMiddleware{
+			next:   next,
+			logger: logger,
+		}
+	}
+}
+
+type loggingTagsMiddleware struct {
+	next   model.Tags
+	logger *logrus.Logger
+}
+
+func (mw loggingTagsMiddleware) GetTags(ctx context.Context, prefix string, limit int) (tags []dto.Tag, err error) {
+	defer func(begin time.Time) {
+		mw.logger.WithFields(logrus.Fields{
+			"took":   time.Since(begin).Milliseconds(),
+			"error":  err,
+			"prefix": prefix,
+		}).Info("method == GetTags")
+	}(time.Now())
+	return mw.next.GetTags(ctx, prefix, limit)
+}
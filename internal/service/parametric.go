@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/pkg/expr"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	parametricParametersMax  = 10
+	parametricDistractorsMax = 9
+	parametricStepsMax       = 1_000_000 // values of one parameter
+	parametricPrecisionMax   = 10
+
+	// parametricValidationSeeds is a number of random renderings checked on save
+	parametricValidationSeeds = 50
+
+	parametricEpsilon = 1e-9
+)
+
+var parametricPlaceholderRegexp = regexp.MustCompile(`\{([\p{L}_][\p{L}\p{N}_]*)\}`)
+
+// parametricQuestion is a parsed definition of a parametric question
+type parametricQuestion struct {
+	definition  dto.ParametricDefinition
+	answer      *expr.Expression
+	distractors []*expr.Expression
+}
+
+// parseParametricDefinition reads and checks definition from the question answer, expressions may use
+// declared parameters only
+func parseParametricDefinition(answer map[string]interface{}) (parametricQuestion, error) {
+	var pq parametricQuestion
+
+	data, err := json.Marshal(answer)
+	if err != nil {
+		return pq, dto.ErrInvalidParametricAnswer
+	}
+	if err = json.Unmarshal(data, &pq.definition); err != nil {
+		return pq, fmt.Errorf("%w: %v", dto.ErrInvalidParametricAnswer, err)
+	}
+	definition := &pq.definition
+
+	if len(definition.Parameters) == 0 || len(definition.Parameters) > parametricParametersMax {
+		return pq, fmt.Errorf("%w: from 1 to %d parameters are allowed", dto.ErrInvalidParametricAnswer, parametricParametersMax)
+	}
+
+	declared := make(map[string]struct{}, len(definition.Parameters))
+	for i, parameter := range definition.Parameters {
+		if !expr.IsIdentifier(parameter.Name) {
+			return pq, fmt.Errorf("%w: invalid parameter name %q", dto.ErrInvalidParametricAnswer, parameter.Name)
+		}
+		if _, ok := declared[parameter.Name]; ok {
+			return pq, fmt.Errorf("%w: parameter %q is declared twice", dto.ErrInvalidParametricAnswer, parameter.Name)
+		}
+		declared[parameter.Name] = struct{}{}
+
+		if parameter.Step == 0 {
+			definition.Parameters[i].Step = 1
+			parameter.Step = 1
+		}
+		if parameter.Step < 0 || parameter.Min > parameter.Max ||
+			(parameter.Max-parameter.Min)/parameter.Step > parametricStepsMax {
+			return pq, fmt.Errorf("%w: invalid range of parameter %q", dto.ErrInvalidParametricAnswer, parameter.Name)
+		}
+	}
+
+	if definition.Precision < 0 || definition.Precision > parametricPrecisionMax {
+		return pq, fmt.Errorf("%w: precision must be from 0 to %d", dto.ErrInvalidParametricAnswer, parametricPrecisionMax)
+	}
+	if definition.Tolerance < 0 {
+		return pq, fmt.Errorf("%w: tolerance must not be negative", dto.ErrInvalidParametricAnswer)
+	}
+	if len(definition.Distractors) > parametricDistractorsMax {
+		return pq, fmt.Errorf("%w: at most %d distractors are allowed", dto.ErrInvalidParametricAnswer, parametricDistractorsMax)
+	}
+
+	parse := func(source string) (*expr.Expression, error) {
+		e, err := expr.Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", dto.ErrInvalidParametricAnswer, source, err)
+		}
+		for _, name := range e.Variables() {
+			if _, ok := declared[name]; !ok {
+				return nil, fmt.Errorf("%w: %q: parameter %q is not declared", dto.ErrInvalidParametricAnswer, source, name)
+			}
+		}
+		return e, nil
+	}
+
+	if pq.answer, err = parse(definition.Answer); err != nil {
+		return pq, err
+	}
+	for _, distractor := range definition.Distractors {
+		e, err := parse(distractor)
+		if err != nil {
+			return pq, err
+		}
+		pq.distractors = append(pq.distractors, e)
+	}
+
+	return pq, nil
+}
+
+// validateParametricAnswer checks the definition and evaluates it on parameter bounds and on random values,
+// so learners never get a question which can not be rendered (e.g. division by zero)
+func validateParametricAnswer(answer map[string]interface{}) error {
+	pq, err := parseParametricDefinition(answer)
+	if err != nil {
+		return err
+	}
+
+	lower := make(map[string]float64, len(pq.definition.Parameters))
+	upper := make(map[string]float64, len(pq.definition.Parameters))
+	for _, parameter := range pq.definition.Parameters {
+		lower[parameter.Name] = parameter.Min
+		upper[parameter.Name] = pq.value(parameter, pq.steps(parameter))
+	}
+
+	samples := []map[string]float64{lower, upper}
+	for seed := int64(0); seed < parametricValidationSeeds; seed++ {
+		samples = append(samples, pq.generate(rand.New(rand.NewPCG(uint64(seed), 0))))
+	}
+
+	for _, values := range samples {
+		if _, err = pq.evaluate(values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// steps returns the number of steps of the parameter range
+func (pq parametricQuestion) steps(parameter dto.ParametricParameter) int64 {
+	return int64(math.Floor((parameter.Max-parameter.Min)/parameter.Step + parametricEpsilon))
+}
+
+// value returns k-th value of the parameter without floating point noise like 0.30000000000000004
+func (pq parametricQuestion) value(parameter dto.ParametricParameter, k int64) float64 {
+	places := max(decimalPlaces(parameter.Min), decimalPlaces(parameter.Step))
+	return expr.Round(parameter.Min+float64(k)*parameter.Step, places)
+}
+
+func (pq parametricQuestion) generate(rng *rand.Rand) map[string]float64 {
+	values := make(map[string]float64, len(pq.definition.Parameters))
+	for _, parameter := range pq.definition.Parameters {
+		values[parameter.Name] = pq.value(parameter, rng.Int64N(pq.steps(parameter)+1))
+	}
+	return values
+}
+
+// evaluate returns rounded answer followed by rounded distractors
+func (pq parametricQuestion) evaluate(values map[string]float64) ([]float64, error) {
+	results := make([]float64, 0, 1+len(pq.distractors))
+	for _, e := range append([]*expr.Expression{pq.answer}, pq.distractors...) {
+		v, err := e.Eval(values)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", dto.ErrInvalidParametricAnswer, e.String(), err)
+		}
+		results = append(results, expr.Round(v, pq.definition.Precision))
+	}
+	return results, nil
+}
+
+// render fills the question with values generated from the seed, the same seed always gives the same question
+func (pq parametricQuestion) render(question dto.Question, seed int64) (dto.RenderedQuestion, float64, error) {
+	rng := rand.New(rand.NewPCG(uint64(seed), uint64(question.ID)))
+	values := pq.generate(rng)
+
+	results, err := pq.evaluate(values)
+	if err != nil {
+		return dto.RenderedQuestion{}, 0, err
+	}
+	answer := results[0]
+
+	rendered := dto.RenderedQuestion{
+		QuestionID: question.ID,
+		Seed:       seed,
+		Text:       fillPlaceholders(question.Text, values),
+		Code:       fillPlaceholders(question.Code, values),
+		Parameters: values,
+		Answer:     map[string]interface{}{"value": answer},
+	}
+
+	if len(pq.distractors) == 0 {
+		if variants, ok := fillPlaceholdersIn(question.Variants, values).(map[string]interface{}); ok && len(variants) != 0 {
+			rendered.Variants = variants
+		}
+		return rendered, answer, nil
+	}
+
+	// answer and distinct distractors in random (but stable for the seed) order
+	options := []float64{answer}
+	for _, distractor := range results[1:] {
+		duplicate := false
+		for _, option := range options {
+			duplicate = duplicate || math.Abs(option-distractor) < parametricEpsilon
+		}
+		if !duplicate {
+			options = append(options, distractor)
+		}
+	}
+	rng.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+
+	rendered.Variants = make(map[string]interface{}, len(options))
+	for i, option := range options {
+		key := strconv.Itoa(i + 1)
+		rendered.Variants[key] = formatParameter(option)
+		if option == answer {
+			rendered.Answer["variant"] = key
+		}
+	}
+
+	return rendered, answer, nil
+}
+
+// fillPlaceholders replaces {name} of known parameters, other braces (e.g. in code) are kept
+func fillPlaceholders(s string, values map[string]float64) string {
+	return parametricPlaceholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		if v, ok := values[placeholder[1:len(placeholder)-1]]; ok {
+			return formatParameter(v)
+		}
+		return placeholder
+	})
+}
+
+// fillPlaceholdersIn fills placeholders in all strings of decoded json value
+func fillPlaceholdersIn(value interface{}, values map[string]float64) interface{} {
+	switch v := value.(type) {
+	case string:
+		return fillPlaceholders(v, values)
+	case map[string]interface{}:
+		filled := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			filled[key] = fillPlaceholdersIn(item, values)
+		}
+		return filled
+	case []interface{}:
+		filled := make([]interface{}, len(v))
+		for i, item := range v {
+			filled[i] = fillPlaceholdersIn(item, values)
+		}
+		return filled
+	}
+	return value
+}
+
+func formatParameter(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func decimalPlaces(v float64) int {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// RenderQuestion renders parametric question for the seed (usually learner id), answer is shown
+// to the users who can edit the question only, see redactAnswers
+func (s questionsService) RenderQuestion(ctx context.Context, questionID int64, seed int64) (dto.RenderedQuestion, error) {
+	question, pq, err := s.parametricQuestion(ctx, questionID)
+	if err != nil {
+		return dto.RenderedQuestion{}, err
+	}
+
+	rendered, _, err := pq.render(question, seed)
+	if err != nil {
+		return rendered, err
+	}
+
+	questions := []dto.Question{question}
+	if err = redactAnswers(ctx, s.subjectsStorage, questions); err != nil {
+		return dto.RenderedQuestion{}, err
+	}
+	if questions[0].Answer == nil {
+		rendered.Answer = nil
+	}
+
+	return rendered, nil
+}
+
+// CheckParametricAnswer compares entered value with the answer of the question rendered for the seed.
+// Without tolerance the value is compared after rounding to the answer precision. The answer itself
+// is not returned, otherwise any guess would reveal it.
+func (s questionsService) CheckParametricAnswer(ctx context.Context, questionID int64, seed int64, value float64) (dto.ParametricCheckResult, error) {
+	var result dto.ParametricCheckResult
+
+	question, pq, err := s.parametricQuestion(ctx, questionID)
+	if err != nil {
+		return result, err
+	}
+
+	_, expected, err := pq.render(question, seed)
+	if err != nil {
+		return result, err
+	}
+
+	if pq.definition.Tolerance > 0 {
+		result.Correct = math.Abs(value-expected) <= pq.definition.Tolerance+parametricEpsilon
+	} else {
+		result.Correct = math.Abs(expr.Round(value, pq.definition.Precision)-expected) < parametricEpsilon
+	}
+
+	return result, nil
+}
+
+func (s questionsService) parametricQuestion(ctx context.Context, questionID int64) (dto.Question, parametricQuestion, error) {
+	question, err := s.storage.GetQuestionByID(ctx, questionID)
+	if err != nil {
+		return question, parametricQuestion{}, err
+	}
+
+	if question.Type.QuestionTypeName != dto.QuestionTypeNameParametric {
+		return question, parametricQuestion{}, dto.ErrNotParametricQuestion
+	}
+
+	pq, err := parseParametricDefinition(question.Answer)
+	return question, pq, err
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"quiz_backend_core/internal/dto"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	orderingItemsMin = 2
+	orderingItemsMax = 50
+	clozeBlanksMax   = 50
+
+	numericEpsilon = 1e-9
+)
+
+var clozeBlankRegexp = regexp.MustCompile(`\{\{\s*([\p{L}\p{N}_-]+)\s*\}\}`)
+
+// remarshal converts decoded json object (variants, answer, response) to the typed value
+func remarshal(src interface{}, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// *** ordering ********************************************************************************************************
+
+func validateOrderingQuestion(question dto.InputQuestion) error {
+	var variants dto.OrderingVariants
+	var answer dto.OrderingAnswer
+	if remarshal(question.Variants, &variants) != nil || remarshal(question.Answer, &answer) != nil {
+		return dto.ErrInvalidQuestionAnswer
+	}
+
+	if len(variants.Items) < orderingItemsMin || len(variants.Items) > orderingItemsMax {
+		return fmt.Errorf("%w: from %d to %d items are allowed", dto.ErrInvalidQuestionAnswer, orderingItemsMin, orderingItemsMax)
+	}
+
+	ids := make([]string, 0, len(variants.Items))
+	for _, item := range variants.Items {
+		if item.ID == "" || slices.Contains(ids, item.ID) {
+			return fmt.Errorf("%w: item ids must be unique and not empty", dto.ErrInvalidQuestionAnswer)
+		}
+		ids = append(ids, item.ID)
+	}
+
+	if !isPermutation(answer.Order, ids) {
+		return fmt.Errorf("%w: order must contain every item once", dto.ErrInvalidQuestionAnswer)
+	}
+
+	return nil
+}
+
+// gradeOrdering gives partial credit by normalized Kendall tau distance: share of item pairs
+// which are in the right relative order
+func gradeOrdering(answer map[string]interface{}, response map[string]interface{}) (float64, error) {
+	var expected, actual dto.OrderingAnswer
+	if err := remarshal(answer, &expected); err != nil {
+		return 0, dto.ErrInvalidQuestionAnswer
+	}
+	if err := remarshal(response, &actual); err != nil || !isPermutation(actual.Order, expected.Order) {
+		return 0, dto.ErrInvalidResponse
+	}
+
+	n := len(expected.Order)
+	if n < 2 {
+		return 1, nil
+	}
+
+	position := make(map[string]int, n)
+	for i, id := range actual.Order {
+		position[id] = i
+	}
+
+	discordant := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if position[expected.Order[i]] > position[expected.Order[j]] {
+				discordant++
+			}
+		}
+	}
+
+	return 1 - float64(discordant)/float64(n*(n-1)/2), nil
+}
+
+func isPermutation(order []string, ids []string) bool {
+	if len(order) != len(ids) {
+		return false
+	}
+	seen := make(map[string]struct{}, len(order))
+	for _, id := range order {
+		if _, ok := seen[id]; ok || !slices.Contains(ids, id) {
+			return false
+		}
+		seen[id] = struct{}{}
+	}
+	return true
+}
+
+// *** numeric *********************************************************************************************************
+
+func validateNumericQuestion(question dto.InputQuestion) error {
+	var answer dto.NumericAnswer
+	if err := remarshal(question.Answer, &answer); err != nil {
+		return fmt.Errorf("%w: %v", dto.ErrInvalidQuestionAnswer, err)
+	}
+
+	if _, ok := question.Answer["value"]; !ok {
+		return fmt.Errorf("%w: value is required", dto.ErrInvalidQuestionAnswer)
+	}
+
+	if answer.AbsoluteTolerance < 0 || answer.RelativeTolerance < 0 || answer.RelativeTolerance >= 1 {
+		return fmt.Errorf("%w: tolerance must be non-negative, relative tolerance less than 1", dto.ErrInvalidQuestionAnswer)
+	}
+
+	if len(answer.Units) != 0 && answer.Unit == "" {
+		return fmt.Errorf("%w: alternative units require unit", dto.ErrInvalidQuestionAnswer)
+	}
+	for unit, factor := range answer.Units {
+		if strings.TrimSpace(unit) == "" || factor <= 0 || math.IsInf(factor, 0) {
+			return fmt.Errorf("%w: invalid unit %q", dto.ErrInvalidQuestionAnswer, unit)
+		}
+	}
+
+	return nil
+}
+
+// gradeNumeric converts response to the answer unit and compares it with the largest of tolerances
+func gradeNumeric(answer map[string]interface{}, response map[string]interface{}) (float64, error) {
+	var expected dto.NumericAnswer
+	var actual dto.NumericResponse
+	if err := remarshal(answer, &expected); err != nil {
+		return 0, dto.ErrInvalidQuestionAnswer
+	}
+	if _, ok := response["value"]; !ok || remarshal(response, &actual) != nil {
+		return 0, dto.ErrInvalidResponse
+	}
+
+	factor := 1.0
+	unit := strings.TrimSpace(actual.Unit)
+	if unit != "" && unit != expected.Unit {
+		var ok bool
+		if factor, ok = expected.Units[unit]; !ok {
+			return 0, nil
+		}
+	}
+
+	tolerance := math.Max(expected.AbsoluteTolerance, expected.RelativeTolerance*math.Abs(expected.Value))
+	if math.Abs(actual.Value*factor-expected.Value) <= tolerance+numericEpsilon {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// *** cloze ***********************************************************************************************************
+
+// clozeBlanks returns names of blanks marked in text as {{name}}
+func clozeBlanks(text string) []string {
+	var names []string
+	for _, match := range clozeBlankRegexp.FindAllStringSubmatch(text, -1) {
+		if !slices.Contains(names, match[1]) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+func validateClozeQuestion(question dto.InputQuestion) error {
+	var answer dto.ClozeAnswer
+	var variants dto.ClozeVariants
+	if remarshal(question.Answer, &answer) != nil || remarshal(question.Variants, &variants) != nil {
+		return dto.ErrInvalidQuestionAnswer
+	}
+
+	names := clozeBlanks(question.Text)
+	if len(names) == 0 || len(names) > clozeBlanksMax {
+		return fmt.Errorf("%w: text must contain from 1 to %d blanks like {{1}}", dto.ErrInvalidQuestionAnswer, clozeBlanksMax)
+	}
+	if len(answer.Blanks) != len(names) {
+		return fmt.Errorf("%w: every blank of text must have an answer", dto.ErrInvalidQuestionAnswer)
+	}
+
+	for _, name := range names {
+		blank, ok := answer.Blanks[name]
+		if !ok || len(blank.Accepted) == 0 {
+			return fmt.Errorf("%w: blank %q has no accepted answers", dto.ErrInvalidQuestionAnswer, name)
+		}
+
+		options, hasOptions := variants.Options[name]
+		for _, accepted := range blank.Accepted {
+			if strings.TrimSpace(accepted) == "" {
+				return fmt.Errorf("%w: blank %q has empty accepted answer", dto.ErrInvalidQuestionAnswer, name)
+			}
+			if hasOptions && !slices.Contains(options, accepted) {
+				return fmt.Errorf("%w: accepted answer of blank %q is not among its options", dto.ErrInvalidQuestionAnswer, name)
+			}
+		}
+	}
+
+	for name := range variants.Options {
+		if _, ok := answer.Blanks[name]; !ok {
+			return fmt.Errorf("%w: options of unknown blank %q", dto.ErrInvalidQuestionAnswer, name)
+		}
+	}
+
+	return nil
+}
+
+// gradeCloze gives a share of correctly filled blanks, spaces are collapsed before comparison
+func gradeCloze(answer map[string]interface{}, response map[string]interface{}) (float64, error) {
+	var expected dto.ClozeAnswer
+	var actual dto.ClozeResponse
+	if err := remarshal(answer, &expected); err != nil || len(expected.Blanks) == 0 {
+		return 0, dto.ErrInvalidQuestionAnswer
+	}
+	if err := remarshal(response, &actual); err != nil {
+		return 0, dto.ErrInvalidResponse
+	}
+
+	correct := 0
+	for name, blank := range expected.Blanks {
+		value := strings.Join(strings.Fields(actual.Blanks[name]), " ")
+		for _, accepted := range blank.Accepted {
+			accepted = strings.Join(strings.Fields(accepted), " ")
+			if value == accepted || (!blank.CaseSensitive && strings.EqualFold(value, accepted)) {
+				correct++
+				break
+			}
+		}
+	}
+
+	return float64(correct) / float64(len(expected.Blanks)), nil
+}
+
+// *** grading *********************************************************************************************************
+
+// GradeAnswer grades the learner response to ordering, numeric or cloze question
+func (s questionsService) GradeAnswer(ctx context.Context, questionID int64, response map[string]interface{}) (dto.GradeResult, error) {
+	var result dto.GradeResult
+
+	question, err := s.storage.GetQuestionByID(ctx, questionID)
+	if err != nil {
+		return result, err
+	}
+
+	switch question.Type.QuestionTypeName {
+	case dto.QuestionTypeNameOrdering:
+		result.Score, err = gradeOrdering(question.Answer, response)
+	case dto.QuestionTypeNameNumeric:
+		result.Score, err = gradeNumeric(question.Answer, response)
+	case dto.QuestionTypeNameCloze:
+		result.Score, err = gradeCloze(question.Answer, response)
+	default:
+		return result, dto.ErrQuestionNotGradable
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result.Correct = result.Score >= 1-numericEpsilon
+	return result, nil
+}
@@ -2,26 +2,37 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"github.com/sirupsen/logrus"
-	"quiz_backend_core/internal/constants"
 	"quiz_backend_core/internal/dto"
 	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
 	"quiz_backend_core/internal/service/middleware"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type questionsService struct {
-	storage  model.QuestionsStorage
-	logger   *logrus.Logger
-	notifier model.Notifier
+	storage            model.QuestionsStorage
+	subjectsStorage    model.SubjectsStorage
+	attachmentsStorage model.AttachmentsStorage
+	blobs              model.BlobStore
+	runner             model.CodeRunner
+	logger             *logrus.Logger
+	duplicateThreshold float64
 }
 
 func NewQuestionsService(deps Deps) model.Questions {
 	var svc model.Questions = questionsService{
-		storage:  deps.Storages.Questions,
-		logger:   deps.Logger,
-		notifier: deps.Notifier,
+		storage:            deps.Storages.Questions,
+		subjectsStorage:    deps.Storages.Subjects,
+		attachmentsStorage: deps.Storages.Attachments,
+		blobs:              deps.BlobStore,
+		runner:             deps.CodeRunner,
+		logger:             deps.Logger,
+		duplicateThreshold: deps.Config.DuplicateSimilarityThreshold,
 	}
 
 	// middleware services
@@ -31,8 +42,142 @@ func NewQuestionsService(deps Deps) model.Questions {
 	return svc
 }
 
-func (s questionsService) GetQuestions(ctx context.Context, creatorUserID, subjectId, statusID int64) ([]dto.Question, error) {
-	return s.storage.GetQuestions(ctx, creatorUserID, subjectId, statusID)
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 100
+
+	similarQuestionsLimit = 5
+)
+
+func (s questionsService) GetQuestions(ctx context.Context, filter dto.QuestionFilter) ([]dto.Question, error) {
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return nil, err
+	}
+	filter.Tags = tags
+
+	for _, level := range filter.BloomLevels {
+		if !slices.Contains(dto.BloomLevels, level) {
+			return nil, dto.ErrInvalidBloomLevel
+		}
+	}
+
+	questions, err := s.storage.GetQuestions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return questions, redactAnswers(ctx, s.subjectsStorage, questions)
+}
+
+func (s questionsService) SearchQuestions(ctx context.Context, search string, filter dto.QuestionFilter, limit, offset int) ([]dto.QuestionSearchResult, error) {
+	search = strings.TrimSpace(search)
+	if search == "" {
+		return nil, dto.ErrEmptySearchQuery
+	}
+
+	if limit <= 0 {
+		limit = searchDefaultLimit
+	}
+	if limit > searchMaxLimit {
+		limit = searchMaxLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return nil, err
+	}
+	filter.Tags = tags
+
+	results, err := s.storage.SearchQuestions(ctx, search, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := make([]dto.Question, len(results))
+	for i, result := range results {
+		questions[i] = result.Question
+	}
+	if err = redactAnswers(ctx, s.subjectsStorage, questions); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Question = questions[i]
+	}
+
+	return results, nil
+}
+
+func (s questionsService) GetQuestionByID(ctx context.Context, questionID int64) (dto.Question, error) {
+	question, err := s.storage.GetQuestionByID(ctx, questionID)
+	if err != nil {
+		return question, err
+	}
+
+	questions := []dto.Question{question}
+	if err = redactAnswers(ctx, s.subjectsStorage, questions); err != nil {
+		return dto.Question{}, err
+	}
+
+	return questions[0], nil
+}
+
+// canReadQuestion says whether the user can see the question and its attachments: approved questions are
+// open to everyone, drafts, declined questions and questions in the trash to their creators and users who
+// can moderate them only
+func canReadQuestion(ctx context.Context, subjects model.SubjectsStorage, principal policy.Principal, ownership dto.Ownership) (bool, error) {
+	if !ownership.Deleted && ownership.StatusName == dto.QuestionStatusNameApproved {
+		return true, nil
+	}
+
+	if principal.UserID > 0 && ownership.CreatorUserID == principal.UserID {
+		return true, nil
+	}
+
+	return canMaintainSubject(ctx, subjects, principal.UserID, principal.Role, ownership.SubjectID)
+}
+
+// hiddenAnswerTypes are question types graded on the server, their answers (hidden tests of programs,
+// formulas and parameter ranges of parametric questions) must not reach learners
+var hiddenAnswerTypes = []string{dto.QuestionTypeNameProgram, dto.QuestionTypeNameParametric}
+
+// redactAnswers removes hidden answers from questions which the user from context can not edit, answers
+// are kept for admins, moderators, creators of the questions and maintainers of their subjects
+func redactAnswers(ctx context.Context, subjects model.SubjectsStorage, questions []dto.Question) error {
+	principal, _ := policy.FromContext(ctx)
+	if principal.IsStaff() {
+		return nil
+	}
+
+	maintained := make(map[int64]bool)
+	for i := range questions {
+		question := &questions[i]
+		if !slices.Contains(hiddenAnswerTypes, question.Type.QuestionTypeName) {
+			continue
+		}
+		if principal.UserID > 0 && int64(question.Creator.ID) == principal.UserID {
+			continue
+		}
+
+		maintainer, ok := maintained[question.SubjectID]
+		if !ok && principal.UserID > 0 {
+			var err error
+			maintainer, err = canMaintainSubject(ctx, subjects, principal.UserID, principal.Role, question.SubjectID)
+			if err != nil {
+				return err
+			}
+			maintained[question.SubjectID] = maintainer
+		}
+
+		if !maintainer {
+			question.Answer = nil
+		}
+	}
+
+	return nil
 }
 
 func (s questionsService) GetQuestionTypes(ctx context.Context) ([]dto.QuestionType, error) {
@@ -43,14 +188,71 @@ func (s questionsService) GetQuestionStatuses(ctx context.Context) ([]dto.Questi
 	return s.storage.GetQuestionStatuses(ctx)
 }
 
-func (s questionsService) AddQuestion(ctx context.Context, question dto.InputQuestion) (int64, error) {
-	userID, ok1 := ctx.Value(constants.ContextVariablesUserID).(int64)
-	userRole, ok2 := ctx.Value(constants.ContextVariablesUserRole).(dto.Role) // TODO ALWAYS ok (add check!)
-	if !ok1 || !ok2 {
-		return -1, errors.New("bad user id or role") //TODO
+func (s questionsService) AddQuestion(ctx context.Context, question dto.InputQuestion, force bool) (int64, []dto.SimilarQuestion, error) {
+	principal, ok := policy.FromContext(ctx)
+	if !ok {
+		return -1, nil, fmt.Errorf("%w: unknown user", dto.ErrForbidden)
+	}
+	userID, userRole := principal.UserID, principal.Role
+
+	tags, err := normalizeTags(question.Tags)
+	if err != nil {
+		return -1, nil, err
+	}
+	question.Tags = tags
+
+	if question, err = validateQuestionMetadata(question); err != nil {
+		return -1, nil, err
+	}
+
+	if question, err = validateQuestionCode(question); err != nil {
+		return -1, nil, err
+	}
+
+	if err = s.validateQuestionAnswer(ctx, question); err != nil {
+		return -1, nil, err
+	}
+
+	question.AttachmentIDs = referencedAttachmentIDs(question)
+
+	// look for near-duplicates in the same subject tree
+	var similar []dto.SimilarQuestion
+	if strings.TrimSpace(question.Text) != "" {
+		similar, err = s.storage.FindSimilarQuestions(ctx, question.SubjectID, question.Text, s.duplicateThreshold, similarQuestionsLimit)
+		if err != nil {
+			return -1, nil, err
+		}
+
+		if len(similar) != 0 && !force {
+			return -1, similar, &dto.DuplicateQuestionError{Similar: similar}
+		}
+	}
+
+	moderator, err := canMaintainSubject(ctx, s.subjectsStorage, userID, userRole, question.SubjectID)
+	if err != nil {
+		return -1, similar, err
+	}
+	question = applyModerationRules(question, userID, moderator)
+
+	question.CreatorUserID = userID
+
+	if !moderator {
+		// notifications to users about new question are sent with the question
+		question.Events = moderatorsEvents("AddQuestion")
+	}
+
+	id, err := s.storage.AddQuestion(ctx, question)
+	if err != nil {
+		return -1, similar, err
 	}
 
-	if userRole == dto.RoleAdmin || userRole == dto.RoleModerator {
+	return id, similar, nil
+}
+
+// applyModerationRules sets status of created or changed question: questions of users who can moderate
+// the subject (admins, moderators and subject maintainers) are approved at once, the rest wait for moderation
+func applyModerationRules(question dto.InputQuestion, userID int64, moderator bool) dto.InputQuestion {
+	if moderator {
 		question.StatusName = dto.QuestionStatusNameApproved
 		question.ModeratorUserID = userID
 		question.ModeratedAt = time.Now().Format("2006-01-02 15:04")
@@ -59,49 +261,147 @@ func (s questionsService) AddQuestion(ctx context.Context, question dto.InputQue
 		question.ModeratorUserID = -1
 		question.ModeratedAt = "" //TODO
 	}
+	return question
+}
 
-	question.CreatorUserID = userID
+// moderatorsEvents are notifications to admins and moderators about question waiting for moderation
+func moderatorsEvents(event string) []dto.OutboxEvent {
+	return []dto.OutboxEvent{
+		{Room: "role:" + dto.RoleAdmin, Event: event},
+		{Room: "role:" + dto.RoleModerator, Event: event},
+	}
+}
 
-	id, err := s.storage.AddQuestion(ctx, question)
-	if err != nil {
-		return -1, err
+func (s questionsService) UpdateQuestionByID(ctx context.Context, questionID int64, question dto.InputQuestion) error {
+	//TODO check if not changed
+
+	//s.GetQuestions()
+
+	principal, ok := policy.FromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: unknown user", dto.ErrForbidden)
 	}
+	userID, userRole := principal.UserID, principal.Role
 
-	if userRole != dto.RoleAdmin && userRole != dto.RoleModerator {
-		// send notifications to users about new question
-		if err = s.notifier.Notify(ctx, "role:"+dto.RoleAdmin, "AddQuestion", ""); err != nil {
-			return -1, err //TODO wrap Error?
+	if question.Tags != nil {
+		tags, err := normalizeTags(question.Tags)
+		if err != nil {
+			return err
 		}
+		question.Tags = tags
+	}
+
+	question, err := validateQuestionMetadata(question)
+	if err != nil {
+		return err
+	}
+
+	if question, err = validateQuestionCode(question); err != nil {
+		return err
+	}
+
+	if err = s.validateQuestionAnswer(ctx, question); err != nil {
+		return err
+	}
+
+	question.AttachmentIDs = referencedAttachmentIDs(question)
+
+	moderator, err := canMaintainSubject(ctx, s.subjectsStorage, userID, userRole, question.SubjectID)
+	if err != nil {
+		return err
+	}
+	question = applyModerationRules(question, userID, moderator)
+
+	previousAttachments, err := s.attachmentsStorage.GetAttachmentsByQuestionID(ctx, questionID)
+	if err != nil {
+		return err
+	}
 
-		if err = s.notifier.Notify(ctx, "role:"+dto.RoleModerator, "AddQuestion", ""); err != nil {
-			return -1, err //TODO wrap Error?
+	if err = s.storage.UpdateQuestionByID(ctx, questionID, question); err != nil {
+		return err
+	}
+
+	// attachments which are not used anymore
+	var unlinked []dto.Attachment
+	for _, attachment := range previousAttachments {
+		if !slices.Contains(question.AttachmentIDs, attachment.ID) {
+			unlinked = append(unlinked, attachment)
 		}
 	}
+	removeAttachments(ctx, s.attachmentsStorage, s.blobs, s.logger, unlinked)
 
-	return id, nil
+	return nil
 }
 
-func (s questionsService) UpdateQuestionByID(ctx context.Context, questionID int64, question dto.InputQuestion) error {
-	//TODO check if not changed
+const (
+	learningObjectivesMax      = 10
+	learningObjectiveMaxLength = 500
+)
 
-	//s.GetQuestions()
+// validateQuestionMetadata checks difficulty, estimated time, bloom level and learning objectives,
+// objectives are trimmed
+func validateQuestionMetadata(question dto.InputQuestion) (dto.InputQuestion, error) {
+	if question.Difficulty != 0 &&
+		(question.Difficulty < dto.QuestionDifficultyMin || question.Difficulty > dto.QuestionDifficultyMax) {
+		return question, dto.NewFieldError("difficulty", dto.ErrInvalidDifficulty)
+	}
 
-	userID := ctx.Value(constants.ContextVariablesUserID).(int64)
-	userRole := ctx.Value(constants.ContextVariablesUserRole).(dto.Role)
+	if question.EstimatedTime < 0 {
+		return question, dto.NewFieldError("estimated_time", dto.ErrInvalidEstimatedTime)
+	}
 
-	if userRole == dto.RoleAdmin || userRole == dto.RoleModerator {
-		question.StatusName = dto.QuestionStatusNameApproved
-		question.ModeratorUserID = userID
-		question.ModeratedAt = time.Now().Format("2006-01-02 15:04")
-	} else {
-		question.StatusName = dto.QuestionStatusNameCreated
-		question.ModeratorUserID = -1
-		question.ModeratedAt = "" //TODO
+	if question.BloomLevel != "" && !slices.Contains(dto.BloomLevels, question.BloomLevel) {
+		return question, dto.NewFieldError("bloom_level", dto.ErrInvalidBloomLevel)
+	}
+
+	if len(question.LearningObjectives) > learningObjectivesMax {
+		return question, dto.NewFieldError("learning_objectives", dto.ErrInvalidLearningObjective)
+	}
+
+	objectives := make([]string, 0, len(question.LearningObjectives))
+	for _, objective := range question.LearningObjectives {
+		objective = strings.TrimSpace(objective)
+		if objective == "" || utf8.RuneCountInString(objective) > learningObjectiveMaxLength {
+			return question, dto.NewFieldError("learning_objectives", dto.ErrInvalidLearningObjective)
+		}
+		objectives = append(objectives, objective)
+	}
+	question.LearningObjectives = objectives
+
+	return question, nil
+}
+
+// validateQuestionAnswer checks answer format of question types which are graded automatically
+func (s questionsService) validateQuestionAnswer(ctx context.Context, question dto.InputQuestion) error {
+	types, err := s.storage.GetQuestionTypes(ctx)
+	if err != nil {
+		return err
 	}
 
-	return s.storage.UpdateQuestionByID(ctx, questionID, question)
+	for _, questionType := range types {
+		if int64(questionType.ID) != question.TypeID {
+			continue
+		}
+
+		switch questionType.QuestionTypeName {
+		case dto.QuestionTypeNameProgram:
+			return validateProgramAnswer(question.Answer, s.runner)
+		case dto.QuestionTypeNameParametric:
+			return validateParametricAnswer(question.Answer)
+		case dto.QuestionTypeNameOrdering:
+			return validateOrderingQuestion(question)
+		case dto.QuestionTypeNameNumeric:
+			return validateNumericQuestion(question)
+		case dto.QuestionTypeNameCloze:
+			return validateClozeQuestion(question)
+		}
+	}
+
+	return nil
 }
 
+// ModerateQuestion approves or declines the question, the policy allows it to admins, moderators
+// and maintainers of its subject
 func (s questionsService) ModerateQuestion(ctx context.Context, ID int64, approve bool, comment string) error {
 	//TODO create notification with comment
 	if approve {
@@ -111,6 +411,16 @@ func (s questionsService) ModerateQuestion(ctx context.Context, ID int64, approv
 	}
 }
 
+// DeleteQuestion moves the question to the trash, attachments are kept until the question is purged
 func (s questionsService) DeleteQuestion(ctx context.Context, ID int64) error {
 	return s.storage.DeleteQuestion(ctx, ID)
 }
+
+func (s questionsService) GetDeletedQuestions(ctx context.Context, filter dto.QuestionFilter) ([]dto.Question, error) {
+	filter.Deleted = true
+	return s.GetQuestions(ctx, filter)
+}
+
+func (s questionsService) RestoreQuestion(ctx context.Context, ID int64) error {
+	return s.storage.RestoreQuestion(ctx, ID)
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+)
+
+// questionsStorage serves a single question and keeps added ones, other methods are not used by the tests
+type questionsStorage struct {
+	model.QuestionsStorage
+	question dto.Question
+	added    *[]dto.InputQuestion
+}
+
+var questionTypes = []dto.QuestionType{
+	{ID: 1, QuestionTypeName: dto.QuestionTypeNameTest},
+	{ID: 4, QuestionTypeName: dto.QuestionTypeNameProgram},
+}
+
+func (s questionsStorage) GetQuestionTypes(context.Context) ([]dto.QuestionType, error) {
+	return questionTypes, nil
+}
+
+func (s questionsStorage) AddQuestion(_ context.Context, question dto.InputQuestion) (int64, error) {
+	*s.added = append(*s.added, question)
+	return int64(len(*s.added)) + 100, nil
+}
+
+func (s questionsStorage) GetQuestionByID(_ context.Context, questionID int64) (dto.Question, error) {
+	if questionID != s.question.ID {
+		return dto.Question{}, dto.ErrQuestionNotFound
+	}
+	return s.question, nil
+}
+
+func (s questionsStorage) GetQuestionOwnership(_ context.Context, questionID int64) (dto.Ownership, error) {
+	if questionID != s.question.ID {
+		return dto.Ownership{}, dto.ErrQuestionNotFound
+	}
+	return dto.Ownership{
+		CreatorUserID: int64(s.question.Creator.ID),
+		SubjectID:     s.question.SubjectID,
+		StatusName:    s.question.Status,
+	}, nil
+}
+
+func (s questionsStorage) PurgeQuestions(context.Context, time.Duration) ([]dto.Attachment, error) {
+	return nil, nil
+}
+
+// attachmentsStorage serves attachments of the map, purged ones are unlinked uploads
+type attachmentsStorage struct {
+	model.AttachmentsStorage
+	attachments map[int64]dto.Attachment
+	unlinked    []dto.Attachment
+}
+
+func (s attachmentsStorage) GetAttachmentByID(_ context.Context, attachmentID int64) (dto.Attachment, error) {
+	attachment, ok := s.attachments[attachmentID]
+	if !ok {
+		return dto.Attachment{}, dto.ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+func (s attachmentsStorage) GetAttachmentsByQuestionID(_ context.Context, questionID int64) ([]dto.Attachment, error) {
+	var attachments []dto.Attachment
+	for _, attachment := range s.attachments {
+		if attachment.QuestionID == questionID {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+func (s attachmentsStorage) PurgeUnlinkedAttachments(context.Context, time.Duration) ([]dto.Attachment, error) {
+	return s.unlinked, nil
+}
+
+func (s attachmentsStorage) DeleteAttachment(context.Context, int64) error {
+	return dto.ErrAttachmentNotFound // rows are removed by the purge
+}
+
+// subjectsStorage knows maintainers of subjects only
+type subjectsStorage struct {
+	model.SubjectsStorage
+	maintainers map[int64][]int64 // subject id -> user ids
+}
+
+func (s subjectsStorage) IsSubjectMaintainer(_ context.Context, subjectID int64, userID int64) (bool, error) {
+	for _, id := range s.maintainers[subjectID] {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestGetQuestionByIDRedactsHiddenAnswers(t *testing.T) {
+	const (
+		creatorID    = 10
+		maintainerID = 20
+		learnerID    = 30
+	)
+
+	answers := map[string]map[string]interface{}{
+		dto.QuestionTypeNameProgram: {
+			"tests": []interface{}{map[string]interface{}{"input": "1 2", "expected_output": "3"}},
+		},
+		dto.QuestionTypeNameParametric: {
+			"parameters": []interface{}{map[string]interface{}{"name": "a", "min": 1, "max": 9}},
+			"answer":     "a * 2",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		principal  policy.Principal
+		wantAnswer bool
+	}{
+		{"learner", policy.Principal{UserID: learnerID, Role: dto.RoleUser}, false},
+		{"anonymous", policy.Principal{}, false},
+		{"creator", policy.Principal{UserID: creatorID, Role: dto.RoleUser}, true},
+		{"maintainer", policy.Principal{UserID: maintainerID, Role: dto.RoleUser}, true},
+		{"moderator", policy.Principal{UserID: learnerID, Role: dto.RoleModerator}, true},
+	}
+
+	for typeName, answer := range answers {
+		question := dto.Question{
+			ID:        1,
+			Type:      dto.QuestionType{QuestionTypeName: typeName},
+			SubjectID: 5,
+			Creator:   dto.User{ID: creatorID},
+			Answer:    answer,
+		}
+		s := questionsService{
+			storage:         questionsStorage{question: question},
+			subjectsStorage: subjectsStorage{maintainers: map[int64][]int64{5: {maintainerID}}},
+		}
+
+		for _, tt := range tests {
+			t.Run(typeName+" "+tt.name, func(t *testing.T) {
+				ctx := policy.NewContext(context.Background(), tt.principal)
+				got, err := s.GetQuestionByID(ctx, question.ID)
+				if err != nil {
+					t.Fatalf("GetQuestionByID: %v", err)
+				}
+				if shown := got.Answer != nil; shown != tt.wantAnswer {
+					t.Errorf("answer is shown: %v, want %v (%v)", shown, tt.wantAnswer, got.Answer)
+				}
+			})
+		}
+	}
+
+	// answers of other question types are a part of the question
+	test := dto.Question{
+		ID:     1,
+		Type:   dto.QuestionType{QuestionTypeName: dto.QuestionTypeNameTest},
+		Answer: map[string]interface{}{"correct": []interface{}{"1"}},
+	}
+	s := questionsService{storage: questionsStorage{question: test}, subjectsStorage: subjectsStorage{}}
+	ctx := policy.NewContext(context.Background(), policy.Principal{UserID: learnerID, Role: dto.RoleUser})
+	if got, err := s.GetQuestionByID(ctx, test.ID); err != nil || got.Answer == nil {
+		t.Errorf("answer of a test question is hidden: %v, %v", got.Answer, err)
+	}
+}
+
+func TestCloneQuestionChecksSource(t *testing.T) {
+	const (
+		creatorID    = 10
+		maintainerID = 20
+		learnerID    = 30
+	)
+
+	question := func(typeID int, status dto.QuestionStatusName, difficulty int) dto.Question {
+		typeName := dto.QuestionTypeNameTest
+		if typeID == 4 {
+			typeName = dto.QuestionTypeNameProgram
+		}
+		return dto.Question{
+			ID:         1,
+			Text:       "2 + 2 = ?",
+			Type:       dto.QuestionType{ID: typeID, QuestionTypeName: typeName},
+			Status:     status,
+			SubjectID:  5,
+			Creator:    dto.User{ID: creatorID},
+			Answer:     map[string]interface{}{"correct": []interface{}{"4"}},
+			Difficulty: difficulty,
+		}
+	}
+
+	learner := policy.Principal{UserID: learnerID, Role: dto.RoleUser}
+
+	tests := []struct {
+		name      string
+		principal policy.Principal
+		source    dto.Question
+		want      error
+	}{
+		{"approved question", learner, question(1, dto.QuestionStatusNameApproved, 0), nil},
+		{"draft of other", learner, question(1, dto.QuestionStatusNameCreated, 0), dto.ErrForbidden},
+		{"declined question of other", learner, question(1, dto.QuestionStatusNameDeclined, 0), dto.ErrForbidden},
+		{"own draft", policy.Principal{UserID: creatorID, Role: dto.RoleUser}, question(1, dto.QuestionStatusNameCreated, 0), nil},
+		{"draft in maintained subject", policy.Principal{UserID: maintainerID, Role: dto.RoleUser}, question(1, dto.QuestionStatusNameCreated, 0), nil},
+		{"draft by moderator", policy.Principal{UserID: learnerID, Role: dto.RoleModerator}, question(1, dto.QuestionStatusNameCreated, 0), nil},
+		{"approved program with hidden tests", learner, question(4, dto.QuestionStatusNameApproved, 0), dto.ErrForbidden},
+		{"invalid source", learner, question(1, dto.QuestionStatusNameApproved, 9), dto.ErrInvalidDifficulty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var added []dto.InputQuestion
+			s := questionsService{
+				storage:            questionsStorage{question: tt.source, added: &added},
+				subjectsStorage:    subjectsStorage{maintainers: map[int64][]int64{5: {maintainerID}}},
+				attachmentsStorage: attachmentsStorage{},
+			}
+
+			ctx := policy.NewContext(context.Background(), tt.principal)
+			_, err := s.CloneQuestion(ctx, tt.source.ID)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			if tt.want != nil {
+				if len(added) != 0 {
+					t.Errorf("copy is saved: %+v", added)
+				}
+				return
+			}
+			if len(added) != 1 || added[0].CreatorUserID != tt.principal.UserID || added[0].SourceQuestionID != tt.source.ID {
+				t.Errorf("saved copies %+v, want one copy of question %d by user %d", added, tt.source.ID, tt.principal.UserID)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"quiz_backend_core/internal/dto"
+	"slices"
+	"sort"
+)
+
+// assembleQuestions picks random approved questions matching the assembly criteria.
+// Questions without estimated time do not consume the time budget.
+// Picked questions are ordered from easy to hard.
+func (s quizzesService) assembleQuestions(ctx context.Context, assembly dto.QuizAssembly) (dto.Int64Array, error) {
+	if assembly.Count <= 0 {
+		return nil, dto.ErrNotEnoughQuestions
+	}
+
+	for _, level := range assembly.BloomLevels {
+		if !slices.Contains(dto.BloomLevels, level) {
+			return nil, dto.ErrInvalidBloomLevel
+		}
+	}
+
+	tags, err := normalizeTags(assembly.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	statusID, err := s.approvedStatusID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := dto.NewQuestionFilter()
+	filter.SubjectID = assembly.SubjectID
+	filter.IncludeSubSubjects = true
+	filter.StatusID = statusID
+	filter.BloomLevels = assembly.BloomLevels
+	filter.Tags = tags
+	if assembly.MinDifficulty > 0 {
+		filter.MinDifficulty = assembly.MinDifficulty
+	}
+	if assembly.MaxDifficulty > 0 {
+		filter.MaxDifficulty = assembly.MaxDifficulty
+	}
+
+	candidates, err := s.questionsStorage.GetQuestions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	var picked []dto.Question
+	totalTime := 0
+	for _, question := range candidates {
+		if len(picked) == assembly.Count {
+			break
+		}
+		if assembly.MaxTotalTime > 0 && totalTime+question.EstimatedTime > assembly.MaxTotalTime {
+			continue
+		}
+		totalTime += question.EstimatedTime
+		picked = append(picked, question)
+	}
+
+	if len(picked) < assembly.Count {
+		return nil, dto.ErrNotEnoughQuestions
+	}
+
+	sort.SliceStable(picked, func(i, j int) bool {
+		return picked[i].Difficulty < picked[j].Difficulty
+	})
+
+	ids := make(dto.Int64Array, len(picked))
+	for i, question := range picked {
+		ids[i] = question.ID
+	}
+	return ids, nil
+}
+
+func (s quizzesService) approvedStatusID(ctx context.Context) (int64, error) {
+	statuses, err := s.questionsStorage.GetQuestionStatuses(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, status := range statuses {
+		if status.Name == dto.QuestionStatusNameApproved {
+			return int64(status.ID), nil
+		}
+	}
+	return -1, dto.ErrInternalServerError
+}
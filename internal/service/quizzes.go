@@ -2,21 +2,26 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/sirupsen/logrus"
-	"quiz_backend_core/internal/constants"
 	"quiz_backend_core/internal/dto"
 	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
 )
 
 type quizzesService struct {
-	storage model.QuizzesStorage
-	logger  *logrus.Logger
+	storage          model.QuizzesStorage
+	questionsStorage model.QuestionsStorage
+	subjectsStorage  model.SubjectsStorage
+	logger           *logrus.Logger
 }
 
 func NewQuizzesService(deps Deps) model.Quizzes {
 	var svc model.Quizzes = quizzesService{
-		storage: deps.Storages.Quizzes,
-		logger:  deps.Logger,
+		storage:          deps.Storages.Quizzes,
+		questionsStorage: deps.Storages.Questions,
+		subjectsStorage:  deps.Storages.Subjects,
+		logger:           deps.Logger,
 	}
 
 	//TODO
@@ -27,12 +32,21 @@ func NewQuizzesService(deps Deps) model.Quizzes {
 	return svc
 }
 
-func (s quizzesService) GetQuizzes(ctx context.Context, creatorUserID int64) ([]dto.Quiz, error) {
-	return s.storage.GetQuizzes(ctx, creatorUserID)
+func (s quizzesService) GetQuizzes(ctx context.Context, creatorUserID int64, tags []string) ([]dto.Quiz, error) {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	return s.storage.GetQuizzes(ctx, creatorUserID, tags)
 }
 
 func (s quizzesService) GetQuestionsByQuizID(ctx context.Context, quizID int64) ([]dto.Question, error) {
-	return s.storage.GetQuestionsByQuizID(ctx, quizID)
+	questions, err := s.storage.GetQuestionsByQuizID(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	return questions, redactAnswers(ctx, s.subjectsStorage, questions)
 }
 
 func (s quizzesService) GetQuizByID(ctx context.Context, quizID int64) (dto.Quiz, error) {
@@ -40,10 +54,35 @@ func (s quizzesService) GetQuizByID(ctx context.Context, quizID int64) (dto.Quiz
 }
 
 func (s quizzesService) AddQuiz(ctx context.Context, quiz dto.InputQuiz) (int64, error) {
-	quiz.CreatorUserID = ctx.Value(constants.ContextVariablesUserID).(int64)
+	principal, ok := policy.FromContext(ctx)
+	if !ok {
+		return -1, fmt.Errorf("%w: unknown user", dto.ErrForbidden)
+	}
+	quiz.CreatorUserID = principal.UserID
+
+	tags, err := normalizeTags(quiz.Tags)
+	if err != nil {
+		return -1, err
+	}
+	quiz.Tags = tags
+
+	if len(quiz.QuestionIDs) == 0 && quiz.Assembly != nil {
+		if quiz.QuestionIDs, err = s.assembleQuestions(ctx, *quiz.Assembly); err != nil {
+			return -1, err
+		}
+	}
+
 	return s.storage.AddQuiz(ctx, quiz)
 }
 
 func (s quizzesService) DeleteQuizByID(ctx context.Context, quizID int64) error {
 	return s.storage.DeleteQuizByID(ctx, quizID)
 }
+
+func (s quizzesService) GetDeletedQuizzes(ctx context.Context, creatorUserID int64) ([]dto.Quiz, error) {
+	return s.storage.GetDeletedQuizzes(ctx, creatorUserID)
+}
+
+func (s quizzesService) RestoreQuiz(ctx context.Context, quizID int64) error {
+	return s.storage.RestoreQuiz(ctx, quizID)
+}
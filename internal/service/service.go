@@ -3,14 +3,27 @@ package service
 import (
 	"github.com/go-kit/kit/metrics"
 	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/config"
+	"quiz_backend_core/internal/idempotency"
 	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
 	"quiz_backend_core/internal/storage"
+	"quiz_backend_core/internal/validation"
 )
 
 type Services struct {
-	Subjects  model.Subjects
-	Questions model.Questions
-	Quizzes   model.Quizzes
+	Subjects    model.Subjects
+	Questions   model.Questions
+	Quizzes     model.Quizzes
+	Tags        model.Tags
+	Attachments model.Attachments
+	Submissions model.Submissions
+	APITokens   model.APITokens
+	Audit       model.Audit
+
+	Policy      *policy.Policy
+	Validator   *validation.Validator
+	Idempotency *idempotency.Keeper
 }
 
 type Deps struct {
@@ -19,15 +32,32 @@ type Deps struct {
 	RequestCounter      metrics.Counter
 	RequestLatencyMeter metrics.Histogram
 	Notifier            model.Notifier //TODO interface
+	Config              *config.Config
+	BlobStore           model.BlobStore
+	CodeRunner          model.CodeRunner
 }
 
 func NewServices(deps Deps) *Services {
 	subjects := NewSubjectsService(deps) //TODO раскрыть deps для каждого сервиса
 	questions := NewQuestionsService(deps)
 	quizzes := NewQuizzesService(deps)
+	tags := NewTagsService(deps)
+	attachments := NewAttachmentsService(deps)
+	submissions := NewSubmissionsService(deps)
+	p := policy.DefaultPolicy(deps.Storages.Questions, deps.Storages.Quizzes, deps.Storages.Subjects, deps.Storages.APITokens)
+	apiTokens := NewAPITokensService(deps, p)
 	return &Services{
-		Subjects:  subjects,
-		Questions: questions,
-		Quizzes:   quizzes,
+		Subjects:    subjects,
+		Questions:   questions,
+		Quizzes:     quizzes,
+		Tags:        tags,
+		Attachments: attachments,
+		Submissions: submissions,
+		APITokens:   apiTokens,
+		Audit:       NewAuditService(deps),
+
+		Policy:      p,
+		Validator:   validation.NewValidator(deps.Storages.Questions, deps.Storages.Subjects),
+		Idempotency: idempotency.NewKeeper(deps.Storages.IdempotencyKeys, deps.Config.IdempotencyKeyTTL, deps.Logger),
 	}
 }
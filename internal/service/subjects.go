@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/sirupsen/logrus"
 	"quiz_backend_core/internal/dto"
 	"quiz_backend_core/internal/model"
 	"quiz_backend_core/internal/service/middleware"
+	"slices"
 )
 
 type subjectsService struct {
@@ -30,7 +32,58 @@ func (s subjectsService) GetSubjects(ctx context.Context) ([]dto.Subject, error)
 	return s.storage.GetSubjects(ctx)
 }
 
-func (s subjectsService) AddSubject(ctx context.Context, subject dto.Subject) (int64, error) {
+// GetSubjectTree returns nested subtree of rootID (-1 means the whole forest), depth counts levels below the root,
+// 0 means no limit
+func (s subjectsService) GetSubjectTree(ctx context.Context, rootID int64, depth int) ([]dto.SubjectTreeNode, error) {
+	if depth < 0 {
+		depth = 0
+	}
+
+	nodes, err := s.storage.GetSubjectTree(ctx, rootID, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	return nestSubjects(nodes), nil
+}
+
+// nestSubjects builds a tree from nodes ordered by path, nodes without a parent in the list are the roots
+func nestSubjects(nodes []dto.SubjectTreeNode) []dto.SubjectTreeNode {
+	present := make(map[int64]bool, len(nodes))
+	children := make(map[int64][]dto.SubjectTreeNode, len(nodes))
+	for _, node := range nodes {
+		present[node.ID] = true
+	}
+
+	// children are filled from the deepest nodes, so every child is complete when it is attached
+	var roots []dto.SubjectTreeNode
+	for i := len(nodes) - 1; i >= 0; i-- {
+		node := nodes[i]
+		node.Children = children[node.ID]
+		if node.Children == nil {
+			node.Children = []dto.SubjectTreeNode{}
+		}
+		slices.Reverse(node.Children)
+
+		if present[node.ParentId] {
+			children[node.ParentId] = append(children[node.ParentId], node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	slices.Reverse(roots)
+
+	if roots == nil {
+		roots = []dto.SubjectTreeNode{}
+	}
+	return roots
+}
+
+// AddSubject creates the subject maintained by its creator, sub subjects can be added only by parent maintainers
+// (checked by the policy)
+func (s subjectsService) AddSubject(ctx context.Context, userID int64, subject dto.Subject) (int64, error) {
+	subject.CreatorUserId = userID
+
 	return s.storage.AddSubject(ctx, subject)
 }
 
@@ -42,10 +95,57 @@ func (s subjectsService) GetStatistic(ctx context.Context, userId int64, userRol
 	return statistic, err
 }
 
-func (s subjectsService) UpdateSubject(ctx context.Context, subject dto.Subject) error {
+func (s subjectsService) UpdateSubject(ctx context.Context, userID int64, subject dto.Subject) error {
 	return s.storage.UpdateSubject(ctx, subject)
 }
 
-func (s subjectsService) DeleteSubjectByID(ctx context.Context, subjectID int64) error {
-	return s.storage.DeleteSubjectByID(ctx, subjectID)
+// DeleteSubjectByID moves the subject with its subtree to the trash, empty mode means refuse.
+// Returned preview lists what was affected (or what prevents deletion in refuse mode).
+func (s subjectsService) DeleteSubjectByID(ctx context.Context, userID int64, subjectID int64, mode dto.SubjectDeleteMode, targetSubjectID int64) (dto.SubjectDeletePreview, error) {
+	if mode == "" {
+		mode = dto.SubjectDeleteRefuse
+	}
+
+	if !slices.Contains(dto.SubjectDeleteModes, mode) {
+		return dto.SubjectDeletePreview{}, fmt.Errorf("%w: %q", dto.ErrInvalidDeleteMode, mode)
+	}
+
+	if mode == dto.SubjectDeleteReassign && (targetSubjectID <= 0 || targetSubjectID == subjectID) {
+		return dto.SubjectDeletePreview{}, dto.ErrInvalidReassignTarget
+	}
+
+	return s.storage.DeleteSubjectByID(ctx, subjectID, mode, targetSubjectID)
+}
+
+// GetSubjectByID returns the subject with its version, subjects in the trash are found too
+func (s subjectsService) GetSubjectByID(ctx context.Context, subjectID int64) (dto.Subject, error) {
+	return s.storage.GetSubjectByID(ctx, subjectID)
+}
+
+func (s subjectsService) GetSubjectDeletePreview(ctx context.Context, subjectID int64) (dto.SubjectDeletePreview, error) {
+	return s.storage.GetSubjectDeletePreview(ctx, subjectID)
+}
+
+func (s subjectsService) GetDeletedSubjects(ctx context.Context) ([]dto.Subject, error) {
+	return s.storage.GetDeletedSubjects(ctx)
+}
+
+func (s subjectsService) RestoreSubject(ctx context.Context, userID int64, subjectID int64) error {
+	return s.storage.RestoreSubject(ctx, subjectID)
+}
+
+func (s subjectsService) MoveSubject(ctx context.Context, userID int64, subjectID int64, parentID int64) error {
+	if parentID == subjectID {
+		return dto.ErrSubjectCycle
+	}
+
+	return s.storage.MoveSubject(ctx, subjectID, parentID)
+}
+
+func (s subjectsService) MergeSubjects(ctx context.Context, userID int64, sourceID int64, targetID int64) error {
+	if sourceID == targetID {
+		return dto.ErrSubjectCycle
+	}
+
+	return s.storage.MergeSubjects(ctx, sourceID, targetID)
 }
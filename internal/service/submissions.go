@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/service/middleware"
+	"slices"
+	"strings"
+)
+
+const programTestsMax = 50
+
+type submissionsService struct {
+	storage          model.SubmissionsStorage
+	questionsStorage model.QuestionsStorage
+	runner           model.CodeRunner
+	logger           *logrus.Logger
+	maxSize          int
+}
+
+func NewSubmissionsService(deps Deps) model.Submissions {
+	var svc model.Submissions = submissionsService{
+		storage:          deps.Storages.Submissions,
+		questionsStorage: deps.Storages.Questions,
+		runner:           deps.CodeRunner,
+		logger:           deps.Logger,
+		maxSize:          deps.Config.SubmissionMaxSize,
+	}
+
+	// middleware services
+	svc = middleware.LoggingSubmissionsMiddleware(deps.Logger)(svc)
+	svc = middleware.InstrumentingSubmissionsMiddleware(deps.RequestCounter, deps.RequestLatencyMeter)(svc)
+
+	return svc
+}
+
+// SubmitProgram runs submitted code against hidden tests of the program question and stores graded submission.
+// Code is always run in the language of the question.
+func (s submissionsService) SubmitProgram(ctx context.Context, submission dto.Submission) (dto.Submission, error) {
+	if strings.TrimSpace(submission.Code) == "" {
+		return submission, dto.ErrEmptySubmission
+	}
+
+	if len(submission.Code) > s.maxSize {
+		return submission, dto.ErrSubmissionTooLarge
+	}
+
+	question, err := s.questionsStorage.GetQuestionByID(ctx, submission.QuestionID)
+	if err != nil {
+		return submission, err
+	}
+
+	if question.Type.QuestionTypeName != dto.QuestionTypeNameProgram {
+		return submission, dto.ErrNotProgramQuestion
+	}
+
+	answer, err := programAnswer(question.Answer)
+	if err != nil {
+		return submission, err
+	}
+	submission.Language = answer.Language
+
+	inputs := make([]string, 0, len(answer.Tests))
+	for _, test := range answer.Tests {
+		inputs = append(inputs, test.Input)
+	}
+
+	run, err := s.runner.Run(ctx, dto.RunRequest{
+		Language: answer.Language,
+		Code:     submission.Code,
+		Inputs:   inputs,
+	})
+	if err != nil {
+		return submission, err
+	}
+
+	submission = gradeProgram(submission, answer.Tests, run)
+
+	if submission.ID, err = s.storage.AddSubmission(ctx, submission); err != nil {
+		return submission, err
+	}
+
+	return submission, nil
+}
+
+// GetSubmissionByID returns submission of the user, admins and moderators can see any submission
+func (s submissionsService) GetSubmissionByID(ctx context.Context, userID int64, userRole dto.Role, submissionID int64) (dto.Submission, error) {
+	submission, err := s.storage.GetSubmissionByID(ctx, submissionID)
+	if err != nil {
+		return submission, err
+	}
+
+	if submission.UserID != userID && userRole != dto.RoleAdmin && userRole != dto.RoleModerator {
+		return dto.Submission{}, dto.ErrForbidden
+	}
+
+	return submission, nil
+}
+
+// GetSubmissions returns submissions of the user, questionID -1 means "any question"
+func (s submissionsService) GetSubmissions(ctx context.Context, userID int64, questionID int64) ([]dto.Submission, error) {
+	return s.storage.GetSubmissions(ctx, userID, questionID)
+}
+
+// programAnswer reads hidden tests from the answer of a program question
+func programAnswer(answer map[string]interface{}) (dto.ProgramAnswer, error) {
+	var programAnswer dto.ProgramAnswer
+
+	data, err := json.Marshal(answer)
+	if err != nil {
+		return programAnswer, dto.ErrInvalidProgramAnswer
+	}
+
+	if err = json.Unmarshal(data, &programAnswer); err != nil {
+		return programAnswer, dto.ErrInvalidProgramAnswer
+	}
+
+	if programAnswer.Language == "" || len(programAnswer.Tests) == 0 || len(programAnswer.Tests) > programTestsMax {
+		return programAnswer, dto.ErrInvalidProgramAnswer
+	}
+
+	return programAnswer, nil
+}
+
+// validateProgramAnswer checks answer of a program question when it is saved
+func validateProgramAnswer(answer map[string]interface{}, runner model.CodeRunner) error {
+	programAnswer, err := programAnswer(answer)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(runner.Languages(), programAnswer.Language) {
+		return dto.ErrUnsupportedRunnerLanguage
+	}
+
+	return nil
+}
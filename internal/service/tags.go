@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/service/middleware"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	tagMaxLength = 50
+
+	tagsDefaultLimit = 10
+	tagsMaxLimit     = 100
+)
+
+type tagsService struct {
+	storage model.TagsStorage
+	logger  *logrus.Logger
+}
+
+func NewTagsService(deps Deps) model.Tags {
+	var svc model.Tags = tagsService{
+		storage: deps.Storages.Tags,
+		logger:  deps.Logger,
+	}
+
+	// middleware services
+	svc = middleware.LoggingTagsMiddleware(deps.Logger)(svc)
+	svc = middleware.InstrumentingTagsMiddleware(deps.RequestCounter, deps.RequestLatencyMeter)(svc)
+
+	return svc
+}
+
+// GetTags is used for autocomplete: tags with the prefix, most used first
+func (s tagsService) GetTags(ctx context.Context, prefix string, limit int) ([]dto.Tag, error) {
+	if limit <= 0 {
+		limit = tagsDefaultLimit
+	}
+	if limit > tagsMaxLimit {
+		limit = tagsMaxLimit
+	}
+
+	return s.storage.GetTags(ctx, strings.ToLower(strings.TrimSpace(prefix)), limit)
+}
+
+// normalizeTags trims and lowercases tags and removes duplicates. nil stays nil.
+// Tag may contain letters, digits and "-_.+#" only.
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > tagMaxLength {
+			return nil, dto.NewFieldError("tags", dto.ErrInvalidTag)
+		}
+
+		for _, r := range tag {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.+#", r) {
+				return nil, dto.NewFieldError("tags", dto.ErrInvalidTag)
+			}
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"quiz_backend_core/internal/model"
+	"time"
+)
+
+// TrashPurger removes questions, quizzes and subjects which are in the trash longer than retention period,
+// and attachments which are not linked to a question longer than unlinkedTTL
+type TrashPurger struct {
+	subjects    model.SubjectsStorage
+	questions   model.QuestionsStorage
+	quizzes     model.QuizzesStorage
+	attachments model.AttachmentsStorage
+	blobs       model.BlobStore
+	logger      *logrus.Logger
+	retention   time.Duration
+	interval    time.Duration
+	unlinkedTTL time.Duration
+}
+
+func NewTrashPurger(deps Deps) *TrashPurger {
+	return &TrashPurger{
+		subjects:    deps.Storages.Subjects,
+		questions:   deps.Storages.Questions,
+		quizzes:     deps.Storages.Quizzes,
+		attachments: deps.Storages.Attachments,
+		blobs:       deps.BlobStore,
+		logger:      deps.Logger,
+		retention:   deps.Config.TrashRetention,
+		interval:    deps.Config.TrashPurgeInterval,
+		unlinkedTTL: deps.Config.AttachmentsUnlinkedTTL,
+	}
+}
+
+// Run purges the trash every interval until ctx is done
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Purge(ctx); err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("unable to purge trash")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes expired items, quizzes and questions go first because subjects are referenced by questions
+func (p *TrashPurger) Purge(ctx context.Context) error {
+	quizzes, err := p.quizzes.PurgeQuizzes(ctx, p.retention)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := p.questions.PurgeQuestions(ctx, p.retention)
+	if err != nil {
+		return err
+	}
+	removeAttachments(ctx, p.attachments, p.blobs, p.logger, attachments)
+
+	subjects, err := p.subjects.PurgeSubjects(ctx, p.retention)
+	if err != nil {
+		return err
+	}
+
+	// uploads never linked to a question and attachments unlinked from them
+	unlinked, err := p.attachments.PurgeUnlinkedAttachments(ctx, p.unlinkedTTL)
+	if err != nil {
+		return err
+	}
+	removeAttachments(ctx, p.attachments, p.blobs, p.logger, unlinked)
+	attachments = append(attachments, unlinked...)
+
+	if quizzes != 0 || len(attachments) != 0 || subjects != 0 {
+		p.logger.WithFields(logrus.Fields{
+			"quizzes":     quizzes,
+			"attachments": len(attachments),
+			"subjects":    subjects,
+		}).Info("trash purged")
+	}
+
+	return nil
+}
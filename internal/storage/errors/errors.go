@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"quiz_backend_core/internal/dto"
 )
 
 type (
@@ -59,6 +60,15 @@ func (e *ViolationPSQLRequestError) Error() string {
 	return fmt.Sprintf("%s: more then one record affected, whitch is prohibited", e.Err.Error())
 }
 
+// Is lets storage errors be matched by the kind even if they wrap a driver error
+func (e *NotFoundError) Is(target error) bool {
+	return target == dto.KindNotFound
+}
+
+func (e *AlreadyExistsError) Is(target error) bool {
+	return target == dto.KindConflict
+}
+
 func (e *NotFoundError) Unwrap() error {
 	return e.Err
 }
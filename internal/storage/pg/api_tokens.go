@@ -0,0 +1,236 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"quiz_backend_core/internal/dto"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+	"time"
+)
+
+func NewAPITokensStorage(conn *pgxpool.Pool) *APITokensStorage {
+	return &APITokensStorage{
+		conn: conn,
+	}
+}
+
+type APITokensStorage struct {
+	conn *pgxpool.Pool
+}
+
+// token hash is never returned
+const apiTokenObject = `
+		json_build_object(
+			'id', t.id::TEXT,
+			'name', t.name,
+			'user_id', t.user_id::TEXT,
+			'role', t.role,
+			'scopes', t.scopes,
+			'prefix', t.prefix,
+			'created_by_user_id', t.created_by_user_id::TEXT,
+			'created_at', t.created_at,
+			'expires_at', t.expires_at,
+			'last_used_at', t.last_used_at,
+			'revoked_at', t.revoked_at
+		)`
+
+func (s APITokensStorage) AddAPIToken(ctx context.Context, token dto.InputAPIToken) (int64, error) {
+	var tokenID int64 = -1
+	query := `
+		INSERT INTO api_token (
+			name,				-- 1
+			user_id,			-- 2
+			role,				-- 3
+			scopes,				-- 4
+			token_hash,			-- 5
+			prefix,				-- 6
+			created_by_user_id,	-- 7
+			expires_at			-- 8
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id;
+	`
+
+	var expiresAt *time.Time
+	if !token.ExpiresAt.IsZero() {
+		expiresAt = &token.ExpiresAt
+	}
+
+	args := []interface{}{
+		token.Name,
+		token.UserID,
+		token.Role,
+		token.Scopes,
+		token.Hash,
+		token.Prefix,
+		token.CreatedByUserID,
+		expiresAt,
+	}
+
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return tokenID, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&tokenID); err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
+			return tokenID, &storage_errors.AlreadyExistsError{Err: pgErr}
+		} else {
+			return tokenID, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionCreate, dto.AuditEntityAPIToken, tokenID, nil); err != nil {
+		return -1, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return -1, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return tokenID, nil
+}
+
+func (s APITokensStorage) GetAPITokenByID(ctx context.Context, tokenID int64) (dto.APIToken, error) {
+	query := `
+		SELECT` + apiTokenObject + `
+		FROM api_token t
+		WHERE t.id = $1
+	`
+
+	var res string
+	var token dto.APIToken
+	if err := s.conn.QueryRow(ctx, query, tokenID).Scan(&res); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return token, &storage_errors.NotFoundError{Err: dto.ErrAPITokenNotFound}
+		default:
+			return token, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+
+	if err := json.Unmarshal([]byte(res), &token); err != nil {
+		return token, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+	}
+
+	return token, nil
+}
+
+// GetAPITokens returns tokens newest first including revoked and expired ones, -1 means "any" user
+func (s APITokensStorage) GetAPITokens(ctx context.Context, userID int64) ([]dto.APIToken, error) {
+	var tokens = []dto.APIToken{}
+	query := `
+		SELECT` + apiTokenObject + `
+		FROM api_token t
+		WHERE $1 = -1 OR t.user_id = $1
+		ORDER BY t.id DESC
+	`
+
+	rows, err := s.conn.Query(ctx, query, userID)
+	if err != nil {
+		return tokens, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res string
+		if err := rows.Scan(&res); err != nil {
+			return tokens, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		var result dto.APIToken
+		if err := json.Unmarshal([]byte(res), &result); err != nil {
+			return tokens, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		tokens = append(tokens, result)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken marks the token revoked, revoking a revoked token keeps the first revoke time
+func (s APITokensStorage) RevokeAPIToken(ctx context.Context, tokenID int64) error {
+	query := `
+		UPDATE api_token SET revoked_at = coalesce(revoked_at, now()) WHERE id = $1
+	`
+
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := snapshot(ctx, tx, dto.AuditEntityAPIToken, tokenID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(ctx, query, tokenID)
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if result.RowsAffected() == 0 {
+		return &storage_errors.NotFoundError{Err: dto.ErrAPITokenNotFound}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionRevoke, dto.AuditEntityAPIToken, tokenID, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// apiTokenUsePrecision limits writes of last use time, a token used by every request is updated once per it
+const apiTokenUsePrecision = time.Minute
+
+// UseAPIToken finds active token by its hash and records the use, the returned token has the previous use time
+func (s APITokensStorage) UseAPIToken(ctx context.Context, hash []byte) (dto.APIToken, error) {
+	query := `
+		WITH token AS (
+			SELECT * FROM api_token
+			WHERE token_hash = $1
+				AND revoked_at IS NULL
+				AND (expires_at IS NULL OR expires_at > now())
+		), used AS (
+			UPDATE api_token SET last_used_at = now()
+			WHERE id IN (SELECT id FROM token)
+				AND (last_used_at IS NULL OR last_used_at < now() - $2 * interval '1 second')
+		)
+		SELECT` + apiTokenObject + `
+		FROM token t
+	`
+
+	var res string
+	var token dto.APIToken
+	if err := s.conn.QueryRow(ctx, query, hash, apiTokenUsePrecision.Seconds()).Scan(&res); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return token, &storage_errors.NotFoundError{Err: dto.ErrInvalidAPIToken}
+		default:
+			return token, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+
+	if err := json.Unmarshal([]byte(res), &token); err != nil {
+		return token, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+	}
+
+	return token, nil
+}
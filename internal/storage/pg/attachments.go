@@ -0,0 +1,279 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"quiz_backend_core/internal/dto"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+	"time"
+)
+
+func NewAttachmentsStorage(conn *pgxpool.Pool) *AttachmentsStorage {
+	return &AttachmentsStorage{
+		conn: conn,
+	}
+}
+
+type AttachmentsStorage struct {
+	conn *pgxpool.Pool
+}
+
+const attachmentObject = `
+		json_build_object(
+			'id', a.id::TEXT,
+			'question_id', a.question_id::TEXT,
+			'file_name', a.file_name,
+			'mime_type', a.mime_type,
+			'size', a.size,
+			'creator_user_id', a.creator_user_id::TEXT,
+			'created_at', a.created_at
+		)`
+
+func (a AttachmentsStorage) AddAttachment(ctx context.Context, attachment dto.Attachment) (int64, error) {
+	var attachmentID int64 = -1
+	query := `
+		INSERT INTO attachment (
+			file_name,
+			mime_type,
+			size,
+			storage_key,
+			creator_user_id
+		)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id;
+	`
+
+	args := []interface{}{
+		attachment.FileName,
+		attachment.MimeType,
+		attachment.Size,
+		attachment.StorageKey,
+		attachment.CreatorUserID,
+	}
+
+	tx, err := a.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return attachmentID, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&attachmentID); err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
+			return attachmentID, &storage_errors.AlreadyExistsError{Err: pgErr}
+		} else {
+			return attachmentID, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionCreate, dto.AuditEntityAttachment, attachmentID, nil); err != nil {
+		return -1, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return -1, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return attachmentID, nil
+}
+
+func (a AttachmentsStorage) GetAttachmentByID(ctx context.Context, attachmentID int64) (dto.Attachment, error) {
+	query := `
+		SELECT` + attachmentObject + `, a.storage_key
+		FROM attachment a
+		WHERE a.id = $1
+	`
+
+	var res string
+	var attachment dto.Attachment
+	if err := a.conn.QueryRow(ctx, query, attachmentID).Scan(&res, &attachment.StorageKey); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return attachment, &storage_errors.NotFoundError{Err: dto.ErrAttachmentNotFound}
+		default:
+			return attachment, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+
+	if err := json.Unmarshal([]byte(res), &attachment); err != nil {
+		return attachment, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+	}
+
+	return attachment, nil
+}
+
+func (a AttachmentsStorage) GetAttachmentsByQuestionID(ctx context.Context, questionID int64) ([]dto.Attachment, error) {
+	var attachments = []dto.Attachment{}
+	query := `
+		SELECT` + attachmentObject + `, a.storage_key
+		FROM attachment a
+		WHERE a.question_id = $1
+		ORDER BY a.id
+	`
+
+	rows, err := a.conn.Query(ctx, query, questionID)
+	if err != nil {
+		return attachments, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res string
+		var result dto.Attachment
+		if err := rows.Scan(&res, &result.StorageKey); err != nil {
+			return attachments, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		if err := json.Unmarshal([]byte(res), &result); err != nil {
+			return attachments, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		attachments = append(attachments, result)
+	}
+	return attachments, nil
+}
+
+func (a AttachmentsStorage) DeleteAttachment(ctx context.Context, attachmentID int64) error {
+	query := `
+		DELETE FROM
+		    attachment t
+		WHERE
+		    t.id = $1
+	`
+
+	tx, err := a.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	removed, err := removeAudited(ctx, tx, dto.AuditActionPurge, dto.AuditEntityAttachment, query, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	if removed == 0 {
+		return &storage_errors.NotFoundError{Err: dto.ErrAttachmentNotFound}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// PurgeUnlinkedAttachments removes attachments which are not linked to a question longer than ttl: uploads
+// never used by a question and attachments unlinked by question updates. Removed attachments are returned,
+// their blobs have to be deleted by the caller.
+func (a AttachmentsStorage) PurgeUnlinkedAttachments(ctx context.Context, ttl time.Duration) ([]dto.Attachment, error) {
+	var attachments = []dto.Attachment{}
+	// rows are locked, so an attachment linked by a concurrent question save is not removed
+	attachmentsQuery := `
+		SELECT` + attachmentObject + `, a.storage_key
+		FROM attachment a
+		WHERE a.question_id IS NULL AND a.created_at < now() - $1::INTERVAL
+		FOR UPDATE
+	`
+	removeQuery := `
+		DELETE FROM
+		    attachment t
+		WHERE
+		    t.id = ANY($1::BIGINT[])
+	`
+
+	tx, err := a.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return attachments, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, attachmentsQuery, ttl)
+	if err != nil {
+		return attachments, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var res string
+		var result dto.Attachment
+		if err = rows.Scan(&res, &result.StorageKey); err != nil {
+			rows.Close()
+			return attachments, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		if err = json.Unmarshal([]byte(res), &result); err != nil {
+			rows.Close()
+			return attachments, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		attachments = append(attachments, result)
+		ids = append(ids, result.ID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return attachments, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if len(ids) == 0 {
+		return attachments, nil
+	}
+
+	if _, err = removeAudited(ctx, tx, dto.AuditActionPurge, dto.AuditEntityAttachment, removeQuery, ids); err != nil {
+		return []dto.Attachment{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return []dto.Attachment{}, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return attachments, nil
+}
+
+// setQuestionAttachments links attachments to the question and unlinks the rest of question attachments.
+// Attachment which belongs to another question can not be linked.
+func setQuestionAttachments(ctx context.Context, tx pgx.Tx, questionID int64, attachmentIDs []int64) error {
+	unlinkQuery := `
+		UPDATE attachment SET question_id = NULL
+		WHERE question_id = $1 AND NOT (id = ANY($2::BIGINT[]))
+	`
+	linkQuery := `
+		UPDATE attachment SET question_id = $1
+		WHERE id = ANY($2::BIGINT[]) AND (question_id IS NULL OR question_id = $1)
+	`
+
+	if attachmentIDs == nil {
+		attachmentIDs = []int64{}
+	}
+
+	if _, err := tx.Exec(ctx, unlinkQuery, questionID, attachmentIDs); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("unlink attachments failed: %v\n", err)}
+	}
+
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+
+	tag, err := tx.Exec(ctx, linkQuery, questionID, attachmentIDs)
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("link attachments failed: %v\n", err)}
+	}
+
+	if int(tag.RowsAffected()) != len(attachmentIDs) {
+		return &storage_errors.NotFoundError{Err: dto.ErrAttachmentNotFound}
+	}
+
+	return nil
+}
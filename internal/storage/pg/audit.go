@@ -0,0 +1,275 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"quiz_backend_core/internal/audit"
+	"quiz_backend_core/internal/dto"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+	"strings"
+)
+
+func NewAuditStorage(conn *pgxpool.Pool) *AuditStorage {
+	return &AuditStorage{
+		conn: conn,
+	}
+}
+
+type AuditStorage struct {
+	conn *pgxpool.Pool
+}
+
+const auditEntryObject = `
+		json_build_object(
+			'id', a.id::TEXT,
+			'actor_user_id', a.actor_user_id::TEXT,
+			'actor_role', a.actor_role,
+			'action', a.action,
+			'entity_type', a.entity_type,
+			'entity_id', a.entity_id::TEXT,
+			'before', a.before,
+			'after', a.after,
+			'request_id', a.request_id,
+			'created_at', a.created_at
+		)`
+
+// GetAuditEntries returns entries newest first
+func (s AuditStorage) GetAuditEntries(ctx context.Context, filter dto.AuditFilter) ([]dto.AuditEntry, error) {
+	var entries = []dto.AuditEntry{}
+	query := `
+		SELECT` + auditEntryObject + `
+		FROM audit_log a
+		%s
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT %d OFFSET %d
+	`
+
+	var conditions []string
+	var args []interface{}
+
+	if filter.EntityType != "" {
+		args = append(args, filter.EntityType)
+		conditions = append(conditions, fmt.Sprintf("a.entity_type = $%d", len(args)))
+	}
+
+	if filter.EntityID != -1 {
+		conditions = append(conditions, fmt.Sprintf("a.entity_id=%d", filter.EntityID))
+	}
+
+	if filter.ActorUserID != -1 {
+		conditions = append(conditions, fmt.Sprintf("a.actor_user_id=%d", filter.ActorUserID))
+	}
+
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("a.created_at >= $%d", len(args)))
+	}
+
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("a.created_at < $%d", len(args)))
+	}
+
+	allConditions := ""
+	if len(conditions) != 0 {
+		allConditions = fmt.Sprintf("WHERE %s", strings.Join(conditions, " and "))
+	}
+	query = fmt.Sprintf(query, allConditions, filter.Limit, filter.Offset)
+
+	rows, err := s.conn.Query(ctx, query, args...)
+	if err != nil {
+		return entries, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res string
+		if err := rows.Scan(&res); err != nil {
+			return entries, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		var result dto.AuditEntry
+		if err := json.Unmarshal([]byte(res), &result); err != nil {
+			return entries, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		entries = append(entries, result)
+	}
+	return entries, nil
+}
+
+// auditHiddenColumns are not copied to the audit log
+var auditHiddenColumns = map[dto.AuditEntity]string{
+	dto.AuditEntityQuestion: `'{search_vector}'`,
+	dto.AuditEntityAPIToken: `'{token_hash}'`,
+}
+
+// auditRow is JSON of the row of the entity table aliased alias
+func auditRow(entity dto.AuditEntity, alias string) string {
+	if hidden, ok := auditHiddenColumns[entity]; ok {
+		return "to_jsonb(" + alias + ") - " + hidden + "::TEXT[]"
+	}
+	return "to_jsonb(" + alias + ")"
+}
+
+// snapshot returns the row of the object for the audit log, nil if there is no such row
+func snapshot(ctx context.Context, tx pgx.Tx, entity dto.AuditEntity, id int64) (json.RawMessage, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s t WHERE t.id = $1
+	`, auditRow(entity, "t"), entity)
+
+	var row json.RawMessage
+	if err := tx.QueryRow(ctx, query, id).Scan(&row); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return row, nil
+}
+
+// writeAudit appends the change made by the actor from ctx to the audit log
+func writeAudit(ctx context.Context, tx pgx.Tx, action dto.AuditAction, entity dto.AuditEntity, id int64, before, after json.RawMessage) error {
+	query := `
+		INSERT INTO audit_log (
+			actor_user_id,	-- 1
+			actor_role,		-- 2
+			action,			-- 3
+			entity_type,	-- 4
+			entity_id,		-- 5
+			before,			-- 6
+			after,			-- 7
+			request_id		-- 8
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	actor, ok := audit.FromContext(ctx)
+	if !ok {
+		actor.Role = dto.AuditRoleSystem
+	}
+
+	var actorUserID *int64
+	if actor.UserID > 0 {
+		actorUserID = &actor.UserID
+	}
+
+	var requestID *string
+	if actor.RequestID != "" {
+		requestID = &actor.RequestID
+	}
+
+	args := []interface{}{
+		actorUserID,
+		actor.Role,
+		action,
+		entity,
+		id,
+		before,
+		after,
+		requestID,
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("audit failed: %v\n", err)}
+	}
+
+	return nil
+}
+
+// auditChange writes the change of the object, its current row is the after state
+func auditChange(ctx context.Context, tx pgx.Tx, action dto.AuditAction, entity dto.AuditEntity, id int64, before json.RawMessage) error {
+	after, err := snapshot(ctx, tx, entity, id)
+	if err != nil {
+		return err
+	}
+	return writeAudit(ctx, tx, action, entity, id, before, after)
+}
+
+// removeAudited runs DELETE of entity table aliased t, the query ends with RETURNING added here.
+// Every removed row is written to the audit log, the number of removed rows is returned.
+func removeAudited(ctx context.Context, tx pgx.Tx, action dto.AuditAction, entity dto.AuditEntity, query string, args ...interface{}) (int64, error) {
+	query += fmt.Sprintf(" RETURNING t.id, %s", auditRow(entity, "t"))
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return 0, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	type removed struct {
+		id  int64
+		row json.RawMessage
+	}
+	var removedRows []removed
+	for rows.Next() {
+		var r removed
+		if err := rows.Scan(&r.id, &r.row); err != nil {
+			rows.Close()
+			return 0, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+		removedRows = append(removedRows, r)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	for _, r := range removedRows {
+		if err = writeAudit(ctx, tx, action, entity, r.id, r.row, nil); err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(removedRows)), nil
+}
+
+// updateAudited runs UPDATE of entity table aliased t, columns in set and where are qualified by t.
+// Every changed row is written to the audit log, so changes cascaded from another object can be found
+// by their own entity. Ids of changed rows are returned.
+func updateAudited(ctx context.Context, tx pgx.Tx, action dto.AuditAction, entity dto.AuditEntity, set, where string, args ...interface{}) ([]int64, error) {
+	// the joined copy of the row is read before the update, it is the before state
+	query := fmt.Sprintf(`
+		UPDATE %[1]s t
+		SET %[2]s
+		FROM %[1]s old
+		WHERE old.id = t.id AND (%[3]s)
+		RETURNING t.id, %[4]s, %[5]s
+	`, entity, set, where, auditRow(entity, "old"), auditRow(entity, "t"))
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	type changed struct {
+		id            int64
+		before, after json.RawMessage
+	}
+	var changedRows []changed
+	for rows.Next() {
+		var c changed
+		if err := rows.Scan(&c.id, &c.before, &c.after); err != nil {
+			rows.Close()
+			return nil, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+		changedRows = append(changedRows, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	ids := make([]int64, 0, len(changedRows))
+	for _, c := range changedRows {
+		if err = writeAudit(ctx, tx, action, entity, c.id, c.before, c.after); err != nil {
+			return nil, err
+		}
+		ids = append(ids, c.id)
+	}
+
+	return ids, nil
+}
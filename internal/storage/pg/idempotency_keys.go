@@ -0,0 +1,99 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"quiz_backend_core/internal/dto"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+)
+
+func NewIdempotencyKeysStorage(conn *pgxpool.Pool) *IdempotencyKeysStorage {
+	return &IdempotencyKeysStorage{
+		conn: conn,
+	}
+}
+
+type IdempotencyKeysStorage struct {
+	conn *pgxpool.Pool
+}
+
+// ReserveIdempotencyKey stores the key without response, expired key is taken over. If the key is used already
+// its stored state is returned and reserved is false.
+func (s IdempotencyKeysStorage) ReserveIdempotencyKey(ctx context.Context, key dto.IdempotencyKey) (stored dto.IdempotencyKey, reserved bool, err error) {
+	insertQuery := `
+		INSERT INTO idempotency_key (user_id, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			response = NULL,
+			version = 0,
+			created_at = now(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_key.expires_at <= now()
+	`
+	selectQuery := `
+		SELECT request_hash, response, version, expires_at
+		FROM idempotency_key
+		WHERE user_id = $1 AND key = $2
+	`
+
+	result, err := s.conn.Exec(ctx, insertQuery, key.UserID, key.Key, key.RequestHash, key.ExpiresAt)
+	if err != nil {
+		return stored, false, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if result.RowsAffected() != 0 {
+		return key, true, nil
+	}
+
+	stored = dto.IdempotencyKey{UserID: key.UserID, Key: key.Key}
+	if err = s.conn.QueryRow(ctx, selectQuery, key.UserID, key.Key).Scan(&stored.RequestHash, &stored.Response, &stored.Version, &stored.ExpiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// removed by purge right now, the client retries
+			return stored, false, dto.ErrIdempotencyKeyInProgress
+		}
+		return stored, false, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return stored, false, nil
+}
+
+func (s IdempotencyKeysStorage) SaveIdempotentResponse(ctx context.Context, key dto.IdempotencyKey) error {
+	query := `
+		UPDATE idempotency_key SET response = $3, version = $4 WHERE user_id = $1 AND key = $2
+	`
+
+	if _, err := s.conn.Exec(ctx, query, key.UserID, key.Key, key.Response, key.Version); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey removes the key of failed request, so the request may be retried with it
+func (s IdempotencyKeysStorage) ReleaseIdempotencyKey(ctx context.Context, userID int64, key string) error {
+	query := `
+		DELETE FROM idempotency_key WHERE user_id = $1 AND key = $2 AND response IS NULL
+	`
+
+	if _, err := s.conn.Exec(ctx, query, userID, key); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+func (s IdempotencyKeysStorage) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM idempotency_key WHERE expires_at <= now()
+	`
+
+	result, err := s.conn.Exec(ctx, query)
+	if err != nil {
+		return 0, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return result.RowsAffected(), nil
+}
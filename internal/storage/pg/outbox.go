@@ -0,0 +1,116 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"quiz_backend_core/internal/dto"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+	"time"
+)
+
+func NewOutboxStorage(conn *pgxpool.Pool) *OutboxStorage {
+	return &OutboxStorage{
+		conn: conn,
+	}
+}
+
+type OutboxStorage struct {
+	conn *pgxpool.Pool
+}
+
+// enqueueEvents writes events to the outbox in the transaction of the change, so they are sent only if it is committed
+func enqueueEvents(ctx context.Context, tx pgx.Tx, events []dto.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox_event (room, event, data) VALUES ($1, $2, $3)
+	`
+
+	for _, event := range events {
+		if _, err := tx.Exec(ctx, query, event.Room, event.Event, event.Data); err != nil {
+			return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("outbox failed: %v\n", err)}
+		}
+	}
+
+	return nil
+}
+
+// ClaimOutboxEvents returns up to limit pending events which are due, oldest first. Claimed events are leased:
+// other dispatchers don't get them until the lease expires, so an event of crashed dispatcher is delivered again.
+func (s OutboxStorage) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]dto.OutboxEvent, error) {
+	var events []dto.OutboxEvent
+	query := `
+		UPDATE outbox_event SET next_attempt_at = now() + $2::INTERVAL
+		WHERE id IN (
+			SELECT id FROM outbox_event
+			WHERE delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= now()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, room, event, data, attempts
+	`
+
+	rows, err := s.conn.Query(ctx, query, limit, lease)
+	if err != nil {
+		return events, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event dto.OutboxEvent
+		if err := rows.Scan(&event.ID, &event.Room, &event.Event, &event.Data, &event.Attempts); err != nil {
+			return events, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return events, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return events, nil
+}
+
+func (s OutboxStorage) MarkOutboxEventDelivered(ctx context.Context, id int64) error {
+	query := `
+		UPDATE outbox_event SET delivered_at = now(), attempts = attempts + 1 WHERE id = $1
+	`
+
+	if _, err := s.conn.Exec(ctx, query, id); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// MarkOutboxEventFailed counts the failed attempt, the event is retried after retryAfter or dead-lettered if dead is set
+func (s OutboxStorage) MarkOutboxEventFailed(ctx context.Context, id int64, lastError string, retryAfter time.Duration, dead bool) error {
+	query := `
+		UPDATE outbox_event SET
+			attempts = attempts + 1,
+			last_error = $2,
+			next_attempt_at = now() + $3::INTERVAL,
+			dead_at = CASE WHEN $4::BOOLEAN THEN now() END
+		WHERE id = $1
+	`
+
+	if _, err := s.conn.Exec(ctx, query, id, lastError, retryAfter, dead); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// PurgeOutboxEvents removes events delivered longer than retention ago, dead letters are kept for investigation
+func (s OutboxStorage) PurgeOutboxEvents(ctx context.Context, retention time.Duration) (int64, error) {
+	query := `
+		DELETE FROM outbox_event WHERE delivered_at < now() - $1::INTERVAL
+	`
+
+	result, err := s.conn.Exec(ctx, query, retention)
+	if err != nil {
+		return 0, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return result.RowsAffected(), nil
+}
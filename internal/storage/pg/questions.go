@@ -10,9 +10,11 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"html"
 	"quiz_backend_core/internal/dto"
 	storage_errors "quiz_backend_core/internal/storage/errors"
 	"strings"
+	"time"
 )
 
 func NewQuestionsStorage(conn *pgxpool.Pool) *QuestionsStorage {
@@ -25,14 +27,14 @@ type QuestionsStorage struct {
 	conn *pgxpool.Pool
 }
 
-func (q QuestionsStorage) GetQuestions(ctx context.Context, creatorUserID, subjectID, statusID int64) ([]dto.Question, error) {
-	var questions = []dto.Question{}
-	query := `		
-		SELECT
+// questionObject is the json_build_object projection of a question row, it expects the aliases from questionJoins
+const questionObject = `
 			json_build_object(
 				'id', q.id::TEXT,
 				'text', q.text,
 				'code', q.code,
+				'code_language', q.code_language,
+				'code_blocks', q.code_blocks,
 				'variants', q.variants,
 				'answer', q.answer ,
 				'type', json_build_object(
@@ -53,14 +55,96 @@ func (q QuestionsStorage) GetQuestions(ctx context.Context, creatorUserID, subje
 					'user_name', mua.user_name
 				),
 				'moderated_at', q.moderated_at,
-				'created_at', q.created_at
-			)
-		FROM question q
+				'created_at', q.created_at,
+				'difficulty', q.difficulty,
+				'estimated_time', q.estimated_time_seconds,
+				'bloom_level', q.bloom_level,
+				'learning_objectives', q.learning_objectives,
+				'source_question_id', q.source_question_id::TEXT,
+				'deleted_at', q.deleted_at,
+				'version', q.version,
+				'attachments', (
+					SELECT coalesce(json_agg(json_build_object(
+						'id', a.id::TEXT,
+						'file_name', a.file_name,
+						'mime_type', a.mime_type,
+						'size', a.size
+					) ORDER BY a.id), '[]'::json)
+					FROM attachment a
+					WHERE a.question_id = q.id
+				),
+				'tags', (
+					SELECT coalesce(json_agg(t.name ORDER BY t.name), '[]'::json)
+					FROM question_tag qtg
+					JOIN tag t ON t.id = qtg.tag_id
+					WHERE qtg.question_id = q.id
+				)
+			)`
+
+const questionJoins = `
 		LEFT JOIN question_type qt on qt.id = q.type_id
 		LEFT JOIN question_status qs on qs.id = q.status_id
 		LEFT JOIN subject s on s.id = q.subject_id
 		LEFT JOIN user_account cua on cua.id = q.creator_user_id
-		LEFT JOIN user_account mua on mua.id = q.moderator_user_id
+		LEFT JOIN user_account mua on mua.id = q.moderator_user_id`
+
+// questionFilterConditions converts filter to sql conditions, -1 means "any".
+// String values are passed as query parameters, they are appended to args.
+func questionFilterConditions(filter dto.QuestionFilter, args []interface{}) ([]string, []interface{}) {
+	var conditions []string
+	if filter.Deleted {
+		conditions = append(conditions, "q.deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "q.deleted_at IS NULL")
+	}
+
+	if filter.CreatorUserID != -1 {
+		conditions = append(conditions, fmt.Sprintf("q.creator_user_id=%d", filter.CreatorUserID))
+	}
+
+	if filter.SubjectID != -1 && !filter.IncludeSubSubjects {
+		conditions = append(conditions, fmt.Sprintf("q.subject_id=%d", filter.SubjectID))
+	}
+
+	if filter.StatusID != -1 {
+		conditions = append(conditions, fmt.Sprintf("q.status_id=%d", filter.StatusID))
+	}
+
+	if filter.TypeID != -1 {
+		conditions = append(conditions, fmt.Sprintf("q.type_id=%d", filter.TypeID))
+	}
+
+	if filter.IncludeSubSubjects && filter.SubjectID != -1 {
+		conditions = append(conditions, fmt.Sprintf(
+			"q.subject_id IN (SELECT id FROM subject WHERE path <@ (SELECT path FROM subject WHERE id=%d))", filter.SubjectID))
+	}
+
+	if filter.MinDifficulty != -1 {
+		conditions = append(conditions, fmt.Sprintf("q.difficulty>=%d", filter.MinDifficulty))
+	}
+
+	if filter.MaxDifficulty != -1 {
+		conditions = append(conditions, fmt.Sprintf("q.difficulty<=%d", filter.MaxDifficulty))
+	}
+
+	if len(filter.BloomLevels) != 0 {
+		args = append(args, filter.BloomLevels)
+		conditions = append(conditions, fmt.Sprintf("q.bloom_level = ANY($%d::TEXT[])", len(args)))
+	}
+
+	if len(filter.Tags) != 0 {
+		args = append(args, filter.Tags)
+		conditions = append(conditions, taggedCondition("q.id", "question_tag", "question_id", len(args)))
+	}
+
+	return conditions, args
+}
+
+func (q QuestionsStorage) GetQuestions(ctx context.Context, filter dto.QuestionFilter) ([]dto.Question, error) {
+	var questions = []dto.Question{}
+	query := `
+		SELECT` + questionObject + `
+		FROM question q` + questionJoins + `
 		%s
 	`
 
@@ -70,18 +154,7 @@ func (q QuestionsStorage) GetQuestions(ctx context.Context, creatorUserID, subje
 	//	return questions, &storage_errors.StatementPSQLError{Err: err}
 	//}
 
-	var conditions []string
-	if creatorUserID != -1 {
-		conditions = append(conditions, fmt.Sprintf("q.creator_user_id=%d", creatorUserID))
-	}
-
-	if subjectID != -1 {
-		conditions = append(conditions, fmt.Sprintf("q.subject_id=%d", subjectID))
-	}
-
-	if statusID != -1 {
-		conditions = append(conditions, fmt.Sprintf("q.status_id=%d", statusID))
-	}
+	conditions, args := questionFilterConditions(filter, nil)
 
 	allConditions := ""
 	if len(conditions) != 0 {
@@ -89,7 +162,7 @@ func (q QuestionsStorage) GetQuestions(ctx context.Context, creatorUserID, subje
 	}
 	query = fmt.Sprintf(query, allConditions)
 
-	rows, err := q.conn.Query(ctx /*preparedStmt.Name*/, query)
+	rows, err := q.conn.Query(ctx /*preparedStmt.Name*/, query, args...)
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows): //it is not error
@@ -115,47 +188,225 @@ func (q QuestionsStorage) GetQuestions(ctx context.Context, creatorUserID, subje
 	return questions, nil
 }
 
-func (q QuestionsStorage) GetQuestionByID(ctx context.Context, questionID int64) (dto.Question, error) {
-	query := `		
+// headline markers are put around matches by ts_headline, they can not be confused with html of the question
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+// headlineHTML escapes the headline made by ts_headline and replaces markers of matches with <mark> tags
+func headlineHTML(headline string) string {
+	if headline == "" {
+		return ""
+	}
+
+	escaped := html.EscapeString(headline)
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(escaped)
+}
+
+// SearchQuestions does full-text search over question text and code (both russian and english configurations),
+// results are ordered by rank. Headlines are safe html: the text is escaped, matches are wrapped in <mark>.
+func (q QuestionsStorage) SearchQuestions(ctx context.Context, search string, filter dto.QuestionFilter, limit, offset int) ([]dto.QuestionSearchResult, error) {
+	var results = []dto.QuestionSearchResult{}
+	query := `
+		WITH search AS (
+			SELECT websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1) AS query
+		)
+		SELECT
+			json_build_object(
+				'question',` + questionObject + `,
+				'rank', ts_rank_cd(q.search_vector, search.query),
+				'headline', ts_headline('russian', coalesce(q.text, ''), search.query, $4),
+				'code_headline', CASE WHEN coalesce(q.code, '') = '' THEN NULL
+					ELSE ts_headline('english', q.code, search.query, $4) END
+			)
+		FROM question q
+		CROSS JOIN search` + questionJoins + `
+		WHERE %s
+		ORDER BY ts_rank_cd(q.search_vector, search.query) DESC, q.id
+		LIMIT $2 OFFSET $3
+	`
+
+	headlineOptions := "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxFragments=3, MaxWords=30, MinWords=10"
+
+	conditions, args := questionFilterConditions(filter, []interface{}{search, limit, offset, headlineOptions})
+	conditions = append([]string{"q.search_vector @@ search.query"}, conditions...)
+	query = fmt.Sprintf(query, strings.Join(conditions, " and "))
+
+	rows, err := q.conn.Query(ctx, query, args...)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows): //it is not error
+			return results, nil
+		default:
+			return results, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res string
+		if err := rows.Scan(&res); err != nil {
+			return results, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		var result dto.QuestionSearchResult
+		if err := json.Unmarshal([]byte(res), &result); err != nil {
+			return results, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		result.Headline = headlineHTML(result.Headline)
+		result.CodeHeadline = headlineHTML(result.CodeHeadline)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// FindSimilarQuestions returns questions from the same subject tree (same root subject) whose text trigram similarity
+// with text is not less than threshold
+func (q QuestionsStorage) FindSimilarQuestions(ctx context.Context, subjectID int64, text string, threshold float64, limit int) ([]dto.SimilarQuestion, error) {
+	var similar = []dto.SimilarQuestion{}
+	query := `
+		WITH root AS (
+			SELECT subpath(path, 0, 1) AS path FROM subject WHERE id = $1
+		)
 		SELECT
 			json_build_object(
 				'id', q.id::TEXT,
 				'text', q.text,
-				'code', q.code,
-				'variants', q.variants,
-				'answer', q.answer ,
-				'type', json_build_object(
-				    'id', qt.id::TEXT,
-				    'name', qt.name
-				),
-				'status', qs.name,
 				'subject_id', q.subject_id::TEXT,
 				'subject_name', s.name,
-				'creator', json_build_object(
-					'id', cua.id::TEXT,
-					'login', cua.login,
-					'user_name', cua.user_name
+				'similarity', similarity(q.text, $2)
+			)
+		FROM question q
+		JOIN subject s ON s.id = q.subject_id
+		JOIN root ON s.path <@ root.path
+		WHERE q.text % $2 AND q.deleted_at IS NULL
+		ORDER BY similarity(q.text, $2) DESC, q.id
+		LIMIT $3
+	`
+
+	tx, err := q.conn.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return similar, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	// the % operator uses the threshold, so trigram index can be used
+	if _, err = tx.Exec(ctx, "SELECT set_config('pg_trgm.similarity_threshold', $1, true)", fmt.Sprint(threshold)); err != nil {
+		return similar, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	rows, err := tx.Query(ctx, query, subjectID, text, limit)
+	if err != nil {
+		return similar, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res string
+		if err := rows.Scan(&res); err != nil {
+			return similar, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		var result dto.SimilarQuestion
+		if err := json.Unmarshal([]byte(res), &result); err != nil {
+			return similar, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		similar = append(similar, result)
+	}
+	if err = rows.Err(); err != nil {
+		return similar, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return similar, nil
+}
+
+// GetSimilarQuestionPairs returns all pairs of questions from the same subject tree with text similarity
+// not less than threshold. If subjectID is not -1 only questions of the subject subtree are compared.
+func (q QuestionsStorage) GetSimilarQuestionPairs(ctx context.Context, subjectID int64, threshold float64) ([]dto.SimilarQuestionPair, error) {
+	var pairs = []dto.SimilarQuestionPair{}
+	query := `
+		SELECT
+			json_build_object(
+				'first', json_build_object(
+					'id', a.id::TEXT,
+					'text', a.text,
+					'subject_id', a.subject_id::TEXT,
+					'subject_name', sa.name
 				),
-				'moderator', json_build_object(
-					'id', mua.id::TEXT,
-					'login', mua.login,
-					'user_name', mua.user_name
+				'second', json_build_object(
+					'id', b.id::TEXT,
+					'text', b.text,
+					'subject_id', b.subject_id::TEXT,
+					'subject_name', sb.name
 				),
-				'moderated_at', q.moderated_at,
-				'created_at', q.created_at
+				'similarity', similarity(a.text, b.text)
 			)
-		FROM question q
-		LEFT JOIN question_type qt on qt.id = q.type_id
-		LEFT JOIN question_status qs on qs.id = q.status_id
-		LEFT JOIN subject s on s.id = q.subject_id
-		LEFT JOIN user_account cua on cua.id = q.creator_user_id
-		LEFT JOIN user_account mua on mua.id = q.moderator_user_id
-		WHERE qt.id = $1
+		FROM question a
+		JOIN subject sa ON sa.id = a.subject_id
+		JOIN question b ON b.id > a.id AND a.text %% b.text AND b.deleted_at IS NULL
+		JOIN subject sb ON sb.id = b.subject_id AND subpath(sb.path, 0, 1) = subpath(sa.path, 0, 1)
+		WHERE a.deleted_at IS NULL
+		%s
+		ORDER BY similarity(a.text, b.text) DESC
+	`
+
+	allConditions := ""
+	if subjectID != -1 {
+		allConditions = fmt.Sprintf(`and sa.path <@ (SELECT path FROM subject WHERE id=%d)
+			and sb.path <@ (SELECT path FROM subject WHERE id=%d)`, subjectID, subjectID)
+	}
+	query = fmt.Sprintf(query, allConditions)
+
+	tx, err := q.conn.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return pairs, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "SELECT set_config('pg_trgm.similarity_threshold', $1, true)", fmt.Sprint(threshold)); err != nil {
+		return pairs, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return pairs, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res string
+		if err := rows.Scan(&res); err != nil {
+			return pairs, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		var result dto.SimilarQuestionPair
+		if err := json.Unmarshal([]byte(res), &result); err != nil {
+			return pairs, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		pairs = append(pairs, result)
+	}
+	if err = rows.Err(); err != nil {
+		return pairs, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return pairs, nil
+}
+
+func (q QuestionsStorage) GetQuestionByID(ctx context.Context, questionID int64) (dto.Question, error) {
+	query := `
+		SELECT` + questionObject + `
+		FROM question q` + questionJoins + `
+		WHERE q.id = $1 AND q.deleted_at IS NULL
 	`
 
 	var question = dto.Question{}
 	if err := q.conn.QueryRow(ctx, query, questionID).Scan(&question); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsCaseNotFound(pgErr.Code) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return question, &storage_errors.NotFoundError{Err: dto.ErrQuestionNotFound}
 		} else {
 			return question, &storage_errors.ExecutionPSQLError{Err: err}
@@ -164,6 +415,61 @@ func (q QuestionsStorage) GetQuestionByID(ctx context.Context, questionID int64)
 	return question, nil
 }
 
+// GetQuestionOwnership returns creator, subject and status of the question, questions in the trash are found too
+func (q QuestionsStorage) GetQuestionOwnership(ctx context.Context, questionID int64) (dto.Ownership, error) {
+	query := `
+		SELECT coalesce(q.creator_user_id, 0), coalesce(q.subject_id, 0), coalesce(qs.name, ''), q.deleted_at IS NOT NULL
+		FROM question q
+		LEFT JOIN question_status qs ON qs.id = q.status_id
+		WHERE q.id = $1
+	`
+
+	var ownership dto.Ownership
+	if err := q.conn.QueryRow(ctx, query, questionID).Scan(
+		&ownership.CreatorUserID,
+		&ownership.SubjectID,
+		&ownership.StatusName,
+		&ownership.Deleted,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ownership, &storage_errors.NotFoundError{Err: dto.ErrQuestionNotFound}
+		}
+		return ownership, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return ownership, nil
+}
+
+// GetMissingQuestionIDs returns ids which do not belong to questions out of the trash
+func (q QuestionsStorage) GetMissingQuestionIDs(ctx context.Context, questionIDs []int64) ([]int64, error) {
+	query := `
+		SELECT u.id
+		FROM unnest($1::BIGINT[]) AS u(id)
+		WHERE NOT EXISTS (SELECT 1 FROM question q WHERE q.id = u.id AND q.deleted_at IS NULL)
+		ORDER BY u.id
+	`
+
+	var missing = []int64{}
+	rows, err := q.conn.Query(ctx, query, questionIDs)
+	if err != nil {
+		return missing, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return missing, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+		missing = append(missing, id)
+	}
+	if err = rows.Err(); err != nil {
+		return missing, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return missing, nil
+}
+
 func (q QuestionsStorage) GetQuestionTypes(ctx context.Context) ([]dto.QuestionType, error) {
 	var types = []dto.QuestionType{}
 	query := `		
@@ -243,6 +549,37 @@ func (q QuestionsStorage) GetQuestionStatuses(ctx context.Context) ([]dto.Questi
 	return statuses, nil
 }
 
+// questionMetadataArgs converts optional pedagogical metadata to nullable query arguments
+func questionMetadataArgs(question dto.InputQuestion) (sql.NullInt32, sql.NullInt32, sql.NullString) {
+	var difficulty sql.NullInt32
+	difficulty.Int32 = int32(question.Difficulty)
+	difficulty.Valid = question.Difficulty > 0
+
+	var estimatedTime sql.NullInt32
+	estimatedTime.Int32 = int32(question.EstimatedTime)
+	estimatedTime.Valid = question.EstimatedTime > 0
+
+	var bloomLevel sql.NullString
+	bloomLevel.String = string(question.BloomLevel)
+	bloomLevel.Valid = question.BloomLevel != ""
+
+	return difficulty, estimatedTime, bloomLevel
+}
+
+// questionCodeArgs converts code language and code blocks to query arguments, code blocks are never NULL
+func questionCodeArgs(question dto.InputQuestion) (sql.NullString, []dto.CodeBlock) {
+	var codeLanguage sql.NullString
+	codeLanguage.String = question.CodeLanguage
+	codeLanguage.Valid = question.CodeLanguage != ""
+
+	codeBlocks := question.CodeBlocks
+	if codeBlocks == nil {
+		codeBlocks = []dto.CodeBlock{}
+	}
+
+	return codeLanguage, codeBlocks
+}
+
 // TODO return id
 func (q QuestionsStorage) AddQuestion(ctx context.Context, question dto.InputQuestion) (int64, error) {
 	var questionID int64 = -1
@@ -257,7 +594,14 @@ func (q QuestionsStorage) AddQuestion(ctx context.Context, question dto.InputQue
 			subject_id,				-- 7
 			creator_user_id,		-- 8
 			moderator_user_id,		-- 9
-			moderated_at 			-- 10
+			moderated_at, 			-- 10
+			difficulty,				-- 11
+			estimated_time_seconds,	-- 12
+			bloom_level,			-- 13
+			learning_objectives,	-- 14
+			code_language,			-- 15
+			code_blocks,			-- 16
+			source_question_id		-- 17
 		)
 		values (
 		    $1,
@@ -269,7 +613,14 @@ func (q QuestionsStorage) AddQuestion(ctx context.Context, question dto.InputQue
 			$7,
 			$8,
 			$9,
-			$10
+			$10,
+			$11,
+			$12,
+			$13,
+			coalesce($14, '{}'::TEXT[]),
+			$15,
+			$16,
+			$17
 		)
 		RETURNING id;
 	`
@@ -297,6 +648,13 @@ func (q QuestionsStorage) AddQuestion(ctx context.Context, question dto.InputQue
 	moderatedAt.String = question.ModeratedAt
 	moderatedAt.Valid = question.ModeratedAt != ""
 
+	difficulty, estimatedTime, bloomLevel := questionMetadataArgs(question)
+	codeLanguage, codeBlocks := questionCodeArgs(question)
+
+	var sourceQuestionID sql.NullInt64
+	sourceQuestionID.Int64 = question.SourceQuestionID
+	sourceQuestionID.Valid = question.SourceQuestionID > 0
+
 	// Порядок параметров должен соответствовать порядку в запросе
 	args := []interface{}{
 		question.Text,
@@ -309,6 +667,13 @@ func (q QuestionsStorage) AddQuestion(ctx context.Context, question dto.InputQue
 		question.CreatorUserID,
 		moderatorUserID,
 		moderatedAt,
+		difficulty,
+		estimatedTime,
+		bloomLevel,
+		question.LearningObjectives,
+		codeLanguage,
+		codeBlocks,
+		sourceQuestionID,
 	}
 
 	if err := tx.QueryRow(ctx /*preparedStmt.Name*/, query, args...).Scan(&questionID); err != nil {
@@ -319,6 +684,22 @@ func (q QuestionsStorage) AddQuestion(ctx context.Context, question dto.InputQue
 		}
 	}
 
+	if err = setTags(ctx, tx, "question_tag", "question_id", questionID, question.Tags); err != nil {
+		return -1, err
+	}
+
+	if err = setQuestionAttachments(ctx, tx, questionID, question.AttachmentIDs); err != nil {
+		return -1, err
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionCreate, dto.AuditEntityQuestion, questionID, nil); err != nil {
+		return -1, err
+	}
+
+	if err = enqueueEvents(ctx, tx, question.Events); err != nil {
+		return -1, err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return questionID, &storage_errors.ExecutionPSQLError{Err: err}
 	}
@@ -331,15 +712,22 @@ func (q QuestionsStorage) UpdateQuestionByID(ctx context.Context, ID int64, ques
 		UPDATE 
 		    question
 		SET
+			version = version + 1,
 			text=$1,
 			code=$2,
 			variants=$3,
 			answer=$4,
 			type_id=$5,
 			status_id=(SELECT id FROM question_status WHERE name=$6),
-			subject_id=$7
+			subject_id=$7,
+			difficulty=$8,
+			estimated_time_seconds=$9,
+			bloom_level=$10,
+			learning_objectives=coalesce($11, '{}'::TEXT[]),
+			code_language=$12,
+			code_blocks=$13
 		WHERE
-		    id = $8
+		    id = $14 AND deleted_at IS NULL
 		`
 
 	//preparedStmt, err := q.conn.Prepare(ctx, "UpdateQuestion", query)
@@ -356,6 +744,18 @@ func (q QuestionsStorage) UpdateQuestionByID(ctx context.Context, ID int64, ques
 	}
 	defer tx.Rollback(ctx)
 
+	if err = checkVersion(ctx, tx, "question", ID, dto.ErrQuestionNotFound); err != nil {
+		return err
+	}
+
+	before, err := snapshot(ctx, tx, dto.AuditEntityQuestion, ID)
+	if err != nil {
+		return err
+	}
+
+	difficulty, estimatedTime, bloomLevel := questionMetadataArgs(question)
+	codeLanguage, codeBlocks := questionCodeArgs(question)
+
 	// Порядок параметров должен соответствовать порядку в запросе
 	args := []interface{}{
 		question.Text,
@@ -365,18 +765,44 @@ func (q QuestionsStorage) UpdateQuestionByID(ctx context.Context, ID int64, ques
 		question.TypeID,
 		question.StatusName,
 		question.SubjectID,
+		difficulty,
+		estimatedTime,
+		bloomLevel,
+		question.LearningObjectives,
+		codeLanguage,
+		codeBlocks,
 
 		ID,
 	}
 
-	if _, err = tx.Exec(ctx /*preparedStmt.Name*/, query, args...); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsCaseNotFound(pgErr.Code) {
+	result, err := tx.Exec(ctx /*preparedStmt.Name*/, query, args...)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.ForeignKeyViolation {
 			return &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
 		} else {
 			return &storage_errors.ExecutionPSQLError{Err: err}
 		}
 	}
 
+	if result.RowsAffected() == 0 {
+		return &storage_errors.NotFoundError{Err: dto.ErrQuestionNotFound}
+	}
+
+	// nil tags means "do not change"
+	if question.Tags != nil {
+		if err = setTags(ctx, tx, "question_tag", "question_id", ID, question.Tags); err != nil {
+			return err
+		}
+	}
+
+	if err = setQuestionAttachments(ctx, tx, ID, question.AttachmentIDs); err != nil {
+		return err
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionUpdate, dto.AuditEntityQuestion, ID, before); err != nil {
+		return err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return &storage_errors.ExecutionPSQLError{Err: err}
 	}
@@ -389,9 +815,10 @@ func (q QuestionsStorage) UpdateQuestionStatus(ctx context.Context, ID int64, st
 		UPDATE 
 		    question
 		SET
+			version = version + 1,
 			status_id=(SELECT id FROM question_status WHERE name=$1)
 		WHERE
-		    id = $2
+		    id = $2 AND deleted_at IS NULL
 		`
 
 	//preparedStmt, err := q.conn.Prepare(ctx, "UpdateQuestionStatus", query)
@@ -408,18 +835,32 @@ func (q QuestionsStorage) UpdateQuestionStatus(ctx context.Context, ID int64, st
 	}
 	defer tx.Rollback(ctx)
 
+	if err = checkVersion(ctx, tx, "question", ID, dto.ErrQuestionNotFound); err != nil {
+		return err
+	}
+
+	before, err := snapshot(ctx, tx, dto.AuditEntityQuestion, ID)
+	if err != nil {
+		return err
+	}
+
 	// Порядок параметров должен соответствовать порядку в запросе
 	args := []interface{}{
 		status,
 		ID,
 	}
 
-	if _, err = tx.Exec(ctx /*preparedStmt.Name*/, query, args...); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsCaseNotFound(pgErr.Code) {
-			return &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
-		} else {
-			return &storage_errors.ExecutionPSQLError{Err: err}
-		}
+	result, err := tx.Exec(ctx /*preparedStmt.Name*/, query, args...)
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if result.RowsAffected() == 0 {
+		return &storage_errors.NotFoundError{Err: dto.ErrQuestionNotFound}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionModerate, dto.AuditEntityQuestion, ID, before); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(ctx); err != nil {
@@ -429,12 +870,16 @@ func (q QuestionsStorage) UpdateQuestionStatus(ctx context.Context, ID int64, st
 	return nil
 }
 
+// DeleteQuestion moves the question to the trash, it is removed by PurgeQuestions later
 func (q QuestionsStorage) DeleteQuestion(ctx context.Context, ID int64) error {
 	query := `		
-		DELETE FROM 
+		UPDATE
 		    question
+		SET
+		    version = version + 1,
+		    deleted_at = now()
 		WHERE
-		    id = $1
+		    id = $1 AND deleted_at IS NULL
 		`
 
 	//preparedStmt, err := q.conn.Prepare(ctx, "DeleteQuestion", query)
@@ -451,17 +896,94 @@ func (q QuestionsStorage) DeleteQuestion(ctx context.Context, ID int64) error {
 	}
 	defer tx.Rollback(ctx)
 
+	if err = checkVersion(ctx, tx, "question", ID, dto.ErrQuestionNotFound); err != nil {
+		return err
+	}
+
+	before, err := snapshot(ctx, tx, dto.AuditEntityQuestion, ID)
+	if err != nil {
+		return err
+	}
+
 	// Порядок параметров должен соответствовать порядку в запросе
 	args := []interface{}{
 		ID,
 	}
 
-	if _, err = tx.Exec(ctx /*preparedStmt.Name*/, query, args...); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsCaseNotFound(pgErr.Code) {
-			return &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
-		} else {
-			return &storage_errors.ExecutionPSQLError{Err: err}
+	result, err := tx.Exec(ctx /*preparedStmt.Name*/, query, args...)
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if result.RowsAffected() == 0 {
+		return &storage_errors.NotFoundError{Err: dto.ErrQuestionNotFound}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionDelete, dto.AuditEntityQuestion, ID, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// RestoreQuestion takes the question out of the trash, the question of a subject in the trash can not be restored
+func (q QuestionsStorage) RestoreQuestion(ctx context.Context, ID int64) error {
+	checkQuery := `
+		SELECT s.deleted_at IS NOT NULL
+		FROM question q
+		JOIN subject s ON s.id = q.subject_id
+		WHERE q.id = $1 AND q.deleted_at IS NOT NULL
+	`
+	restoreQuery := `
+		UPDATE
+		    question
+		SET
+		    version = version + 1,
+		    deleted_at = NULL
+		WHERE
+		    id = $1
+	`
+
+	tx, err := q.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	if err = checkDeletedVersion(ctx, tx, "question", ID, dto.ErrQuestionNotFound); err != nil {
+		return err
+	}
+
+	var subjectDeleted bool
+	if err = tx.QueryRow(ctx, checkQuery, ID).Scan(&subjectDeleted); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &storage_errors.NotFoundError{Err: dto.ErrQuestionNotFound}
 		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if subjectDeleted {
+		return dto.ErrParentInTrash
+	}
+
+	before, err := snapshot(ctx, tx, dto.AuditEntityQuestion, ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, restoreQuery, ID); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionRestore, dto.AuditEntityQuestion, ID, before); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(ctx); err != nil {
@@ -470,3 +992,73 @@ func (q QuestionsStorage) DeleteQuestion(ctx context.Context, ID int64) error {
 
 	return nil
 }
+
+// PurgeQuestions removes questions which are in the trash longer than retention.
+// Attachments of removed questions are returned, their blobs have to be deleted by the caller.
+func (q QuestionsStorage) PurgeQuestions(ctx context.Context, retention time.Duration) ([]dto.Attachment, error) {
+	attachmentsQuery := `
+		SELECT` + attachmentObject + `, a.storage_key
+		FROM attachment a
+		JOIN question q ON q.id = a.question_id
+		WHERE q.deleted_at < now() - $1::INTERVAL
+	`
+	removeQuizzesQuestionsQuery := `
+		DELETE FROM
+		    quizzes_questions
+		WHERE question_id IN (SELECT id FROM question WHERE deleted_at < now() - $1::INTERVAL)
+	`
+	removeQuestionsQuery := `
+		DELETE FROM
+		    question t
+		WHERE t.deleted_at < now() - $1::INTERVAL
+	`
+
+	var attachments = []dto.Attachment{}
+
+	tx, err := q.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return attachments, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, attachmentsQuery, retention)
+	if err != nil {
+		return attachments, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	for rows.Next() {
+		var res string
+		var result dto.Attachment
+		if err := rows.Scan(&res, &result.StorageKey); err != nil {
+			rows.Close()
+			return attachments, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		if err := json.Unmarshal([]byte(res), &result); err != nil {
+			rows.Close()
+			return attachments, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		attachments = append(attachments, result)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return attachments, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if _, err = tx.Exec(ctx, removeQuizzesQuestionsQuery, retention); err != nil {
+		return attachments, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("Exec failed: %v\n", err)}
+	}
+
+	if _, err = removeAudited(ctx, tx, dto.AuditActionPurge, dto.AuditEntityQuestion, removeQuestionsQuery, retention); err != nil {
+		return attachments, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return attachments, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return attachments, nil
+}
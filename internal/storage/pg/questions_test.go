@@ -0,0 +1,23 @@
+package pg
+
+import "testing"
+
+func TestHeadlineHTML(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"", ""},
+		{"plain text", "plain text"},
+		{"find \x02word\x03 here", "find <mark>word</mark> here"},
+		{"<script>\x02alert\x03(1)</script>", "&lt;script&gt;<mark>alert</mark>(1)&lt;/script&gt;"},
+		{"if a < b && \x02c\x03 > \"d\"", "if a &lt; b &amp;&amp; <mark>c</mark> &gt; &#34;d&#34;"},
+		{"<mark>fake</mark>", "&lt;mark&gt;fake&lt;/mark&gt;"},
+	}
+
+	for _, tt := range tests {
+		if got := headlineHTML(tt.headline); got != tt.want {
+			t.Errorf("headlineHTML(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"quiz_backend_core/internal/dto"
 	storage_errors "quiz_backend_core/internal/storage/errors"
 	"strings"
+	"time"
 )
 
 func NewQuizzesStorage(conn *pgxpool.Pool) *QuizzesStorage {
@@ -24,7 +25,24 @@ type QuizzesStorage struct {
 	conn *pgxpool.Pool
 }
 
-func (q QuizzesStorage) GetQuizzes(ctx context.Context, creatorUserID int64) ([]dto.Quiz, error) {
+const quizTags = `
+		    'tags', (
+				SELECT coalesce(json_agg(t.name ORDER BY t.name), '[]'::json)
+				FROM quiz_tag qzt
+				JOIN tag t ON t.id = qzt.tag_id
+				WHERE qzt.quiz_id = q.id
+		    )`
+
+func (q QuizzesStorage) GetQuizzes(ctx context.Context, creatorUserID int64, tags []string) ([]dto.Quiz, error) {
+	return q.getQuizzes(ctx, creatorUserID, tags, false)
+}
+
+// GetDeletedQuizzes returns quizzes from the trash, -1 means quizzes of any creator
+func (q QuizzesStorage) GetDeletedQuizzes(ctx context.Context, creatorUserID int64) ([]dto.Quiz, error) {
+	return q.getQuizzes(ctx, creatorUserID, nil, true)
+}
+
+func (q QuizzesStorage) getQuizzes(ctx context.Context, creatorUserID int64, tags []string, deleted bool) ([]dto.Quiz, error) {
 	query := `
         SELECT json_build_object(
 			'id', q.id::TEXT,
@@ -37,9 +55,12 @@ func (q QuizzesStorage) GetQuizzes(ctx context.Context, creatorUserID int64) ([]
 			),
 			'created_at', q.created_at,
 			'updated_at', q.updated_at,
+			'source_quiz_id', q.source_quiz_id::TEXT,
+			'deleted_at', q.deleted_at,
+			'version', q.version,
 		    'question_ids', (
 				SELECT json_agg(question_id::TEXT) FROM quizzes_questions qq WHERE qq.quiz_id = q.id
-		    )
+		    ),` + quizTags + `
 		)
 		FROM quiz q
 		LEFT JOIN user_account cua on cua.id = q.creator_user_id
@@ -48,10 +69,22 @@ func (q QuizzesStorage) GetQuizzes(ctx context.Context, creatorUserID int64) ([]
 
 	//conditions
 	var conditions []string
+	if deleted {
+		conditions = append(conditions, "q.deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "q.deleted_at IS NULL")
+	}
+
 	if creatorUserID != -1 {
 		conditions = append(conditions, fmt.Sprintf("q.creator_user_id=%d", creatorUserID))
 	}
 
+	var args []interface{}
+	if len(tags) != 0 {
+		args = append(args, tags)
+		conditions = append(conditions, taggedCondition("q.id", "quiz_tag", "quiz_id", len(args)))
+	}
+
 	allConditions := ""
 	if len(conditions) != 0 {
 		allConditions = fmt.Sprintf("WHERE %s", strings.Join(conditions, " and "))
@@ -60,7 +93,7 @@ func (q QuizzesStorage) GetQuizzes(ctx context.Context, creatorUserID int64) ([]
 
 	//do request
 	var quizzes []dto.Quiz
-	rows, err := q.conn.Query(ctx, query)
+	rows, err := q.conn.Query(ctx, query, args...)
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows): //it is not error
@@ -87,46 +120,37 @@ func (q QuizzesStorage) GetQuizzes(ctx context.Context, creatorUserID int64) ([]
 	return quizzes, nil
 }
 
+// GetQuestionsByQuizID returns questions of the quiz, the quiz in the trash is not found
 func (q QuizzesStorage) GetQuestionsByQuizID(ctx context.Context, quizID int64) ([]dto.Question, error) {
+	quizQuery := `
+		SELECT EXISTS (SELECT 1 FROM quiz WHERE id = $1 AND deleted_at IS NULL)
+	`
 	query := `
-		SELECT
-			json_build_object(
-				'id', q.id::TEXT,
-				'text', q.text,
-				'code', q.code,
-				'variants', q.variants,
-				'answer', q.answer ,
-				'type', json_build_object(
-					'id', qt.id::TEXT,
-					'name', qt.name
-				),
-				'status', qs.name,
-				'subject_id', q.subject_id::TEXT,
-				'subject_name', s.name,
-				'creator', json_build_object(
-					'id', cua.id::TEXT,
-					'login', cua.login,
-					'user_name', cua.user_name
-				),
-				'moderator', json_build_object(
-					'id', mua.id::TEXT,
-					'login', mua.login,
-					'user_name', mua.user_name
-				),
-				'moderated_at', q.moderated_at,
-				'created_at', q.created_at
-			)
+		SELECT` + questionObject + `
 		FROM quizzes_questions qq
-		JOIN question q on qq.question_id = q.id
-		LEFT JOIN question_type qt on qt.id = q.type_id
-		LEFT JOIN question_status qs on qs.id = q.status_id
-		LEFT JOIN subject s on s.id = q.subject_id
-		LEFT JOIN user_account cua on cua.id = q.creator_user_id
-		LEFT JOIN user_account mua on mua.id = q.moderator_user_id
-		WHERE qq.quiz_id = $1
+		JOIN question q on qq.question_id = q.id` + questionJoins + `
+		WHERE qq.quiz_id = $1 AND q.deleted_at IS NULL
 	`
 	var questions = []dto.Question{}
-	rows, err := q.conn.Query(ctx, query, quizID)
+
+	tx, err := q.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return questions, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err = tx.QueryRow(ctx, quizQuery, quizID).Scan(&exists); err != nil {
+		return questions, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	if !exists {
+		return questions, &storage_errors.NotFoundError{Err: dto.ErrQuizNotFound}
+	}
+
+	rows, err := tx.Query(ctx, query, quizID)
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows): //it is not error
@@ -152,6 +176,23 @@ func (q QuizzesStorage) GetQuestionsByQuizID(ctx context.Context, quizID int64)
 	return questions, nil
 }
 
+// GetQuizOwnership returns creator of the quiz, quizzes in the trash are found too
+func (q QuizzesStorage) GetQuizOwnership(ctx context.Context, quizID int64) (dto.Ownership, error) {
+	query := `
+		SELECT coalesce(creator_user_id, 0), deleted_at IS NOT NULL FROM quiz WHERE id = $1
+	`
+
+	var ownership dto.Ownership
+	if err := q.conn.QueryRow(ctx, query, quizID).Scan(&ownership.CreatorUserID, &ownership.Deleted); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ownership, &storage_errors.NotFoundError{Err: dto.ErrQuizNotFound}
+		}
+		return ownership, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return ownership, nil
+}
+
 func (q QuizzesStorage) GetQuizByID(ctx context.Context, quizID int64) (dto.Quiz, error) {
 	query := `
         SELECT json_build_object(
@@ -165,21 +206,24 @@ func (q QuizzesStorage) GetQuizByID(ctx context.Context, quizID int64) (dto.Quiz
 			),
 			'created_at', q.created_at,
 			'updated_at', q.updated_at,
+			'source_quiz_id', q.source_quiz_id::TEXT,
+			'deleted_at', q.deleted_at,
+			'version', q.version,
 		    'question_ids', (
 				SELECT json_agg(question_id) FROM quizzes_questions qq WHERE qq.quiz_id = q.id
-		    )
+		    ),` + quizTags + `
 		)
 		FROM quiz q
 		LEFT JOIN user_account cua on cua.id = q.creator_user_id
-	    WHERE q.id = $1
+	    WHERE q.id = $1 AND q.deleted_at IS NULL
 	`
 
 	var quiz dto.Quiz
 	err := q.conn.QueryRow(ctx, query, quizID).Scan(&quiz)
 	if err != nil {
 		switch {
-		case errors.Is(err, pgx.ErrNoRows): //it is not error
-			return quiz, nil
+		case errors.Is(err, pgx.ErrNoRows):
+			return quiz, &storage_errors.NotFoundError{Err: dto.ErrQuizNotFound}
 		default:
 			return quiz, &storage_errors.ExecutionPSQLError{Err: err}
 		}
@@ -229,7 +273,7 @@ func (q QuizzesStorage) AddQuiz(ctx context.Context, quiz dto.InputQuiz) (int64,
 	}
 
 	if quizID == -1 {
-		return quizID, &storage_errors.ExecutionPSQLError{Err: errors.New("quiz id does not exist")}
+		return quizID, &storage_errors.NotFoundError{Err: dto.ErrQuizNotFound}
 	}
 
 	//second request
@@ -252,6 +296,14 @@ func (q QuizzesStorage) AddQuiz(ctx context.Context, quiz dto.InputQuiz) (int64,
 		return -1, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("question to add: %v, question added: %v \n", len(rows), copyCount)}
 	}
 
+	if err = setTags(ctx, tx, "quiz_tag", "quiz_id", quizID, quiz.Tags); err != nil {
+		return -1, err
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionCreate, dto.AuditEntityQuiz, quizID, nil); err != nil {
+		return -1, err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return -1, &storage_errors.ExecutionPSQLError{Err: err}
 	}
@@ -259,17 +311,80 @@ func (q QuizzesStorage) AddQuiz(ctx context.Context, quiz dto.InputQuiz) (int64,
 	return quizID, nil
 }
 
-func (q QuizzesStorage) DeleteQuizByID(ctx context.Context, quizID int64) error {
-	removeQuizzesQuestionsQuery := `
-		DELETE FROM 
-		   quizzes_questions
-	    WHERE quiz_id = $1	
+// CloneQuiz copies the quiz with its questions links and tags, the copy belongs to creatorUserID.
+// Empty name means the name of the source quiz.
+func (q QuizzesStorage) CloneQuiz(ctx context.Context, sourceQuizID int64, creatorUserID int64, name string) (int64, error) {
+	cloneQuizQuery := `
+		INSERT INTO
+		    quiz (
+		    	name,
+		     	description,
+		     	creator_user_id,
+		     	source_quiz_id
+		    )
+		SELECT coalesce(nullif($3, ''), name), description, $2, id
+		FROM quiz
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id
+	`
+	cloneQuestionsQuery := `
+		INSERT INTO quizzes_questions (quiz_id, question_id)
+		SELECT $2, question_id FROM quizzes_questions WHERE quiz_id = $1
 	`
+	cloneTagsQuery := `
+		INSERT INTO quiz_tag (quiz_id, tag_id)
+		SELECT $2, tag_id FROM quiz_tag WHERE quiz_id = $1
+	`
+
+	tx, err := q.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return -1, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	var quizID int64 = -1
+	if err = tx.QueryRow(ctx, cloneQuizQuery, sourceQuizID, creatorUserID, name).Scan(&quizID); err != nil {
+		switch pgErr, ok := err.(*pgconn.PgError); {
+		case errors.Is(err, pgx.ErrNoRows):
+			return -1, &storage_errors.NotFoundError{Err: dto.ErrQuizNotFound}
+		case ok && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code):
+			return -1, &storage_errors.AlreadyExistsError{Err: pgErr}
+		default:
+			return -1, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+
+	if _, err = tx.Exec(ctx, cloneQuestionsQuery, sourceQuizID, quizID); err != nil {
+		return -1, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("clone quiz questions failed: %v\n", err)}
+	}
+
+	if _, err = tx.Exec(ctx, cloneTagsQuery, sourceQuizID, quizID); err != nil {
+		return -1, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("clone quiz tags failed: %v\n", err)}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionCreate, dto.AuditEntityQuiz, quizID, nil); err != nil {
+		return -1, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return -1, &storage_errors.ExecutionPSQLError{Err: err}
+	}
 
+	return quizID, nil
+}
+
+// DeleteQuizByID moves the quiz to the trash, it is removed by PurgeQuizzes later
+func (q QuizzesStorage) DeleteQuizByID(ctx context.Context, quizID int64) error {
 	removeQuizQuery := `
-		DELETE FROM 
+		UPDATE
 		   quiz
-	    WHERE id = $1	
+		SET
+		   version = version + 1,
+		   deleted_at = now()
+	    WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	//transaction
@@ -282,19 +397,119 @@ func (q QuizzesStorage) DeleteQuizByID(ctx context.Context, quizID int64) error
 	}
 	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, removeQuizzesQuestionsQuery, quizID)
+	if err = checkVersion(ctx, tx, "quiz", quizID, dto.ErrQuizNotFound); err != nil {
+		return err
+	}
+
+	before, err := snapshot(ctx, tx, dto.AuditEntityQuiz, quizID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(ctx, removeQuizQuery, quizID)
 	if err != nil {
 		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("Exec failed: %v\n", err)}
 	}
 
-	_, err = tx.Exec(ctx, removeQuizQuery, quizID)
+	if result.RowsAffected() == 0 {
+		return &storage_errors.NotFoundError{Err: dto.ErrQuizNotFound}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionDelete, dto.AuditEntityQuiz, quizID, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// RestoreQuiz takes the quiz out of the trash
+func (q QuizzesStorage) RestoreQuiz(ctx context.Context, quizID int64) error {
+	query := `
+		UPDATE
+		   quiz
+		SET
+		   version = version + 1,
+		   deleted_at = NULL
+	    WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	tx, err := q.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	if err = checkDeletedVersion(ctx, tx, "quiz", quizID, dto.ErrQuizNotFound); err != nil {
+		return err
+	}
+
+	before, err := snapshot(ctx, tx, dto.AuditEntityQuiz, quizID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(ctx, query, quizID)
 	if err != nil {
 		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("Exec failed: %v\n", err)}
 	}
 
+	if result.RowsAffected() == 0 {
+		return &storage_errors.NotFoundError{Err: dto.ErrQuizNotFound}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionRestore, dto.AuditEntityQuiz, quizID, before); err != nil {
+		return err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return &storage_errors.ExecutionPSQLError{Err: err}
 	}
 
 	return nil
 }
+
+// PurgeQuizzes removes quizzes which are in the trash longer than retention, returns number of removed quizzes
+func (q QuizzesStorage) PurgeQuizzes(ctx context.Context, retention time.Duration) (int64, error) {
+	removeQuizzesQuestionsQuery := `
+		DELETE FROM
+		   quizzes_questions
+	    WHERE quiz_id IN (SELECT id FROM quiz WHERE deleted_at < now() - $1::INTERVAL)
+	`
+
+	removeQuizzesQuery := `
+		DELETE FROM
+		   quiz t
+	    WHERE t.deleted_at < now() - $1::INTERVAL
+	`
+
+	tx, err := q.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return 0, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, removeQuizzesQuestionsQuery, retention); err != nil {
+		return 0, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("Exec failed: %v\n", err)}
+	}
+
+	removed, err := removeAudited(ctx, tx, dto.AuditActionPurge, dto.AuditEntityQuiz, removeQuizzesQuery, retention)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,431 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/etag"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+)
+
+// Integration tests run against the database from TEST_DATABASE_DSN with all migrations applied,
+// they are skipped if it is not set:
+//
+//	TEST_DATABASE_DSN="host=localhost port=5432 user=quiz password=pgpassword dbname=quiz_test sslmode=disable" go test ./internal/storage/pg/
+
+// missingID is not taken by any row, it fits INTEGER and BIGINT ids
+const missingID int64 = math.MaxInt32
+
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("pgxpool.New: %v", err)
+	}
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
+		t.Fatalf("ping: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// assertNotFound checks that err is storage NotFoundError of the want entity
+func assertNotFound(t *testing.T, err error, want error) {
+	t.Helper()
+
+	var notFound *storage_errors.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("want NotFoundError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, want) {
+		t.Errorf("want %v, got %v", want, err)
+	}
+	if !errors.Is(err, dto.KindNotFound) {
+		t.Errorf("error %v is not of kind %v", err, dto.KindNotFound)
+	}
+}
+
+func TestMissingRowsAreNotFound(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+
+	questions := NewQuestionsStorage(pool)
+	quizzes := NewQuizzesStorage(pool)
+	subjects := NewSubjectsStorage(pool)
+	attachments := NewAttachmentsStorage(pool)
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{
+			name: "GetQuestionByID",
+			call: func() error {
+				_, err := questions.GetQuestionByID(ctx, missingID)
+				return err
+			},
+			want: dto.ErrQuestionNotFound,
+		},
+		{
+			// the subject exists check is not reached, zero updated rows are reported
+			name: "UpdateQuestionByID",
+			call: func() error {
+				return questions.UpdateQuestionByID(ctx, missingID, dto.InputQuestion{
+					Text:       "missing",
+					StatusName: dto.QuestionStatusNameCreated,
+				})
+			},
+			want: dto.ErrQuestionNotFound,
+		},
+		{
+			name: "UpdateQuestionStatus",
+			call: func() error {
+				return questions.UpdateQuestionStatus(ctx, missingID, dto.QuestionStatusNameApproved)
+			},
+			want: dto.ErrQuestionNotFound,
+		},
+		{
+			name: "DeleteQuestion",
+			call: func() error {
+				return questions.DeleteQuestion(ctx, missingID)
+			},
+			want: dto.ErrQuestionNotFound,
+		},
+		{
+			name: "GetQuizByID",
+			call: func() error {
+				_, err := quizzes.GetQuizByID(ctx, missingID)
+				return err
+			},
+			want: dto.ErrQuizNotFound,
+		},
+		{
+			name: "GetQuestionsByQuizID",
+			call: func() error {
+				_, err := quizzes.GetQuestionsByQuizID(ctx, missingID)
+				return err
+			},
+			want: dto.ErrQuizNotFound,
+		},
+		{
+			name: "DeleteQuizByID",
+			call: func() error {
+				return quizzes.DeleteQuizByID(ctx, missingID)
+			},
+			want: dto.ErrQuizNotFound,
+		},
+		{
+			name: "UpdateSubject",
+			call: func() error {
+				return subjects.UpdateSubject(ctx, dto.Subject{ID: missingID, Name: "missing"})
+			},
+			want: dto.ErrSubjectNotFound,
+		},
+		{
+			name: "GetAttachmentByID",
+			call: func() error {
+				_, err := attachments.GetAttachmentByID(ctx, missingID)
+				return err
+			},
+			want: dto.ErrAttachmentNotFound,
+		},
+		{
+			name: "DeleteAttachment",
+			call: func() error {
+				return attachments.DeleteAttachment(ctx, missingID)
+			},
+			want: dto.ErrAttachmentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertNotFound(t, tt.call(), tt.want)
+		})
+	}
+}
+
+// TestSubjectInTrashIsNotFound updates and deletes an existing subject, the subject in the trash is not updated
+// and not deleted again
+func TestSubjectInTrashIsNotFound(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	subjects := NewSubjectsStorage(pool)
+
+	subject := dto.Subject{
+		Name:          fmt.Sprintf("storage test %d", time.Now().UnixNano()),
+		CreatorUserId: 1,
+		Active:        true,
+	}
+
+	id, err := subjects.AddSubject(ctx, subject)
+	if err != nil {
+		t.Fatalf("AddSubject: %v", err)
+	}
+	subject.ID = id
+
+	subject.Description = "updated"
+	if err = subjects.UpdateSubject(ctx, subject); err != nil {
+		t.Fatalf("UpdateSubject: %v", err)
+	}
+
+	if _, err = subjects.DeleteSubjectByID(ctx, id, dto.SubjectDeleteRefuse, -1); err != nil {
+		t.Fatalf("DeleteSubjectByID: %v", err)
+	}
+
+	assertNotFound(t, subjects.UpdateSubject(ctx, subject), dto.ErrSubjectNotFound)
+
+	_, err = subjects.DeleteSubjectByID(ctx, id, dto.SubjectDeleteRefuse, -1)
+	assertNotFound(t, err, dto.ErrSubjectNotFound)
+
+	deleted, err := subjects.GetSubjectByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSubjectByID: %v", err)
+	}
+	if deleted.DeletedAt == "" {
+		t.Errorf("subject %d is not in the trash", id)
+	}
+}
+
+// TestConcurrentUpdateIsVersionMismatch changes the subject while an update with the same If-Match waits for the lock,
+// the update fails as a stale version instead of an internal error
+func TestConcurrentUpdateIsVersionMismatch(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	subjects := NewSubjectsStorage(pool)
+
+	id, err := subjects.AddSubject(ctx, dto.Subject{
+		Name:          fmt.Sprintf("storage test %d", time.Now().UnixNano()),
+		CreatorUserId: 1,
+		Active:        true,
+	})
+	if err != nil {
+		t.Fatalf("AddSubject: %v", err)
+	}
+
+	subject, err := subjects.GetSubjectByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSubjectByID: %v", err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "UPDATE subject SET version = version + 1 WHERE id = $1", id); err != nil {
+		t.Fatalf("lock subject: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		subject.Description = "concurrent"
+		done <- subjects.UpdateSubject(etag.NewContext(ctx, subject.Version), subject)
+	}()
+
+	// let the update wait for the lock
+	time.Sleep(200 * time.Millisecond)
+	if err = tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if err = <-done; !errors.Is(err, dto.ErrVersionMismatch) {
+		t.Errorf("want %v, got %v", dto.ErrVersionMismatch, err)
+	}
+}
+
+// TestRestoreChecksVersion restores a subject from the trash with If-Match, the version is the one of the trashed subject
+func TestRestoreChecksVersion(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	subjects := NewSubjectsStorage(pool)
+
+	id, err := subjects.AddSubject(ctx, dto.Subject{
+		Name:          fmt.Sprintf("storage test %d", time.Now().UnixNano()),
+		CreatorUserId: 1,
+		Active:        true,
+	})
+	if err != nil {
+		t.Fatalf("AddSubject: %v", err)
+	}
+
+	// a subject which is not in the trash is not restored
+	assertNotFound(t, subjects.RestoreSubject(etag.NewContext(ctx, 0), id), dto.ErrSubjectNotFound)
+
+	if _, err = subjects.DeleteSubjectByID(ctx, id, dto.SubjectDeleteRefuse, -1); err != nil {
+		t.Fatalf("DeleteSubjectByID: %v", err)
+	}
+
+	deleted, err := subjects.GetSubjectByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSubjectByID: %v", err)
+	}
+
+	err = subjects.RestoreSubject(etag.NewContext(ctx, deleted.Version-1), id)
+	if !errors.Is(err, dto.ErrVersionMismatch) {
+		t.Errorf("stale version: want %v, got %v", dto.ErrVersionMismatch, err)
+	}
+
+	if err = subjects.RestoreSubject(etag.NewContext(ctx, deleted.Version), id); err != nil {
+		t.Errorf("RestoreSubject: %v", err)
+	}
+}
+
+// TestCascadedChangesAreAudited deletes and restores a subject with a sub subject, both of them get audit entries
+func TestCascadedChangesAreAudited(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	subjects := NewSubjectsStorage(pool)
+	audit := NewAuditStorage(pool)
+
+	name := fmt.Sprintf("storage test %d", time.Now().UnixNano())
+	id, err := subjects.AddSubject(ctx, dto.Subject{Name: name, CreatorUserId: 1, Active: true})
+	if err != nil {
+		t.Fatalf("AddSubject: %v", err)
+	}
+	childID, err := subjects.AddSubject(ctx, dto.Subject{Name: name + " child", ParentId: id, CreatorUserId: 1, Active: true})
+	if err != nil {
+		t.Fatalf("AddSubject: %v", err)
+	}
+
+	if _, err = subjects.DeleteSubjectByID(ctx, id, dto.SubjectDeleteCascade, -1); err != nil {
+		t.Fatalf("DeleteSubjectByID: %v", err)
+	}
+	deleted, err := subjects.GetSubjectByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSubjectByID: %v", err)
+	}
+	if err = subjects.RestoreSubject(etag.NewContext(ctx, deleted.Version), id); err != nil {
+		t.Fatalf("RestoreSubject: %v", err)
+	}
+
+	for _, subjectID := range []int64{id, childID} {
+		entries, err := audit.GetAuditEntries(ctx, dto.AuditFilter{EntityType: dto.AuditEntitySubject, EntityID: subjectID, Limit: 10})
+		if err != nil {
+			t.Fatalf("GetAuditEntries: %v", err)
+		}
+
+		actions := map[dto.AuditAction]bool{}
+		for _, entry := range entries {
+			actions[entry.Action] = true
+		}
+		if !actions[dto.AuditActionDelete] || !actions[dto.AuditActionRestore] {
+			t.Errorf("subject %d: want delete and restore entries, got %+v", subjectID, entries)
+		}
+	}
+}
+
+// TestGetTagsMatchesPrefixLiterally looks tags up by a prefix with "_", it is not a LIKE wildcard
+func TestGetTagsMatchesPrefixLiterally(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	tags := NewTagsStorage(pool)
+
+	prefix := fmt.Sprintf("storage_test_%d_", time.Now().UnixNano())
+	underscored, lettered := prefix+"a", strings.TrimSuffix(prefix, "_")+"xa"
+	if _, err := pool.Exec(ctx, `INSERT INTO tag (name) VALUES ($1), ($2)`, underscored, lettered); err != nil {
+		t.Fatalf("insert tags: %v", err)
+	}
+
+	found, err := tags.GetTags(ctx, prefix, 10)
+	if err != nil {
+		t.Fatalf("GetTags: %v", err)
+	}
+
+	if len(found) != 1 || found[0].Name != underscored {
+		t.Errorf("prefix %q: got %+v, want only %q", prefix, found, underscored)
+	}
+}
+
+// TestGetSubjectTreeDepth counts depth below the root: depth 1 returns the root and its children
+func TestGetSubjectTreeDepth(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	subjects := NewSubjectsStorage(pool)
+
+	name := fmt.Sprintf("storage test %d", time.Now().UnixNano())
+	parentID := int64(0)
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		id, err := subjects.AddSubject(ctx, dto.Subject{Name: fmt.Sprintf("%s %d", name, i), ParentId: parentID, CreatorUserId: 1, Active: true})
+		if err != nil {
+			t.Fatalf("AddSubject: %v", err)
+		}
+		ids = append(ids, id)
+		parentID = id
+	}
+
+	for depth, want := range map[int]int{0: 3, 1: 2, 2: 3} {
+		nodes, err := subjects.GetSubjectTree(ctx, ids[0], depth)
+		if err != nil {
+			t.Fatalf("GetSubjectTree: %v", err)
+		}
+		if len(nodes) != want {
+			t.Errorf("depth %d: got %d subjects, want %d", depth, len(nodes), want)
+		}
+	}
+}
+
+// TestUseAPITokenThrottlesLastUse records the first use, repeated uses within apiTokenUsePrecision do not write it
+func TestUseAPITokenThrottlesLastUse(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	tokens := NewAPITokensStorage(pool)
+
+	hash := []byte(fmt.Sprintf("storage test %d", time.Now().UnixNano()))
+	if _, err := tokens.AddAPIToken(ctx, dto.InputAPIToken{
+		Name:   "storage test",
+		UserID: 1,
+		Role:   dto.RoleUser,
+		Scopes: []string{"questions:read"},
+		Hash:   hash,
+		Prefix: dto.APITokenPrefix + "test",
+	}); err != nil {
+		t.Fatalf("AddAPIToken: %v", err)
+	}
+
+	first, err := tokens.UseAPIToken(ctx, hash)
+	if err != nil {
+		t.Fatalf("UseAPIToken: %v", err)
+	}
+	if first.LastUsedAt != "" {
+		t.Errorf("new token was used at %s", first.LastUsedAt)
+	}
+
+	second, err := tokens.UseAPIToken(ctx, hash)
+	if err != nil {
+		t.Fatalf("UseAPIToken: %v", err)
+	}
+	if second.LastUsedAt == "" {
+		t.Fatalf("first use is not recorded")
+	}
+
+	third, err := tokens.UseAPIToken(ctx, hash)
+	if err != nil {
+		t.Fatalf("UseAPIToken: %v", err)
+	}
+	if third.LastUsedAt != second.LastUsedAt {
+		t.Errorf("last use is written again: %s, then %s", second.LastUsedAt, third.LastUsedAt)
+	}
+}
@@ -0,0 +1,203 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"quiz_backend_core/internal/dto"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+)
+
+// GetSubjectByID returns the subject without question counts, subjects in the trash are found too
+func (s SubjectsStorage) GetSubjectByID(ctx context.Context, subjectID int64) (dto.Subject, error) {
+	query := `
+		SELECT json_build_object(
+			'id', s.id::TEXT,
+			'name', s.name,
+			'description', s.description,
+			'creator_user_id', s.creator_user_id::TEXT,
+			'active', s.active,
+			'parent_id', s.parent_id::TEXT,
+			'created_at', s.created_at,
+			'updated_at', s.updated_at,
+			'deleted_at', s.deleted_at,
+			'version', s.version
+		)
+		FROM subject s
+		WHERE s.id = $1
+	`
+
+	var subject dto.Subject
+	if err := s.conn.QueryRow(ctx, query, subjectID).Scan(&subject); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return subject, &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
+		}
+		return subject, &storage_errors.ScanPSQLResultsError{Err: err}
+	}
+
+	return subject, nil
+}
+
+// IsSubjectMaintainer says whether the user maintains the subject or one of its ancestors
+func (s SubjectsStorage) IsSubjectMaintainer(ctx context.Context, subjectID int64, userID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM subject s
+			JOIN subject a ON a.path @> s.path
+			JOIN subject_maintainer m ON m.subject_id = a.id
+			WHERE s.id = $1 AND m.user_id = $2
+		)
+	`
+
+	var maintainer bool
+	if err := s.conn.QueryRow(ctx, query, subjectID, userID).Scan(&maintainer); err != nil {
+		return false, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return maintainer, nil
+}
+
+// GetSubjectMaintainers returns maintainers of the subject including the ones granted on its ancestors,
+// a user granted on several levels is returned once with the nearest grant
+func (s SubjectsStorage) GetSubjectMaintainers(ctx context.Context, subjectID int64) ([]dto.SubjectMaintainer, error) {
+	subjectQuery := `
+		SELECT path::TEXT FROM subject WHERE id = $1 AND deleted_at IS NULL
+	`
+	query := `
+		SELECT DISTINCT ON (m.user_id) json_build_object(
+			'subject_id', m.subject_id::TEXT,
+			'user_id', m.user_id::TEXT,
+			'granted_by_user_id', m.granted_by_user_id::TEXT,
+			'created_at', m.created_at,
+			'inherited', m.subject_id <> $1
+		)
+		FROM subject a
+		JOIN subject_maintainer m ON m.subject_id = a.id
+		WHERE a.path @> $2::ltree
+		ORDER BY m.user_id, nlevel(a.path) DESC
+	`
+
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return nil, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	var path string
+	if err = tx.QueryRow(ctx, subjectQuery, subjectID).Scan(&path); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
+		}
+		return nil, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	var maintainers = []dto.SubjectMaintainer{}
+	rows, err := tx.Query(ctx, query, subjectID, path)
+	if err != nil {
+		return maintainers, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result dto.SubjectMaintainer
+		if err := rows.Scan(&result); err != nil {
+			return maintainers, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+		maintainers = append(maintainers, result)
+	}
+	if err = rows.Err(); err != nil {
+		return maintainers, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return maintainers, nil
+}
+
+// AddSubjectMaintainer grants the subject to the user, repeated grant is not an error
+func (s SubjectsStorage) AddSubjectMaintainer(ctx context.Context, maintainer dto.SubjectMaintainer) error {
+	subjectQuery := `
+		SELECT id FROM subject WHERE id = $1 AND deleted_at IS NULL FOR SHARE
+	`
+	query := `
+		INSERT INTO subject_maintainer AS t (subject_id, user_id, granted_by_user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (subject_id, user_id) DO NOTHING
+		RETURNING to_jsonb(t)
+	`
+
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	if err = tx.QueryRow(ctx, subjectQuery, maintainer.SubjectID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
+		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	var after json.RawMessage
+	if err = tx.QueryRow(ctx, query, maintainer.SubjectID, maintainer.UserID, maintainer.GrantedByUserID).Scan(&after); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// granted already, nothing changed
+			return nil
+		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if err = writeAudit(ctx, tx, dto.AuditActionGrant, dto.AuditEntitySubjectMaintainer, maintainer.SubjectID, nil, after); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// DeleteSubjectMaintainer revokes the grant made on the subject itself, grants on ancestors are kept
+func (s SubjectsStorage) DeleteSubjectMaintainer(ctx context.Context, subjectID int64, userID int64) error {
+	query := `
+		DELETE FROM subject_maintainer t WHERE t.subject_id = $1 AND t.user_id = $2
+		RETURNING to_jsonb(t)
+	`
+
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	var before json.RawMessage
+	if err = tx.QueryRow(ctx, query, subjectID, userID).Scan(&before); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &storage_errors.NotFoundError{Err: dto.ErrMaintainerNotFound}
+		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if err = writeAudit(ctx, tx, dto.AuditActionRevoke, dto.AuditEntitySubjectMaintainer, subjectID, before, nil); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
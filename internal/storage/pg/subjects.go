@@ -11,6 +11,8 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"quiz_backend_core/internal/dto"
 	storage_errors "quiz_backend_core/internal/storage/errors"
+	"strings"
+	"time"
 )
 
 func NewSubjectsStorage(conn *pgxpool.Pool) *SubjectsStorage {
@@ -24,6 +26,15 @@ type SubjectsStorage struct {
 }
 
 func (s SubjectsStorage) GetSubjects(ctx context.Context) ([]dto.Subject, error) {
+	return s.getSubjects(ctx, false)
+}
+
+// GetDeletedSubjects returns subjects from the trash
+func (s SubjectsStorage) GetDeletedSubjects(ctx context.Context) ([]dto.Subject, error) {
+	return s.getSubjects(ctx, true)
+}
+
+func (s SubjectsStorage) getSubjects(ctx context.Context, deleted bool) ([]dto.Subject, error) {
 	query := `		
 		SELECT json_build_object(
 			'id', s.id::TEXT,
@@ -35,14 +46,23 @@ func (s SubjectsStorage) GetSubjects(ctx context.Context) ([]dto.Subject, error)
 			'created_at', s.created_at,
 			'updated_at', s.updated_at,
 			'question_count', count(q.id),
-			'approved_question_count', count(q.id) FILTER ( WHERE q.status_id = (SELECT id from question_status WHERE name='Одобрен') )
+			'approved_question_count', count(q.id) FILTER ( WHERE q.status_id = (SELECT id from question_status WHERE name='Одобрен') ),
+			'deleted_at', s.deleted_at,
+			'version', s.version
 		)
 		FROM subject s
-		LEFT JOIN public.question q ON s.id = q.subject_id
-		GROUP BY s.id, s.name, s.description, s.creator_user_id, s.active, s.parent_id, s.created_at, s.updated_at
+		LEFT JOIN public.question q ON s.id = q.subject_id AND (q.deleted_at IS NULL OR q.deleted_at = s.deleted_at)
+		WHERE %s
+		GROUP BY s.id, s.name, s.description, s.creator_user_id, s.active, s.parent_id, s.created_at, s.updated_at, s.deleted_at, s.version
 		ORDER BY s.path;
 	`
 
+	if deleted {
+		query = fmt.Sprintf(query, "s.deleted_at IS NOT NULL")
+	} else {
+		query = fmt.Sprintf(query, "s.deleted_at IS NULL")
+	}
+
 	var subjects []dto.Subject
 	rows, errRows := s.conn.Query(ctx, query)
 	if errRows != nil {
@@ -76,6 +96,69 @@ func (s SubjectsStorage) GetSubjects(ctx context.Context) ([]dto.Subject, error)
 	return subjects, nil
 }
 
+// GetSubjectTree returns live subjects of the subtree of rootID (-1 means all subjects) in path order,
+// question counts are rolled up over descendants. Depth limits levels below the root, 0 means no limit:
+// depth 1 returns the root with its children, or top level subjects for the whole forest.
+// Children of nodes are not filled.
+func (s SubjectsStorage) GetSubjectTree(ctx context.Context, rootID int64, depth int) ([]dto.SubjectTreeNode, error) {
+	query := `
+		WITH root AS (
+			%s
+		)
+		SELECT json_build_object(
+			'id', s.id::TEXT,
+			'name', s.name,
+			'description', s.description,
+			'active', s.active,
+			'parent_id', s.parent_id::TEXT,
+			'question_count', count(q.id) FILTER ( WHERE q.subject_id = s.id ),
+			'total_question_count', count(q.id),
+			'approved_question_count', count(q.id) FILTER ( WHERE qs.name = $2 ),
+			'pending_question_count', count(q.id) FILTER ( WHERE qs.name = $3 )
+		)
+		FROM subject s
+		JOIN root ON s.path <@ root.path OR root.path IS NULL
+		LEFT JOIN subject d ON d.path <@ s.path AND d.deleted_at IS NULL
+		LEFT JOIN question q ON q.subject_id = d.id AND q.deleted_at IS NULL
+		LEFT JOIN question_status qs ON qs.id = q.status_id
+		WHERE s.deleted_at IS NULL AND ($4 = 0 OR nlevel(s.path) - root.level <= $4)
+		GROUP BY s.id, s.path
+		ORDER BY s.path
+	`
+
+	// level is the level of the root, the root is not counted by depth
+	if rootID > 0 {
+		query = fmt.Sprintf(query, `SELECT path, nlevel(path) AS level FROM subject WHERE id = $1 AND deleted_at IS NULL`)
+	} else {
+		query = fmt.Sprintf(query, `SELECT NULL::ltree AS path, 0 AS level WHERE $1::BIGINT <= 0`)
+	}
+
+	var nodes = []dto.SubjectTreeNode{}
+	rows, err := s.conn.Query(ctx, query, rootID, dto.QuestionStatusNameApproved, dto.QuestionStatusNameCreated, depth)
+	if err != nil {
+		return nodes, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result dto.SubjectTreeNode
+		if err := rows.Scan(&result); err != nil {
+			return nodes, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+		nodes = append(nodes, result)
+	}
+	if err = rows.Err(); err != nil {
+		return nodes, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	// the root itself is always returned
+	if rootID > 0 && len(nodes) == 0 {
+		return nodes, &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
+	}
+
+	return nodes, nil
+}
+
 func (s SubjectsStorage) AddSubject(ctx context.Context, subject dto.Subject) (int64, error) {
 	var subjectID int64 = -1
 	query := `
@@ -89,6 +172,10 @@ func (s SubjectsStorage) AddSubject(ctx context.Context, subject dto.Subject) (i
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id;
 		`
+	maintainerQuery := `
+		INSERT INTO subject_maintainer (subject_id, user_id, granted_by_user_id)
+		VALUES ($1, $2, $2)
+		`
 
 	//preparedStmt, err := s.conn.Prepare(ctx, "AddSubject", query)
 	//if err != nil {
@@ -124,6 +211,15 @@ func (s SubjectsStorage) AddSubject(ctx context.Context, subject dto.Subject) (i
 		}
 	}
 
+	// creator maintains the subject
+	if _, err = tx.Exec(ctx, maintainerQuery, subjectID, subject.CreatorUserId); err != nil {
+		return subjectID, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionCreate, dto.AuditEntitySubject, subjectID, nil); err != nil {
+		return subjectID, err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return subjectID, &storage_errors.ExecutionPSQLError{Err: err}
 	}
@@ -234,16 +330,27 @@ func (s SubjectsStorage) GetStatistic(ctx context.Context, userId int64) (dto.St
 					FROM
 						subject s2
 					LEFT JOIN
-						question q2 ON EXISTS (SELECT 1 FROM subject s3 WHERE s3.id = q2.subject_id AND s3.path <@ s2.path)
-					WHERE s2.parent_id IS NULL
+						question q2 ON q2.deleted_at IS NULL
+							AND EXISTS (SELECT 1 FROM subject s3 WHERE s3.id = q2.subject_id AND s3.path <@ s2.path)
+					WHERE s2.parent_id IS NULL AND s2.deleted_at IS NULL
 					GROUP BY s2.id, s2.name, s2.path
 					ORDER BY s2.path
-				) AS t), '[]'::json)  -- Add subject data
+				) AS t), '[]'::json),  -- Add subject data
+			'tag_counts', COALESCE((SELECT json_agg(row_to_json(t)) FROM (
+					SELECT
+						tg.id::TEXT AS id,
+						tg.name AS name,
+						(SELECT COUNT(*) FROM question_tag qtg WHERE qtg.tag_id = tg.id) AS question_count,
+						(SELECT COUNT(*) FROM quiz_tag qzt WHERE qzt.tag_id = tg.id) AS quiz_count
+					FROM
+						tag tg
+					ORDER BY question_count DESC, quiz_count DESC, tg.name
+				) AS t), '[]'::json)
 		) AS counts
 		FROM (SELECT 1 as dummy) AS one_row
-		LEFT JOIN quiz q ON TRUE
-		LEFT JOIN subject s ON TRUE
-		LEFT JOIN question qu ON TRUE
+		LEFT JOIN quiz q ON q.deleted_at IS NULL
+		LEFT JOIN subject s ON s.deleted_at IS NULL
+		LEFT JOIN question qu ON qu.deleted_at IS NULL
 	`
 
 	var statistic dto.Statistic
@@ -256,18 +363,18 @@ func (s SubjectsStorage) GetStatistic(ctx context.Context, userId int64) (dto.St
 	return statistic, nil
 }
 
+// UpdateSubject changes the subject and moves it if parent is changed, creator is never changed
 func (s SubjectsStorage) UpdateSubject(ctx context.Context, subject dto.Subject) error {
 	query := `		
 		UPDATE 
 		    subject
 		SET
+		    version = version + 1,
 		    name=$1,
 		    description=$2,
-		    creator_user_id=$3,
-		    active=$4,
-		    parent_id=$5
+		    active=$3
 		WHERE
-		    id = $6
+		    id = $4 AND deleted_at IS NULL
 		`
 
 	//preparedStmt, err := s.conn.Prepare(ctx, "UpdateSubject", query);
@@ -284,11 +391,22 @@ func (s SubjectsStorage) UpdateSubject(ctx context.Context, subject dto.Subject)
 	}
 	defer tx.Rollback(ctx)
 
-	var ParentId sql.NullInt32
-	ParentId.Int32 = int32(subject.ParentId)
-	ParentId.Valid = subject.ParentId > 0
+	if err = checkVersion(ctx, tx, "subject", subject.ID, dto.ErrSubjectNotFound); err != nil {
+		return err
+	}
+
+	before, err := snapshot(ctx, tx, dto.AuditEntitySubject, subject.ID)
+	if err != nil {
+		return err
+	}
+
+	// parent change moves the whole subtree
+	if err = moveSubtree(ctx, tx, subject.ID, subject.ParentId); err != nil {
+		return err
+	}
 
-	if _, err = tx.Exec(ctx /*preparedStmt.Name*/, query, subject.Name, subject.Description, subject.CreatorUserId, subject.Active, ParentId, subject.ID); err != nil {
+	result, err := tx.Exec(ctx /*preparedStmt.Name*/, query, subject.Name, subject.Description, subject.Active, subject.ID)
+	if err != nil {
 		//if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) { //TODO IntegrityConstraintViolation
 		//	return &storage_errors.AlreadyExistsError{Err: dto.ErrSubjectAlreadyExists}
 		//} else {
@@ -296,6 +414,14 @@ func (s SubjectsStorage) UpdateSubject(ctx context.Context, subject dto.Subject)
 		//}
 	}
 
+	if result.RowsAffected() == 0 {
+		return &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionUpdate, dto.AuditEntitySubject, subject.ID, before); err != nil {
+		return err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return &storage_errors.ExecutionPSQLError{Err: err}
 	}
@@ -303,19 +429,378 @@ func (s SubjectsStorage) UpdateSubject(ctx context.Context, subject dto.Subject)
 	return nil
 }
 
-func (s SubjectsStorage) DeleteSubjectByID(ctx context.Context, subjectID int64) error {
-	query := `		
-		DELETE FROM 
+// moveSubtree puts the subject under parentID (0 or less means the top level) and rewrites ltree paths
+// of the whole subtree including subjects in the trash. Moving a subject into its own subtree is refused.
+func moveSubtree(ctx context.Context, tx pgx.Tx, subjectID int64, parentID int64) error {
+	subjectQuery := `
+		SELECT path::TEXT, coalesce(parent_id, 0) FROM subject WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`
+	parentQuery := `
+		SELECT path::TEXT FROM subject WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`
+	moveQuery := `
+		UPDATE
+		    subject
+		SET
+		    version = version + 1,
+		    parent_id = CASE WHEN id = $1 THEN $2 ELSE parent_id END,
+		    path = $3::ltree || subpath(path, nlevel($4::ltree) - 1)
+		WHERE
+		    path <@ $4::ltree
+	`
+
+	var path string
+	var currentParentID int64
+	if err := tx.QueryRow(ctx, subjectQuery, subjectID).Scan(&path, &currentParentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
+		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if parentID < 0 {
+		parentID = 0
+	}
+	if parentID == currentParentID {
+		return nil
+	}
+
+	var parentPath string
+	if parentID > 0 {
+		if err := tx.QueryRow(ctx, parentQuery, parentID).Scan(&parentPath); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return &storage_errors.NotFoundError{Err: fmt.Errorf("%w: parent %d", dto.ErrSubjectNotFound, parentID)}
+			}
+			return &storage_errors.ExecutionPSQLError{Err: err}
+		}
+
+		if isSubPath(parentPath, path) {
+			return dto.ErrSubjectCycle
+		}
+	}
+
+	var parent sql.NullInt64
+	parent.Int64 = parentID
+	parent.Valid = parentID > 0
+
+	if _, err := tx.Exec(ctx, moveQuery, subjectID, parent, parentPath, path); err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
+			return &storage_errors.AlreadyExistsError{Err: dto.ErrSubjectAlreadyExists}
+		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// isSubPath is the ltree "path <@ root" check for text paths
+func isSubPath(path, root string) bool {
+	return path == root || strings.HasPrefix(path, root+".")
+}
+
+// MoveSubject puts the subject with its subtree under another parent (0 or less means the top level)
+func (s SubjectsStorage) MoveSubject(ctx context.Context, subjectID int64, parentID int64) error {
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	if err = checkVersion(ctx, tx, "subject", subjectID, dto.ErrSubjectNotFound); err != nil {
+		return err
+	}
+
+	before, err := snapshot(ctx, tx, dto.AuditEntitySubject, subjectID)
+	if err != nil {
+		return err
+	}
+
+	if err = moveSubtree(ctx, tx, subjectID, parentID); err != nil {
+		return err
+	}
+
+	if err = auditChange(ctx, tx, dto.AuditActionMove, dto.AuditEntitySubject, subjectID, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// MergeSubjects moves questions and sub subjects of the source subject to the target one,
+// then the empty source subject goes to the trash
+func (s SubjectsStorage) MergeSubjects(ctx context.Context, sourceID int64, targetID int64) error {
+	lockQuery := `
+		SELECT path::TEXT FROM subject WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`
+	// moved questions and sub subjects are audited one by one, see updateAudited
+	moveQuestionsSet := `version = t.version + 1, subject_id = $2`
+	moveQuestionsWhere := `t.subject_id = $1`
+	moveChildrenSet := `
+		version = t.version + 1,
+		parent_id = CASE WHEN t.parent_id = $1 THEN $2 ELSE t.parent_id END,
+		path = $3::ltree || subpath(t.path, nlevel($4::ltree))
+	`
+	moveChildrenWhere := `t.path <@ $4::ltree AND t.id <> $1`
+	removeSourceQuery := `
+		UPDATE
 		    subject
+		SET
+		    version = version + 1,
+		    deleted_at = now()
 		WHERE
 		    id = $1
-		`
+	`
+
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	if err = checkVersion(ctx, tx, "subject", sourceID, dto.ErrSubjectNotFound); err != nil {
+		return err
+	}
+
+	var sourcePath, targetPath string
+	if err = tx.QueryRow(ctx, lockQuery, sourceID).Scan(&sourcePath); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
+		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if err = tx.QueryRow(ctx, lockQuery, targetID).Scan(&targetPath); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &storage_errors.NotFoundError{Err: fmt.Errorf("%w: target %d", dto.ErrSubjectNotFound, targetID)}
+		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if isSubPath(targetPath, sourcePath) {
+		return dto.ErrSubjectCycle
+	}
+
+	before, err := snapshot(ctx, tx, dto.AuditEntitySubject, sourceID)
+	if err != nil {
+		return err
+	}
+
+	if _, err = updateAudited(ctx, tx, dto.AuditActionMove, dto.AuditEntityQuestion, moveQuestionsSet, moveQuestionsWhere, sourceID, targetID); err != nil {
+		return err
+	}
+
+	_, err = updateAudited(ctx, tx, dto.AuditActionMove, dto.AuditEntitySubject, moveChildrenSet, moveChildrenWhere,
+		sourceID, targetID, targetPath, sourcePath)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
+			return &storage_errors.AlreadyExistsError{Err: dto.ErrSubjectAlreadyExists}
+		}
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, removeSourceQuery, sourceID); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	// the entry of the source subject, the target is not changed
+	if err = auditChange(ctx, tx, dto.AuditActionMerge, dto.AuditEntitySubject, sourceID, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return nil
+}
+
+// subjectDeletePreview collects live sub subjects (by ltree path), their questions and quizzes using the questions
+func subjectDeletePreview(ctx context.Context, tx pgx.Tx, subjectID int64) (dto.SubjectDeletePreview, error) {
+	query := `
+		WITH root AS (
+			SELECT id, path FROM subject WHERE id = $1 AND deleted_at IS NULL
+		), tree AS (
+			SELECT s.id, s.name, s.parent_id, s.path
+			FROM subject s
+			JOIN root ON s.path <@ root.path
+			WHERE s.deleted_at IS NULL
+		), tree_questions AS (
+			SELECT q.id
+			FROM question q
+			WHERE q.subject_id IN (SELECT id FROM tree) AND q.deleted_at IS NULL
+		)
+		SELECT json_build_object(
+			'subject_id', root.id::TEXT,
+			'descendants', (
+				SELECT coalesce(json_agg(json_build_object(
+					'id', t.id::TEXT,
+					'name', t.name,
+					'parent_id', t.parent_id::TEXT
+				) ORDER BY t.path), '[]'::json)
+				FROM tree t
+				WHERE t.id <> root.id
+			),
+			'question_ids', (
+				SELECT coalesce(json_agg(tq.id::TEXT ORDER BY tq.id), '[]'::json)
+				FROM tree_questions tq
+			),
+			'quizzes', (
+				SELECT coalesce(json_agg(json_build_object(
+					'id', z.id::TEXT,
+					'name', z.name
+				) ORDER BY z.id), '[]'::json)
+				FROM quiz z
+				WHERE z.deleted_at IS NULL AND EXISTS (
+					SELECT 1 FROM quizzes_questions qq
+					WHERE qq.quiz_id = z.id AND qq.question_id IN (SELECT id FROM tree_questions)
+				)
+			)
+		)
+		FROM root
+	`
+
+	var preview dto.SubjectDeletePreview
+	if err := tx.QueryRow(ctx, query, subjectID).Scan(&preview); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return preview, &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
+		}
+		return preview, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return preview, nil
+}
+
+// GetSubjectDeletePreview is a dry run of DeleteSubjectByID, nothing is changed
+func (s SubjectsStorage) GetSubjectDeletePreview(ctx context.Context, subjectID int64) (dto.SubjectDeletePreview, error) {
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return dto.SubjectDeletePreview{}, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	return subjectDeletePreview(ctx, tx, subjectID)
+}
+
+// DeleteSubjectByID moves the subject with its subtree to the trash, what happens with the subtree content
+// depends on mode (see dto.SubjectDeleteMode), targetSubjectID is used by reassign mode only.
+// Subjects and questions deleted together get the same deleted_at, so RestoreSubject can bring back exactly this deletion.
+func (s SubjectsStorage) DeleteSubjectByID(ctx context.Context, subjectID int64, mode dto.SubjectDeleteMode, targetSubjectID int64) (dto.SubjectDeletePreview, error) {
+	lockQuery := `
+		SELECT id FROM subject WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`
+	targetQuery := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM subject t
+			JOIN subject r ON r.id = $1
+			WHERE t.id = $2 AND t.deleted_at IS NULL AND NOT t.path <@ r.path
+		)
+	`
+	// changes of subjects and questions are audited one by one, see updateAudited
+	reassignQuestionsSet := `version = t.version + 1, subject_id = $2`
+	reassignQuestionsWhere := `t.id = ANY($1::BIGINT[])`
+	removeSet := `version = t.version + 1, deleted_at = now()`
+	removeSubjectsWhere := `t.path <@ (SELECT s.path FROM subject s WHERE s.id = $1) AND t.deleted_at IS NULL`
+	removeQuestionsWhere := `t.subject_id = ANY($1::BIGINT[]) AND t.deleted_at IS NULL`
 
 	//preparedStmt, err := s.conn.Prepare(ctx, "DeleteSubjectByID", query);
 	//if err != nil {
 	//	return &storage_errors.StatementPSQLError{Err: err}
 	//}
 
+	var preview dto.SubjectDeletePreview
+
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return preview, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	var lockedID int64
+	if err = tx.QueryRow(ctx, lockQuery, subjectID).Scan(&lockedID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return preview, &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
+		}
+		return preview, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if err = checkVersion(ctx, tx, "subject", subjectID, dto.ErrSubjectNotFound); err != nil {
+		return preview, err
+	}
+
+	if preview, err = subjectDeletePreview(ctx, tx, subjectID); err != nil {
+		return preview, err
+	}
+
+	switch mode {
+	case dto.SubjectDeleteRefuse:
+		if !preview.IsEmpty() {
+			return preview, dto.ErrSubjectNotEmpty
+		}
+	case dto.SubjectDeleteReassign:
+		var targetValid bool
+		if err = tx.QueryRow(ctx, targetQuery, subjectID, targetSubjectID).Scan(&targetValid); err != nil {
+			return preview, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+		if !targetValid {
+			return preview, dto.ErrInvalidReassignTarget
+		}
+
+		_, err = updateAudited(ctx, tx, dto.AuditActionMove, dto.AuditEntityQuestion, reassignQuestionsSet, reassignQuestionsWhere,
+			[]int64(preview.QuestionIDs), targetSubjectID)
+		if err != nil {
+			return preview, err
+		}
+	}
+
+	// sub subjects and questions share deleted_at of the subject
+	subjectIDs, err := updateAudited(ctx, tx, dto.AuditActionDelete, dto.AuditEntitySubject, removeSet, removeSubjectsWhere, subjectID)
+	if err != nil {
+		return preview, err
+	}
+
+	if _, err = updateAudited(ctx, tx, dto.AuditActionDelete, dto.AuditEntityQuestion, removeSet, removeQuestionsWhere, subjectIDs); err != nil {
+		return preview, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return preview, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return preview, nil
+}
+
+// RestoreSubject takes the subject out of the trash together with subjects and questions deleted with it.
+// The subject can not be restored while its parent is in the trash.
+func (s SubjectsStorage) RestoreSubject(ctx context.Context, subjectID int64) error {
+	checkQuery := `
+		SELECT coalesce((SELECT p.deleted_at IS NOT NULL FROM subject p WHERE p.id = s.parent_id), false)
+		FROM subject s
+		WHERE s.id = $1 AND s.deleted_at IS NOT NULL
+	`
+	rootQuery := `SELECT path::TEXT, deleted_at FROM subject WHERE id = $1`
+	// restored subjects and questions are audited one by one, see updateAudited
+	restoreSet := `version = t.version + 1, deleted_at = NULL`
+	restoreSubjectsWhere := `t.path <@ $1::ltree AND t.deleted_at = $2`
+	restoreQuestionsWhere := `t.subject_id = ANY($1::BIGINT[]) AND t.deleted_at = $2`
+
 	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel:   pgx.RepeatableRead,
 		AccessMode: pgx.ReadWrite,
@@ -325,12 +810,35 @@ func (s SubjectsStorage) DeleteSubjectByID(ctx context.Context, subjectID int64)
 	}
 	defer tx.Rollback(ctx)
 
-	if _, err = tx.Exec(ctx /*preparedStmt.Name*/, query, subjectID); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsCaseNotFound(pgErr.Code) {
-			return &storage_errors.AlreadyExistsError{Err: dto.ErrSubjectNotFound}
-		} else {
-			return &storage_errors.ExecutionPSQLError{Err: err}
+	if err = checkDeletedVersion(ctx, tx, "subject", subjectID, dto.ErrSubjectNotFound); err != nil {
+		return err
+	}
+
+	var parentDeleted bool
+	if err = tx.QueryRow(ctx, checkQuery, subjectID).Scan(&parentDeleted); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &storage_errors.NotFoundError{Err: dto.ErrSubjectNotFound}
 		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if parentDeleted {
+		return dto.ErrParentInTrash
+	}
+
+	var rootPath string
+	var deletedAt time.Time
+	if err = tx.QueryRow(ctx, rootQuery, subjectID).Scan(&rootPath, &deletedAt); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	subjectIDs, err := updateAudited(ctx, tx, dto.AuditActionRestore, dto.AuditEntitySubject, restoreSet, restoreSubjectsWhere, rootPath, deletedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err = updateAudited(ctx, tx, dto.AuditActionRestore, dto.AuditEntityQuestion, restoreSet, restoreQuestionsWhere, subjectIDs, deletedAt); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(ctx); err != nil {
@@ -339,3 +847,34 @@ func (s SubjectsStorage) DeleteSubjectByID(ctx context.Context, subjectID int64)
 
 	return nil
 }
+
+// PurgeSubjects removes subjects which are in the trash longer than retention, returns number of removed subjects.
+// Questions of the subjects are deleted together with them, so questions have to be purged first.
+func (s SubjectsStorage) PurgeSubjects(ctx context.Context, retention time.Duration) (int64, error) {
+	query := `
+		DELETE FROM
+		    subject t
+		WHERE
+		    t.deleted_at < now() - $1::INTERVAL
+	`
+
+	tx, err := s.conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return 0, &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("BeginTx failed: %v\n", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	removed, err := removeAudited(ctx, tx, dto.AuditActionPurge, dto.AuditEntitySubject, query, retention)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	return removed, nil
+}
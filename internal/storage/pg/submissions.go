@@ -0,0 +1,156 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"quiz_backend_core/internal/dto"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+	"strings"
+)
+
+func NewSubmissionsStorage(conn *pgxpool.Pool) *SubmissionsStorage {
+	return &SubmissionsStorage{
+		conn: conn,
+	}
+}
+
+type SubmissionsStorage struct {
+	conn *pgxpool.Pool
+}
+
+const submissionObject = `
+		json_build_object(
+			'id', sb.id::TEXT,
+			'question_id', sb.question_id::TEXT,
+			'user_id', sb.user_id::TEXT,
+			'language', sb.language,
+			'code', sb.code,
+			'status', sb.status,
+			'score', sb.score,
+			'passed_tests', sb.passed_tests,
+			'total_tests', sb.total_tests,
+			'compile_error', sb.compile_error,
+			'results', sb.results,
+			'created_at', sb.created_at
+		)`
+
+func (s SubmissionsStorage) AddSubmission(ctx context.Context, submission dto.Submission) (int64, error) {
+	var submissionID int64 = -1
+	query := `
+		INSERT INTO submission (
+			question_id,	-- 1
+			user_id,		-- 2
+			language,		-- 3
+			code,			-- 4
+			status,			-- 5
+			score,			-- 6
+			passed_tests,	-- 7
+			total_tests,	-- 8
+			compile_error,	-- 9
+			results			-- 10
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, nullif($9, ''), $10)
+		RETURNING id;
+	`
+
+	results := submission.Results
+	if results == nil {
+		results = []dto.TestResult{}
+	}
+
+	args := []interface{}{
+		submission.QuestionID,
+		submission.UserID,
+		submission.Language,
+		submission.Code,
+		submission.Status,
+		submission.Score,
+		submission.PassedTests,
+		submission.TotalTests,
+		submission.CompileError,
+		results,
+	}
+
+	if err := s.conn.QueryRow(ctx, query, args...).Scan(&submissionID); err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
+			return submissionID, &storage_errors.AlreadyExistsError{Err: pgErr}
+		} else {
+			return submissionID, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+
+	return submissionID, nil
+}
+
+func (s SubmissionsStorage) GetSubmissionByID(ctx context.Context, submissionID int64) (dto.Submission, error) {
+	query := `
+		SELECT` + submissionObject + `
+		FROM submission sb
+		WHERE sb.id = $1
+	`
+
+	var res string
+	var submission dto.Submission
+	if err := s.conn.QueryRow(ctx, query, submissionID).Scan(&res); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return submission, &storage_errors.NotFoundError{Err: dto.ErrSubmissionNotFound}
+		default:
+			return submission, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+
+	if err := json.Unmarshal([]byte(res), &submission); err != nil {
+		return submission, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+	}
+
+	return submission, nil
+}
+
+// GetSubmissions returns submissions newest first, -1 means "any" for user and question
+func (s SubmissionsStorage) GetSubmissions(ctx context.Context, userID int64, questionID int64) ([]dto.Submission, error) {
+	var submissions = []dto.Submission{}
+
+	var conditions []string
+	if userID != -1 {
+		conditions = append(conditions, fmt.Sprintf("sb.user_id = %d", userID))
+	}
+	if questionID != -1 {
+		conditions = append(conditions, fmt.Sprintf("sb.question_id = %d", questionID))
+	}
+
+	query := `
+		SELECT` + submissionObject + `
+		FROM submission sb
+	`
+	if len(conditions) != 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY sb.id DESC"
+
+	rows, err := s.conn.Query(ctx, query)
+	if err != nil {
+		return submissions, &storage_errors.ExecutionPSQLError{Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res string
+		if err := rows.Scan(&res); err != nil {
+			return submissions, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		var result dto.Submission
+		if err := json.Unmarshal([]byte(res), &result); err != nil {
+			return submissions, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		submissions = append(submissions, result)
+	}
+	return submissions, nil
+}
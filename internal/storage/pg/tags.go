@@ -0,0 +1,111 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"quiz_backend_core/internal/dto"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+)
+
+func NewTagsStorage(conn *pgxpool.Pool) *TagsStorage {
+	return &TagsStorage{
+		conn: conn,
+	}
+}
+
+type TagsStorage struct {
+	conn *pgxpool.Pool
+}
+
+// GetTags returns tags which names start with prefix, most used tags go first.
+// The prefix is compared as is, "_" allowed in tag names is not a wildcard.
+func (t TagsStorage) GetTags(ctx context.Context, prefix string, limit int) ([]dto.Tag, error) {
+	var tags = []dto.Tag{}
+	query := `
+		SELECT json_build_object(
+			'id', t.id::TEXT,
+			'name', t.name,
+			'question_count', (SELECT count(*) FROM question_tag qtg WHERE qtg.tag_id = t.id),
+			'quiz_count', (SELECT count(*) FROM quiz_tag qzt WHERE qzt.tag_id = t.id)
+		)
+		FROM tag t
+		WHERE starts_with(t.name, $1)
+		ORDER BY
+			(SELECT count(*) FROM question_tag qtg WHERE qtg.tag_id = t.id) +
+			(SELECT count(*) FROM quiz_tag qzt WHERE qzt.tag_id = t.id) DESC,
+			t.name
+		LIMIT $2
+	`
+
+	rows, err := t.conn.Query(ctx, query, prefix, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows): //it is not error
+			return tags, nil
+		default:
+			return tags, &storage_errors.ExecutionPSQLError{Err: err}
+		}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res string
+		if err := rows.Scan(&res); err != nil {
+			return tags, &storage_errors.ScanPSQLResultsError{Err: err}
+		}
+
+		var result dto.Tag
+		if err := json.Unmarshal([]byte(res), &result); err != nil {
+			return tags, &storage_errors.UnmarshalPSQLResultsError{Err: err}
+		}
+		tags = append(tags, result)
+	}
+	return tags, nil
+}
+
+// setTags replaces tags of the entity (question or quiz) in the link table, missing tags are created
+func setTags(ctx context.Context, tx pgx.Tx, linkTable, linkColumn string, entityID int64, tags []string) error {
+	insertTagsQuery := `
+		INSERT INTO tag (name)
+		SELECT unnest($1::TEXT[])
+		ON CONFLICT (name) DO NOTHING
+	`
+	removeLinksQuery := fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, linkTable, linkColumn)
+	addLinksQuery := fmt.Sprintf(`
+		INSERT INTO %s (%s, tag_id)
+		SELECT $1, id FROM tag WHERE name = ANY($2::TEXT[])
+	`, linkTable, linkColumn)
+
+	if len(tags) != 0 {
+		if _, err := tx.Exec(ctx, insertTagsQuery, tags); err != nil {
+			return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("insert tags failed: %v\n", err)}
+		}
+	}
+
+	if _, err := tx.Exec(ctx, removeLinksQuery, entityID); err != nil {
+		return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("remove tags failed: %v\n", err)}
+	}
+
+	if len(tags) != 0 {
+		if _, err := tx.Exec(ctx, addLinksQuery, entityID, tags); err != nil {
+			return &storage_errors.ExecutionPSQLError{Err: fmt.Errorf("link tags failed: %v\n", err)}
+		}
+	}
+
+	return nil
+}
+
+// taggedCondition is a condition "entity has all tags from parameter $argN"
+func taggedCondition(idColumn, linkTable, linkColumn string, argN int) string {
+	return fmt.Sprintf(`%s IN (
+			SELECT lt.%s FROM %s lt
+			JOIN tag t ON t.id = lt.tag_id
+			WHERE t.name = ANY($%d::TEXT[])
+			GROUP BY lt.%s
+			HAVING count(DISTINCT t.id) = cardinality($%d::TEXT[])
+		)`, idColumn, linkColumn, linkTable, argN, linkColumn, argN)
+}
@@ -0,0 +1,59 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/etag"
+	storage_errors "quiz_backend_core/internal/storage/errors"
+)
+
+// checkVersion locks the object and compares its version with the one expected by If-Match from ctx,
+// nothing is checked for requests without expected version. table is a constant of the caller.
+// The lock waits for a concurrent change of the object, after its commit the repeatable read transaction
+// fails to serialize: the object was changed after the version was read, so it is a version mismatch too.
+func checkVersion(ctx context.Context, tx pgx.Tx, table string, id int64, notFound error) error {
+	return lockVersion(ctx, tx, table, "deleted_at IS NULL", id, notFound)
+}
+
+// checkDeletedVersion is checkVersion of an object in the trash, objects are restored by it
+func checkDeletedVersion(ctx context.Context, tx pgx.Tx, table string, id int64, notFound error) error {
+	return lockVersion(ctx, tx, table, "deleted_at IS NOT NULL", id, notFound)
+}
+
+func lockVersion(ctx context.Context, tx pgx.Tx, table, condition string, id int64, notFound error) error {
+	expected, ok := etag.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT version FROM %s WHERE id = $1 AND %s FOR UPDATE
+	`, table, condition)
+
+	var version int64
+	if err := tx.QueryRow(ctx, query, id).Scan(&version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &storage_errors.NotFoundError{Err: notFound}
+		}
+		if isSerializationFailure(err) {
+			return fmt.Errorf("%w: changed concurrently: %v", dto.ErrVersionMismatch, err)
+		}
+		return &storage_errors.ExecutionPSQLError{Err: err}
+	}
+
+	if !etag.Matches(expected, version) {
+		return fmt.Errorf("%w: current version is %d", dto.ErrVersionMismatch, version)
+	}
+
+	return nil
+}
+
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgerrcode.SerializationFailure
+}
@@ -8,9 +8,17 @@ import (
 )
 
 type Storages struct {
-	Subjects  model.SubjectsStorage
-	Questions model.QuestionsStorage
-	Quizzes   model.QuizzesStorage
+	Subjects    model.SubjectsStorage
+	Questions   model.QuestionsStorage
+	Quizzes     model.QuizzesStorage
+	Tags        model.TagsStorage
+	Attachments model.AttachmentsStorage
+	Submissions model.SubmissionsStorage
+	APITokens   model.APITokensStorage
+
+	IdempotencyKeys model.IdempotencyKeysStorage
+	Audit           model.AuditStorage
+	Outbox          model.OutboxStorage
 
 	pool *pgxpool.Pool
 }
@@ -27,9 +35,17 @@ func NewStorages(ctx context.Context, databaseDSN string) (*Storages, error) {
 	//}
 
 	return &Storages{
-		Subjects:  pg.NewSubjectsStorage(pool),
-		Questions: pg.NewQuestionsStorage(pool),
-		Quizzes:   pg.NewQuizzesStorage(pool),
+		Subjects:    pg.NewSubjectsStorage(pool),
+		Questions:   pg.NewQuestionsStorage(pool),
+		Quizzes:     pg.NewQuizzesStorage(pool),
+		Tags:        pg.NewTagsStorage(pool),
+		Attachments: pg.NewAttachmentsStorage(pool),
+		Submissions: pg.NewSubmissionsStorage(pool),
+		APITokens:   pg.NewAPITokensStorage(pool),
+
+		IdempotencyKeys: pg.NewIdempotencyKeysStorage(pool),
+		Audit:           pg.NewAuditStorage(pool),
+		Outbox:          pg.NewOutboxStorage(pool),
 
 		pool: pool,
 	}, nil
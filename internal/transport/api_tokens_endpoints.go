@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+)
+
+type GetAPITokensRequest struct {
+	UserID int64
+}
+
+func (r GetAPITokensRequest) APITokenOwner() (int64, dto.Role) { return r.UserID, "" }
+
+type GetAPITokensResponse struct {
+	Tokens []dto.APIToken `json:"tokens"`
+	Err    error          `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type PostAPITokenRequest struct {
+	UserID int64
+	Token  dto.InputAPIToken
+}
+
+func (r PostAPITokenRequest) APITokenOwner() (int64, dto.Role) { return r.Token.UserID, r.Token.Role }
+
+type PostAPITokenResponse struct {
+	Token  dto.APIToken `json:"token"`
+	Secret string       `json:"secret,omitempty"` // shown only once
+	Err    error        `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type DeleteAPITokenRequest struct {
+	ID int64
+}
+
+func (r DeleteAPITokenRequest) ResourceID() int64 { return r.ID }
+
+type DeleteAPITokenResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type APITokensEndpoints struct {
+	GetAPITokensEndpoint   endpoint.Endpoint
+	PostAPITokenEndpoint   endpoint.Endpoint
+	DeleteAPITokenEndpoint endpoint.Endpoint
+}
+
+func MakeAPITokensEndpoints(s model.APITokens, p *policy.Policy) APITokensEndpoints {
+	return APITokensEndpoints{
+		GetAPITokensEndpoint:   p.Middleware(policy.TokensManage)(MakeGetAPITokensEndpoint(s)),
+		PostAPITokenEndpoint:   p.Middleware(policy.TokensManage)(MakePostAPITokenEndpoint(s)),
+		DeleteAPITokenEndpoint: p.Middleware(policy.TokensManage)(MakeDeleteAPITokenEndpoint(s)),
+	}
+}
+
+func MakeGetAPITokensEndpoint(s model.APITokens) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetAPITokensRequest)
+		tokens, err := s.GetAPITokens(ctx, req.UserID)
+		return GetAPITokensResponse{
+			Tokens: tokens,
+			Err:    err,
+		}, err
+	}
+}
+
+func MakePostAPITokenEndpoint(s model.APITokens) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PostAPITokenRequest)
+		token, secret, err := s.CreateAPIToken(ctx, req.UserID, req.Token)
+		return PostAPITokenResponse{
+			Token:  token,
+			Secret: secret,
+			Err:    err,
+		}, err
+	}
+}
+
+func MakeDeleteAPITokenEndpoint(s model.APITokens) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DeleteAPITokenRequest)
+		err := s.RevokeAPIToken(ctx, req.ID)
+		return DeleteAPITokenResponse{
+			Err: err,
+		}, err
+	}
+}
@@ -0,0 +1,70 @@
+package transport
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"io"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+)
+
+type PostAttachmentRequest struct {
+	Attachment dto.Attachment
+	Content    []byte
+}
+
+type PostAttachmentResponse struct {
+	Attachment dto.Attachment `json:"attachment"`
+	Err        error          `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type GetAttachmentRequest struct {
+	ID int64
+}
+
+// GetAttachmentResponse is encoded as raw content, not as json
+type GetAttachmentResponse struct {
+	Attachment dto.Attachment
+	Content    io.ReadCloser
+	Err        error
+}
+
+//**********************************************************************************************************************
+
+type AttachmentsEndpoints struct {
+	PostAttachmentEndpoint endpoint.Endpoint
+	GetAttachmentEndpoint  endpoint.Endpoint
+}
+
+func MakeAttachmentsEndpoints(s model.Attachments, p *policy.Policy) AttachmentsEndpoints {
+	return AttachmentsEndpoints{
+		PostAttachmentEndpoint: p.Middleware(policy.AttachmentsUpload)(MakePostAttachmentEndpoint(s)),
+		GetAttachmentEndpoint:  p.Middleware(policy.AttachmentsRead)(MakeGetAttachmentEndpoint(s)),
+	}
+}
+
+func MakePostAttachmentEndpoint(s model.Attachments) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PostAttachmentRequest)
+		attachment, err := s.UploadAttachment(ctx, req.Attachment, req.Content)
+		return PostAttachmentResponse{
+			Attachment: attachment,
+			Err:        err,
+		}, err
+	}
+}
+
+func MakeGetAttachmentEndpoint(s model.Attachments) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetAttachmentRequest)
+		attachment, content, err := s.GetAttachment(ctx, req.ID)
+		return GetAttachmentResponse{
+			Attachment: attachment,
+			Content:    content,
+			Err:        err,
+		}, err
+	}
+}
@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+)
+
+type GetAuditEntriesRequest struct {
+	Filter dto.AuditFilter
+}
+
+type GetAuditEntriesResponse struct {
+	Entries []dto.AuditEntry `json:"entries"`
+	Err     error            `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type AuditEndpoints struct {
+	GetAuditEntriesEndpoint endpoint.Endpoint
+}
+
+func MakeAuditEndpoints(s model.Audit, p *policy.Policy) AuditEndpoints {
+	return AuditEndpoints{
+		GetAuditEntriesEndpoint: p.Middleware(policy.AuditRead)(MakeGetAuditEntriesEndpoint(s)),
+	}
+}
+
+func MakeGetAuditEntriesEndpoint(s model.Audit) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetAuditEntriesRequest)
+		entries, err := s.GetAuditEntries(ctx, req.Filter)
+		return GetAuditEntriesResponse{
+			Entries: entries,
+			Err:     err,
+		}, err
+	}
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"net/http"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
+	"quiz_backend_core/internal/service"
+	"quiz_backend_core/internal/transport"
+	"strconv"
+)
+
+func makeAPITokensHTTPHandler(s *service.Services, r *mux.Router, options []httptransport.ServerOption) {
+	e := transport.MakeAPITokensEndpoints(s.APITokens, s.Policy)
+
+	r.Methods("OPTIONS", "GET").Path("/tokens").Handler(httptransport.NewServer(
+		e.GetAPITokensEndpoint,
+		decodeGetAPITokensRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "POST").Path("/token").Handler(httptransport.NewServer(
+		e.PostAPITokenEndpoint,
+		decodePostAPITokenRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "DELETE").Path("/token/{id}").Handler(httptransport.NewServer(
+		e.DeleteAPITokenEndpoint,
+		decodeDeleteAPITokenRequest,
+		encodeResponse,
+		options...,
+	))
+}
+
+// decodeGetAPITokensRequest reads ?user_id=, admins get tokens of all users by default, other users their own
+func decodeGetAPITokensRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	tRequest := transport.GetAPITokensRequest{
+		UserID: -1,
+	}
+
+	if principal, _ := policy.FromContext(ctx); principal.Role != dto.RoleAdmin {
+		tRequest.UserID = principal.UserID
+	}
+
+	if userIdStr := r.URL.Query().Get("user_id"); userIdStr != "" {
+		if tRequest.UserID, err = strconv.ParseInt(userIdStr, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return tRequest, nil
+}
+
+func decodePostAPITokenRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	var token dto.InputAPIToken
+	if err = json.NewDecoder(r.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+
+	// by default the token is issued to the caller with the same role
+	principal, _ := policy.FromContext(ctx)
+	if token.UserID == 0 {
+		token.UserID = principal.UserID
+	}
+	if token.Role == "" && token.UserID == principal.UserID {
+		token.Role = principal.Role
+	}
+
+	return transport.PostAPITokenRequest{
+		UserID: principal.UserID,
+		Token:  token,
+	}, nil
+}
+
+func decodeDeleteAPITokenRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	idStr, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.DeleteAPITokenRequest{
+		ID: id,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"io"
+	"mime"
+	"net/http"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
+	"quiz_backend_core/internal/service"
+	"quiz_backend_core/internal/transport"
+	"strconv"
+)
+
+// multipartOverhead is allowed on top of the attachment size for multipart headers and boundaries
+const multipartOverhead = 1 << 20
+
+func makeAttachmentsHTTPHandler(s *service.Services, r *mux.Router, options []httptransport.ServerOption, maxSize int64) {
+	e := transport.MakeAttachmentsEndpoints(s.Attachments, s.Policy)
+
+	r.Methods("OPTIONS", "POST").Path("/attachment").Handler(httptransport.NewServer(
+		e.PostAttachmentEndpoint,
+		makeDecodePostAttachmentRequest(maxSize),
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/attachment/{id}").Handler(httptransport.NewServer(
+		e.GetAttachmentEndpoint,
+		decodeGetAttachmentRequest,
+		encodeAttachmentResponse,
+		options...,
+	))
+}
+
+// makeDecodePostAttachmentRequest reads multipart form with "file" field, body is limited by maxSize
+func makeDecodePostAttachmentRequest(maxSize int64) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		r.Body = http.MaxBytesReader(nil, r.Body, maxSize+multipartOverhead)
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		// one byte more than allowed, so the service can detect too large content
+		content, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+		if err != nil {
+			return nil, err
+		}
+
+		var attachment dto.Attachment
+		attachment.FileName = header.Filename
+		principal, _ := policy.FromContext(ctx)
+		attachment.CreatorUserID = principal.UserID
+
+		return transport.PostAttachmentRequest{
+			Attachment: attachment,
+			Content:    content,
+		}, nil
+	}
+}
+
+func decodeGetAttachmentRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	idStr, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.GetAttachmentRequest{
+		ID: id,
+	}, nil
+}
+
+func encodeAttachmentResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(transport.GetAttachmentResponse)
+	defer resp.Content.Close()
+
+	w.Header().Set("Content-Type", resp.Attachment.MimeType)
+	w.Header().Set("Content-Length", fmt.Sprint(resp.Attachment.Size))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": resp.Attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	_, err := io.Copy(w, resp.Content)
+	return err
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"net/http"
+	"quiz_backend_core/internal/audit"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
+	"quiz_backend_core/internal/service"
+	"quiz_backend_core/internal/transport"
+	"strconv"
+	"time"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+func makeAuditHTTPHandler(s *service.Services, r *mux.Router, options []httptransport.ServerOption) {
+	e := transport.MakeAuditEndpoints(s.Audit, s.Policy)
+
+	r.Methods("OPTIONS", "GET").Path("/entries").Handler(httptransport.NewServer(
+		e.GetAuditEntriesEndpoint,
+		decodeGetAuditEntriesRequest,
+		encodeResponse,
+		options...,
+	))
+}
+
+// decodeGetAuditEntriesRequest reads entity_type, entity_id, actor_user_id, from, to (RFC 3339), limit and offset
+func decodeGetAuditEntriesRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	query := r.URL.Query()
+	filter := dto.NewAuditFilter()
+
+	filter.EntityType = dto.AuditEntity(query.Get("entity_type"))
+
+	if entityIdStr := query.Get("entity_id"); entityIdStr != "" {
+		if filter.EntityID, err = strconv.ParseInt(entityIdStr, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	if actorIdStr := query.Get("actor_user_id"); actorIdStr != "" {
+		if filter.ActorUserID, err = strconv.ParseInt(actorIdStr, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		if filter.From, err = time.Parse(time.RFC3339, fromStr); err != nil {
+			return nil, err
+		}
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		if filter.To, err = time.Parse(time.RFC3339, toStr); err != nil {
+			return nil, err
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if filter.Limit, err = strconv.Atoi(limitStr); err != nil {
+			return nil, err
+		}
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if filter.Offset, err = strconv.Atoi(offsetStr); err != nil {
+			return nil, err
+		}
+	}
+
+	return transport.GetAuditEntriesRequest{
+		Filter: filter,
+	}, nil
+}
+
+// requestIDMiddleware keeps X-Request-ID of the client or makes a new one, the id is sent back and written
+// to the audit log
+func requestIDMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			requestID = hex.EncodeToString(b)
+			r.Header.Set(requestIDHeader, requestID)
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// auditActorToContext puts the principal and the request id to the context for the audit log,
+// it goes after principalToContext
+func auditActorToContext(ctx context.Context, r *http.Request) context.Context {
+	principal, _ := policy.FromContext(ctx)
+
+	return audit.NewContext(ctx, audit.Actor{
+		UserID:    principal.UserID,
+		Role:      principal.Role,
+		RequestID: r.Header.Get(requestIDHeader),
+	})
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/proger567/quiz_backend_middleware"
+	"net/http"
+	"quiz_backend_core/internal/config"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/pkg/jwt"
+	"strconv"
+	"strings"
+)
+
+// jwtAuthMiddleware verifies bearer token and fills user id and role to the same context keys
+// as quiz_backend_middleware.FillContextMiddleware does, so it can be used instead of it
+func jwtAuthMiddleware(verifier *jwt.Verifier, cfg *config.Config) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				unauthorized(w, "bearer token is required")
+				return
+			}
+
+			claims, err := verifier.Verify(strings.TrimSpace(token))
+			if err != nil {
+				unauthorized(w, err.Error())
+				return
+			}
+
+			userIDStr, _ := claims.String(cfg.JWTUserIDClaim)
+			userID, err := strconv.ParseInt(userIDStr, 10, 64)
+			if err != nil || userID <= 0 {
+				unauthorized(w, "token has no valid "+cfg.JWTUserIDClaim+" claim")
+				return
+			}
+
+			role, _ := claims.String(cfg.JWTRoleClaim)
+			if !dto.Role(role).IsKnown() {
+				unauthorized(w, "token has no valid "+cfg.JWTRoleClaim+" claim")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), quiz_backend_middleware.ContextVariablesUserID, userID)
+			ctx = context.WithValue(ctx, quiz_backend_middleware.ContextVariablesUserRole, role)
+
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+type apiTokenScopesKey struct{}
+
+// apiTokenAuthMiddleware authenticates requests with api token in bearer header, other requests
+// go through the auth middleware of the configured mode
+func apiTokenAuthMiddleware(tokens model.APITokens, next mux.MiddlewareFunc) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		fallback := next(h)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "+dto.APITokenPrefix)
+			if !ok {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+
+			token, err := tokens.AuthenticateAPIToken(r.Context(), dto.APITokenPrefix+strings.TrimSpace(secret))
+			if err != nil {
+				if errors.Is(err, dto.ErrInvalidAPIToken) {
+					unauthorized(w, dto.ErrInvalidAPIToken.Error())
+					return
+				}
+				response := errorResponse(err)
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(response.Code)
+				_ = json.NewEncoder(w).Encode(response)
+				return
+			}
+
+			scopes := token.Scopes
+			if scopes == nil {
+				scopes = []string{}
+			}
+
+			ctx := context.WithValue(r.Context(), quiz_backend_middleware.ContextVariablesUserID, token.UserID)
+			ctx = context.WithValue(ctx, quiz_backend_middleware.ContextVariablesUserRole, string(token.Role))
+			ctx = context.WithValue(ctx, apiTokenScopesKey{}, scopes)
+
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{
+		Code:    http.StatusUnauthorized,
+		Message: message,
+		Error:   string(dto.KindUnauthorized),
+	})
+}
@@ -3,14 +3,30 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/sirupsen/logrus"
+	"io"
 	"net/http"
+	"quiz_backend_core/internal/dto"
+	"strconv"
+	"time"
 )
 
+// ErrorResponse is the body of every failed request, Error is a machine-readable code
+// like "question_not_found", Fields point to the invalid request fields and Similar lists
+// existing questions of the "question_duplicate" error
 type ErrorResponse struct {
-	Code    int    `json:"code"`
+	Code    int                   `json:"code"`
+	Message string                `json:"message"`
+	Error   string                `json:"error,omitempty"`
+	Fields  []FieldIssue          `json:"fields,omitempty"`
+	Similar []dto.SimilarQuestion `json:"similar,omitempty"`
+}
+
+type FieldIssue struct {
+	Field   string `json:"field"`
+	Error   string `json:"error"`
 	Message string `json:"message"`
-	Error   string `json:"error,omitempty"`
 }
 
 func errorEncoder(logger *logrus.Logger) func(context.Context, error, http.ResponseWriter) {
@@ -18,10 +34,7 @@ func errorEncoder(logger *logrus.Logger) func(context.Context, error, http.Respo
 		if err == nil {
 			panic("encodeError with nil error")
 		}
-		response := ErrorResponse{
-			Code:    codeFrom(err),
-			Message: err.Error(),
-		}
+		response := errorResponse(err)
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(response.Code)
@@ -34,27 +47,80 @@ func errorEncoder(logger *logrus.Logger) func(context.Context, error, http.Respo
 	}
 }
 
-// TODO TODO TODO
-func codeFrom(err error) int {
+func errorResponse(err error) ErrorResponse {
+	kind := kindOf(err)
+
+	response := ErrorResponse{
+		Code:    statusFrom(kind),
+		Message: err.Error(),
+		Error:   string(kind),
+	}
+
+	var domainErr *dto.Error
+	if errors.As(err, &domainErr) {
+		response.Error = domainErr.Code
+	}
+
+	var duplicateErr *dto.DuplicateQuestionError
+	if errors.As(err, &duplicateErr) {
+		response.Similar = duplicateErr.Similar
+	}
+
+	for _, field := range dto.FieldsOf(err) {
+		response.Fields = append(response.Fields, FieldIssue{
+			Field:   field.Field,
+			Error:   dto.CodeOf(field.Err),
+			Message: field.Err.Error(),
+		})
+	}
+
+	return response
+}
+
+// kindOf is dto.KindOf which also treats errors of request decoders as bad request
+func kindOf(err error) dto.Kind {
+	var numErr *strconv.NumError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+	var timeErr *time.ParseError
+
 	switch {
-	//case errors.Is(err, dto.ErrUserNotFound):
-	//	return http.StatusNotFound
+	case errors.As(err, &maxBytesErr):
+		return dto.KindTooLarge
+	case errors.As(err, &numErr), errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.As(err, &timeErr),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, http.ErrMissingFile):
+		return dto.KindBadRequest
 	default:
-		return http.StatusInternalServerError
+		return dto.KindOf(err)
 	}
 }
 
-//func codeFrom(err error) int {
-//	switch {
-//	case errors.Is(err, ErrNotFound):
-//		return http.StatusNotFound
-//	case errors.Is(err, ErrAlreadyExists), errors.Is(err, ErrInconsistentIDs):
-//		return http.StatusBadRequest
-//	case errors.Is(err, ErrForbidden):
-//		return http.StatusForbidden
-//	case errors.Is(err, ErrPreconditionRequired):
-//		return http.StatusPreconditionRequired
-//	default:
-//		return http.StatusInternalServerError
-//	}
-//}
+func statusFrom(kind dto.Kind) int {
+	switch kind {
+	case dto.KindBadRequest:
+		return http.StatusBadRequest
+	case dto.KindValidation:
+		return http.StatusUnprocessableEntity
+	case dto.KindUnauthorized:
+		return http.StatusUnauthorized
+	case dto.KindForbidden:
+		return http.StatusForbidden
+	case dto.KindNotFound:
+		return http.StatusNotFound
+	case dto.KindConflict:
+		return http.StatusConflict
+	case dto.KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case dto.KindPreconditionRequired:
+		return http.StatusPreconditionRequired
+	case dto.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case dto.KindUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case dto.KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
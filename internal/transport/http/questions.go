@@ -6,14 +6,17 @@ import (
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 	"net/http"
+	"net/url"
 	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
 	"quiz_backend_core/internal/service"
 	"quiz_backend_core/internal/transport"
 	"strconv"
 )
 
 func makeQuestionsHTTPHandler(s *service.Services, r *mux.Router, options []httptransport.ServerOption) {
-	e := transport.MakeQuestionsEndpoints(s.Questions)
+	e := transport.MakeQuestionsEndpoints(s.Questions, s.Policy, s.Validator, s.Idempotency)
+	versioned := versionedOptions(options)
 
 	r.Methods("OPTIONS", "GET").Path("/questions").Handler(httptransport.NewServer(
 		e.GetQuestionsEndpoint,
@@ -22,6 +25,27 @@ func makeQuestionsHTTPHandler(s *service.Services, r *mux.Router, options []http
 		options...,
 	))
 
+	r.Methods("OPTIONS", "GET").Path("/trash").Handler(httptransport.NewServer(
+		e.GetDeletedQuestionsEndpoint,
+		decodeGetDeletedQuestionsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/search").Handler(httptransport.NewServer(
+		e.SearchQuestionsEndpoint,
+		decodeSearchQuestionsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/duplicates").Handler(httptransport.NewServer(
+		e.GetDuplicateClustersEndpoint,
+		decodeGetDuplicateClustersRequest,
+		encodeResponse,
+		options...,
+	))
+
 	r.Methods("OPTIONS", "GET").Path("/types").Handler(httptransport.NewServer(
 		e.GetQuestionTypesEndpoint,
 		decodeGetTypesRequest,
@@ -43,54 +67,202 @@ func makeQuestionsHTTPHandler(s *service.Services, r *mux.Router, options []http
 		options...,
 	))
 
+	r.Methods("OPTIONS", "GET").Path("/question/{id}").Handler(httptransport.NewServer(
+		e.GetQuestionEndpoint,
+		decodeGetQuestionRequest,
+		encodeResponse,
+		options...,
+	))
+
 	r.Methods("OPTIONS", "PUT").Path("/question/{id}").Handler(httptransport.NewServer(
 		e.PutQuestionEndpoint,
-		decodePutQuestionRequest,
+		requireIfMatch(decodePutQuestionRequest),
+		encodeResponse,
+		versioned...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/question/{id}/code").Handler(httptransport.NewServer(
+		e.GetQuestionCodeEndpoint,
+		decodeGetQuestionCodeRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/question/{id}/render").Handler(httptransport.NewServer(
+		e.GetRenderedQuestionEndpoint,
+		decodeGetRenderedQuestionRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "POST").Path("/question/{id}/check").Handler(httptransport.NewServer(
+		e.PostParametricCheckEndpoint,
+		decodePostParametricCheckRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "POST").Path("/question/{id}/grade").Handler(httptransport.NewServer(
+		e.PostGradeAnswerEndpoint,
+		decodePostGradeAnswerRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "POST").Path("/question/{id}/clone").Handler(httptransport.NewServer(
+		e.CloneQuestionEndpoint,
+		decodeCloneQuestionRequest,
 		encodeResponse,
 		options...,
 	))
 
 	r.Methods("OPTIONS", "PUT").Path("/question/{id}/moderate").Handler(httptransport.NewServer( //TODO
 		e.PutQuestionModerateEndpoint,
-		decodePutQuestionModerateRequest,
+		requireIfMatch(decodePutQuestionModerateRequest),
 		encodeResponse,
-		options...,
+		versioned...,
+	))
+
+	r.Methods("OPTIONS", "PUT").Path("/question/{id}/restore").Handler(httptransport.NewServer(
+		e.RestoreQuestionEndpoint,
+		requireIfMatch(decodeRestoreQuestionRequest),
+		encodeResponse,
+		versioned...,
 	))
 
 	r.Methods("OPTIONS", "DELETE").Path("/question/{id}").Handler(httptransport.NewServer(
 		e.DeleteQuestionEndpoint,
-		decodeDeleteQuestionRequest,
+		requireIfMatch(decodeDeleteQuestionRequest),
 		encodeResponse,
-		options...,
+		versioned...,
 	))
 }
 
-func decodeGetQuestionsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+// decodeQuestionFilter reads common questions list filters from query parameters
+func decodeQuestionFilter(query url.Values) (filter dto.QuestionFilter, err error) {
 	//defaults
-	dRequest := transport.GetQuestionsRequest{
-		SubjectID:     -1,
-		CreatorUserID: -1,
-		StatusID:      -1,
-	}
-
-	query := r.URL.Query()
+	filter = dto.NewQuestionFilter()
 
 	// TODO check errors
 	if subjectIdStr := query.Get("subject_id"); subjectIdStr != "" {
-		if dRequest.SubjectID, err = strconv.ParseInt(subjectIdStr, 10, 64); err != nil {
-			return dRequest, err
+		if filter.SubjectID, err = strconv.ParseInt(subjectIdStr, 10, 64); err != nil {
+			return filter, err
 		}
 	}
 
 	if userIdStr := query.Get("creator_user_id"); userIdStr != "" {
-		if dRequest.CreatorUserID, err = strconv.ParseInt(userIdStr, 10, 64); err != nil {
-			return dRequest, err
+		if filter.CreatorUserID, err = strconv.ParseInt(userIdStr, 10, 64); err != nil {
+			return filter, err
 		}
 	}
 
 	if statusIdStr := query.Get("status_id"); statusIdStr != "" {
-		if dRequest.StatusID, err = strconv.ParseInt(statusIdStr, 10, 64); err != nil {
-			return dRequest, err
+		if filter.StatusID, err = strconv.ParseInt(statusIdStr, 10, 64); err != nil {
+			return filter, err
+		}
+	}
+
+	if typeIdStr := query.Get("type_id"); typeIdStr != "" {
+		if filter.TypeID, err = strconv.ParseInt(typeIdStr, 10, 64); err != nil {
+			return filter, err
+		}
+	}
+
+	if tags, ok := query["tag"]; ok {
+		filter.Tags = tags
+	}
+
+	if subtreeStr := query.Get("include_subsubjects"); subtreeStr != "" {
+		if filter.IncludeSubSubjects, err = strconv.ParseBool(subtreeStr); err != nil {
+			return filter, err
+		}
+	}
+
+	if minDifficultyStr := query.Get("min_difficulty"); minDifficultyStr != "" {
+		if filter.MinDifficulty, err = strconv.Atoi(minDifficultyStr); err != nil {
+			return filter, err
+		}
+	}
+
+	if maxDifficultyStr := query.Get("max_difficulty"); maxDifficultyStr != "" {
+		if filter.MaxDifficulty, err = strconv.Atoi(maxDifficultyStr); err != nil {
+			return filter, err
+		}
+	}
+
+	for _, level := range query["bloom_level"] {
+		filter.BloomLevels = append(filter.BloomLevels, dto.BloomLevel(level))
+	}
+
+	return filter, nil
+}
+
+func decodeGetQuestionsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	filter, err := decodeQuestionFilter(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.GetQuestionsRequest{
+		Filter: filter,
+	}, nil
+}
+
+func decodeGetDeletedQuestionsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	filter, err := decodeQuestionFilter(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.GetDeletedQuestionsRequest{
+		Filter: filter,
+	}, nil
+}
+
+func decodeSearchQuestionsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	query := r.URL.Query()
+
+	filter, err := decodeQuestionFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	dRequest := transport.SearchQuestionsRequest{
+		Query:  query.Get("q"),
+		Filter: filter,
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if dRequest.Limit, err = strconv.Atoi(limitStr); err != nil {
+			return nil, err
+		}
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if dRequest.Offset, err = strconv.Atoi(offsetStr); err != nil {
+			return nil, err
+		}
+	}
+
+	return dRequest, nil
+}
+
+func decodeGetDuplicateClustersRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	dRequest := transport.GetDuplicateClustersRequest{
+		SubjectID: -1,
+	}
+
+	query := r.URL.Query()
+
+	if subjectIdStr := query.Get("subject_id"); subjectIdStr != "" {
+		if dRequest.SubjectID, err = strconv.ParseInt(subjectIdStr, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	if thresholdStr := query.Get("threshold"); thresholdStr != "" {
+		if dRequest.Threshold, err = strconv.ParseFloat(thresholdStr, 64); err != nil {
+			return nil, err
 		}
 	}
 
@@ -111,8 +283,11 @@ func decodePostQuestionRequest(_ context.Context, r *http.Request) (request inte
 		return nil, err
 	}
 
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
 	return transport.PostQuestionRequest{
 		Question: question,
+		Force:    force,
 	}, nil
 }
 
@@ -167,6 +342,149 @@ func decodePutQuestionModerateRequest(_ context.Context, r *http.Request) (reque
 	}, nil
 }
 
+func decodeGetQuestionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	questionIdStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	questionId, err := strconv.ParseInt(questionIdStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.GetQuestionRequest{
+		ID: questionId,
+	}, nil
+}
+
+func decodeGetQuestionCodeRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	questionIdStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	questionId, err := strconv.ParseInt(questionIdStr, 10, 64)
+	if err != nil {
+		return nil, err //TODO wrap error with dto.ErrBadRouting?
+	}
+
+	return transport.GetQuestionCodeRequest{
+		ID: questionId,
+	}, nil
+}
+
+// decodeQuestionSeed reads seed of parametric question, the user id is used by default,
+// so a learner always gets the same values
+func decodeQuestionSeed(ctx context.Context, seedStr string) (int64, error) {
+	if seedStr == "" {
+		principal, _ := policy.FromContext(ctx)
+		return principal.UserID, nil
+	}
+	return strconv.ParseInt(seedStr, 10, 64)
+}
+
+func decodeGetRenderedQuestionRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	questionIdStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	questionId, err := strconv.ParseInt(questionIdStr, 10, 64)
+	if err != nil {
+		return nil, err //TODO wrap error with dto.ErrBadRouting?
+	}
+
+	seed, err := decodeQuestionSeed(ctx, r.URL.Query().Get("seed"))
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.GetRenderedQuestionRequest{
+		ID:   questionId,
+		Seed: seed,
+	}, nil
+}
+
+func decodePostParametricCheckRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	var check = struct {
+		Seed  string  `json:"seed,omitempty"`
+		Value float64 `json:"value"`
+	}{}
+
+	if err = json.NewDecoder(r.Body).Decode(&check); err != nil {
+		return nil, err
+	}
+
+	vars := mux.Vars(r)
+	questionIdStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	questionId, err := strconv.ParseInt(questionIdStr, 10, 64)
+	if err != nil {
+		return nil, err //TODO wrap error with dto.ErrBadRouting?
+	}
+
+	seed, err := decodeQuestionSeed(ctx, check.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.PostParametricCheckRequest{
+		ID:    questionId,
+		Seed:  seed,
+		Value: check.Value,
+	}, nil
+}
+
+func decodePostGradeAnswerRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var grade = struct {
+		Response map[string]interface{} `json:"response"`
+	}{}
+
+	if err = json.NewDecoder(r.Body).Decode(&grade); err != nil {
+		return nil, err
+	}
+
+	vars := mux.Vars(r)
+	questionIdStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	questionId, err := strconv.ParseInt(questionIdStr, 10, 64)
+	if err != nil {
+		return nil, err //TODO wrap error with dto.ErrBadRouting?
+	}
+
+	return transport.PostGradeAnswerRequest{
+		ID:       questionId,
+		Response: grade.Response,
+	}, nil
+}
+
+func decodeCloneQuestionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	questionIdStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	questionId, err := strconv.ParseInt(questionIdStr, 10, 64)
+	if err != nil {
+		return nil, err //TODO wrap error with dto.ErrBadRouting?
+	}
+
+	return transport.CloneQuestionRequest{
+		ID: questionId,
+	}, nil
+}
+
 func decodeDeleteQuestionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	vars := mux.Vars(r)
 	questionIdStr, ok := vars["id"]
@@ -183,3 +501,20 @@ func decodeDeleteQuestionRequest(_ context.Context, r *http.Request) (request in
 		ID: questionId,
 	}, nil
 }
+
+func decodeRestoreQuestionRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	questionIdStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	questionId, err := strconv.ParseInt(questionIdStr, 10, 64)
+	if err != nil {
+		return nil, err //TODO wrap error with dto.ErrBadRouting?
+	}
+
+	return transport.RestoreQuestionRequest{
+		ID: questionId,
+	}, nil
+}
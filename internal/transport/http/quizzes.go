@@ -3,17 +3,22 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
+	"io"
 	"net/http"
 	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
 	"quiz_backend_core/internal/service"
 	"quiz_backend_core/internal/transport"
 	"strconv"
+	"strings"
 )
 
 func makeQuizzesHTTPHandler(s *service.Services, r *mux.Router, options []httptransport.ServerOption) {
-	e := transport.MakeQuizzesEndpoints(s.Quizzes)
+	e := transport.MakeQuizzesEndpoints(s.Quizzes, s.Policy, s.Validator, s.Idempotency)
+	versioned := versionedOptions(options)
 
 	r.Methods("OPTIONS", "GET").Path("/quizzes").Handler(httptransport.NewServer(
 		e.GetQuizzesEndpoint,
@@ -22,6 +27,13 @@ func makeQuizzesHTTPHandler(s *service.Services, r *mux.Router, options []httptr
 		options...,
 	))
 
+	r.Methods("OPTIONS", "GET").Path("/trash").Handler(httptransport.NewServer(
+		e.GetDeletedQuizzesEndpoint,
+		decodeGetDeletedQuizzesRequest,
+		encodeResponse,
+		options...,
+	))
+
 	r.Methods("OPTIONS", "GET").Path("/{id}/questions").Handler(httptransport.NewServer(
 		e.GetQuestionsByQuizIDEndpoint,
 		decodeGetQuestionsByQuizIDRequest,
@@ -43,11 +55,25 @@ func makeQuizzesHTTPHandler(s *service.Services, r *mux.Router, options []httptr
 		options...,
 	))
 
+	r.Methods("OPTIONS", "POST").Path("/{id}/clone").Handler(httptransport.NewServer(
+		e.CloneQuizEndpoint,
+		decodeCloneQuizRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "PUT").Path("/{id}/restore").Handler(httptransport.NewServer(
+		e.RestoreQuizEndpoint,
+		requireIfMatch(decodeRestoreQuizRequest),
+		encodeResponse,
+		versioned...,
+	))
+
 	r.Methods("OPTIONS", "DELETE").Path("/{id}").Handler(httptransport.NewServer(
 		e.DeleteQuizEndpoint,
-		decodeDeleteQuizByIDRequest,
+		requireIfMatch(decodeDeleteQuizByIDRequest),
 		encodeResponse,
-		options...,
+		versioned...,
 	))
 }
 
@@ -66,6 +92,10 @@ func decodeGetQuizzesRequest(_ context.Context, r *http.Request) (request interf
 		}
 	}
 
+	if tags, ok := query["tag"]; ok {
+		dRequest.Tags = tags
+	}
+
 	return dRequest, nil
 }
 
@@ -130,3 +160,71 @@ func decodeDeleteQuizByIDRequest(_ context.Context, r *http.Request) (request in
 		QuizID: quizId,
 	}, nil
 }
+
+// decodeCloneQuizRequest reads optional {"name": "..."} body, without it the copy keeps the source name
+func decodeCloneQuizRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	var clone = struct {
+		Name string `json:"name,omitempty"`
+	}{}
+
+	if err = json.NewDecoder(r.Body).Decode(&clone); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	vars := mux.Vars(r)
+	quizIdStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	quizId, err := strconv.ParseInt(quizIdStr, 10, 64)
+	if err != nil {
+		return nil, err //TODO wrap error with dto.ErrBadRouting?
+	}
+
+	principal, _ := policy.FromContext(ctx)
+
+	return transport.CloneQuizRequest{
+		UserID: principal.UserID,
+		QuizID: quizId,
+		Name:   strings.TrimSpace(clone.Name),
+	}, nil
+}
+
+// decodeGetDeletedQuizzesRequest reads ?creator_user_id=, staff can list quizzes of any creator,
+// other users always get their own deleted quizzes
+func decodeGetDeletedQuizzesRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	//defaults
+	dRequest := transport.GetDeletedQuizzesRequest{
+		CreatorUserID: -1,
+	}
+
+	if userIdStr := r.URL.Query().Get("creator_user_id"); userIdStr != "" {
+		if dRequest.CreatorUserID, err = strconv.ParseInt(userIdStr, 10, 64); err != nil {
+			return dRequest, err
+		}
+	}
+
+	if principal, _ := policy.FromContext(ctx); !principal.IsStaff() {
+		dRequest.CreatorUserID = principal.UserID
+	}
+
+	return dRequest, nil
+}
+
+func decodeRestoreQuizRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	quizIdStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	quizId, err := strconv.ParseInt(quizIdStr, 10, 64)
+	if err != nil {
+		return nil, err //TODO wrap error with dto.ErrBadRouting?
+	}
+
+	return transport.RestoreQuizRequest{
+		QuizID: quizId,
+	}, nil
+}
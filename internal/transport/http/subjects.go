@@ -5,16 +5,17 @@ import (
 	"encoding/json"
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
-	"github.com/proger567/quiz_backend_middleware"
 	"net/http"
 	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
 	"quiz_backend_core/internal/service"
 	"quiz_backend_core/internal/transport"
 	"strconv"
 )
 
 func makeSubjectsHTTPHandler(s *service.Services, r *mux.Router, options []httptransport.ServerOption) {
-	e := transport.MakeSubjectsEndpoints(s.Subjects)
+	e := transport.MakeSubjectsEndpoints(s.Subjects, s.Policy, s.Validator)
+	versioned := versionedOptions(options)
 
 	r.Methods("OPTIONS", "GET").Path("/subjects").Handler(httptransport.NewServer(
 		e.GetSubjectsEndpoint,
@@ -23,6 +24,13 @@ func makeSubjectsHTTPHandler(s *service.Services, r *mux.Router, options []httpt
 		options...,
 	))
 
+	r.Methods("OPTIONS", "GET").Path("/tree").Handler(httptransport.NewServer(
+		e.GetSubjectTreeEndpoint,
+		decodeGetSubjectTreeRequest,
+		encodeResponse,
+		options...,
+	))
+
 	r.Methods("OPTIONS", "POST").Path("/subject").Handler(httptransport.NewServer(
 		e.PostSubjectEndpoint,
 		decodePostSubjectRequest,
@@ -30,16 +38,79 @@ func makeSubjectsHTTPHandler(s *service.Services, r *mux.Router, options []httpt
 		options...,
 	))
 
+	r.Methods("OPTIONS", "GET").Path("/subject/{id}").Handler(httptransport.NewServer(
+		e.GetSubjectEndpoint,
+		decodeGetSubjectRequest,
+		encodeResponse,
+		options...,
+	))
+
 	r.Methods("OPTIONS", "PUT").Path("/subject").Handler(httptransport.NewServer(
 		e.PutSubjectEndpoint,
-		decodePutSubjectRequest,
+		requireIfMatch(decodePutSubjectRequest),
 		encodeResponse,
-		options...,
+		versioned...,
 	))
 
 	r.Methods("OPTIONS", "DELETE").Path("/subject/{id}").Handler(httptransport.NewServer(
 		e.DeleteSubjectEndpoint,
-		decodeDeleteSubjectRequest,
+		requireIfMatch(decodeDeleteSubjectRequest),
+		encodeResponse,
+		versioned...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/subject/{id}/delete-preview").Handler(httptransport.NewServer(
+		e.GetSubjectDeletePreviewEndpoint,
+		decodeGetSubjectDeletePreviewRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "PUT").Path("/subject/{id}/move").Handler(httptransport.NewServer(
+		e.MoveSubjectEndpoint,
+		requireIfMatch(decodeMoveSubjectRequest),
+		encodeResponse,
+		versioned...,
+	))
+
+	r.Methods("OPTIONS", "POST").Path("/subject/{id}/merge").Handler(httptransport.NewServer(
+		e.MergeSubjectsEndpoint,
+		requireIfMatch(decodeMergeSubjectsRequest),
+		encodeResponse,
+		versioned...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/trash").Handler(httptransport.NewServer(
+		e.GetDeletedSubjectsEndpoint,
+		decodeGetDeletedSubjectsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "PUT").Path("/subject/{id}/restore").Handler(httptransport.NewServer(
+		e.RestoreSubjectEndpoint,
+		requireIfMatch(decodeRestoreSubjectRequest),
+		encodeResponse,
+		versioned...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/subject/{id}/maintainers").Handler(httptransport.NewServer(
+		e.GetSubjectMaintainersEndpoint,
+		decodeGetSubjectMaintainersRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "PUT").Path("/subject/{id}/maintainers/{user_id}").Handler(httptransport.NewServer(
+		e.GrantSubjectMaintainerEndpoint,
+		decodeSubjectMaintainerRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "DELETE").Path("/subject/{id}/maintainers/{user_id}").Handler(httptransport.NewServer(
+		e.RevokeSubjectMaintainerEndpoint,
+		decodeSubjectMaintainerRequest,
 		encodeResponse,
 		options...,
 	))
@@ -56,31 +127,104 @@ func decodeGetSubjectsRequest(_ context.Context, r *http.Request) (interface{},
 	return transport.GetSubjectsRequest{}, nil
 }
 
-func decodePostSubjectRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeGetSubjectTreeRequest reads root_id (the whole forest by default) and depth, the number of levels
+// below the root: depth=1 returns the root with its children, 0 means no limit
+func decodeGetSubjectTreeRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	//defaults
+	dRequest := transport.GetSubjectTreeRequest{
+		RootID: -1,
+	}
+
+	query := r.URL.Query()
+
+	if rootStr := query.Get("root_id"); rootStr != "" {
+		if dRequest.RootID, err = strconv.ParseInt(rootStr, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	if depthStr := query.Get("depth"); depthStr != "" {
+		if dRequest.Depth, err = strconv.Atoi(depthStr); err != nil {
+			return nil, err
+		}
+	}
+
+	return dRequest, nil
+}
+
+func decodePostSubjectRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	var subject dto.Subject
 	if err := json.NewDecoder(r.Body).Decode(&subject); err != nil {
 		return nil, err
 	}
 
-	subject.CreatorUserId, _ = r.Context().Value(quiz_backend_middleware.ContextVariablesUserID).(int64)
+	principal, _ := policy.FromContext(ctx)
 
 	return transport.PostSubjectRequest{
+		UserID:  principal.UserID,
 		Subject: subject,
 	}, nil
 }
 
-func decodePutSubjectRequest(_ context.Context, r *http.Request) (interface{}, error) {
+func decodePutSubjectRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	var subject dto.Subject
 	if err := json.NewDecoder(r.Body).Decode(&subject); err != nil {
 		return nil, err
 	}
 
+	principal, _ := policy.FromContext(ctx)
+
 	return transport.PutSubjectRequest{
+		UserID:  principal.UserID,
 		Subject: subject,
 	}, nil
 }
 
-func decodeDeleteSubjectRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+func decodeGetSubjectRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	idStr, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.GetSubjectRequest{ID: id}, nil
+}
+
+func decodeDeleteSubjectRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	idStr, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, _ := policy.FromContext(ctx)
+
+	// defaults
+	dRequest := transport.DeleteSubjectRequest{
+		UserID:          principal.UserID,
+		ID:              id,
+		Mode:            dto.SubjectDeleteMode(r.URL.Query().Get("mode")),
+		TargetSubjectID: -1,
+	}
+
+	if targetStr := r.URL.Query().Get("target_subject_id"); targetStr != "" {
+		if dRequest.TargetSubjectID, err = strconv.ParseInt(targetStr, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return dRequest, nil
+}
+
+func decodeGetSubjectDeletePreviewRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	idStr, ok := mux.Vars(r)["id"]
 	if !ok {
 		return nil, dto.ErrBadRouting
@@ -91,17 +235,146 @@ func decodeDeleteSubjectRequest(_ context.Context, r *http.Request) (request int
 		return nil, err
 	}
 
-	return transport.DeleteSubjectRequest{
+	return transport.GetSubjectDeletePreviewRequest{
 		ID: id,
 	}, nil
 }
 
-func decodeGetStatisticRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	userID, _ := r.Context().Value(quiz_backend_middleware.ContextVariablesUserID).(int64)
-	userRole, _ := r.Context().Value(quiz_backend_middleware.ContextVariablesUserRole).(string)
+// decodeMoveSubjectRequest reads {"parent_id": "..."}, empty or "0" parent means the top level
+func decodeMoveSubjectRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	var move = struct {
+		ParentID int64 `json:"parent_id,string,omitempty"`
+	}{}
+
+	if err = json.NewDecoder(r.Body).Decode(&move); err != nil {
+		return nil, err
+	}
+
+	idStr, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, _ := policy.FromContext(ctx)
+
+	return transport.MoveSubjectRequest{
+		UserID:   principal.UserID,
+		ID:       id,
+		ParentID: move.ParentID,
+	}, nil
+}
+
+// decodeMergeSubjectsRequest reads {"target_id": "..."}, the subject from the path is merged into the target,
+// If-Match is the version of the merged subject
+func decodeMergeSubjectsRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	var merge = struct {
+		TargetID int64 `json:"target_id,string"`
+	}{}
+
+	if err = json.NewDecoder(r.Body).Decode(&merge); err != nil {
+		return nil, err
+	}
+
+	idStr, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, _ := policy.FromContext(ctx)
+
+	return transport.MergeSubjectsRequest{
+		UserID:   principal.UserID,
+		SourceID: id,
+		TargetID: merge.TargetID,
+	}, nil
+}
+
+func decodeGetDeletedSubjectsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return transport.GetDeletedSubjectsRequest{}, nil
+}
+
+func decodeRestoreSubjectRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	idStr, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, _ := policy.FromContext(ctx)
+
+	return transport.RestoreSubjectRequest{
+		UserID: principal.UserID,
+		ID:     id,
+	}, nil
+}
+
+func decodeGetSubjectMaintainersRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	idStr, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.GetSubjectMaintainersRequest{
+		SubjectID: id,
+	}, nil
+}
+
+// decodeSubjectMaintainerRequest is used both for grant and revoke
+func decodeSubjectMaintainerRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	idStr, ok := vars["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	maintainerStr, ok := vars["user_id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	maintainerUserID, err := strconv.ParseInt(maintainerStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, _ := policy.FromContext(ctx)
+
+	return transport.SubjectMaintainerRequest{
+		UserID:           principal.UserID,
+		SubjectID:        id,
+		MaintainerUserID: maintainerUserID,
+	}, nil
+}
+
+func decodeGetStatisticRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	principal, _ := policy.FromContext(ctx)
 
 	return transport.GetStatisticRequest{
-		UserId:   userID,
-		UserRole: dto.Role(userRole),
+		UserId:   principal.UserID,
+		UserRole: principal.Role,
 	}, nil
 }
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"net/http"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/policy"
+	"quiz_backend_core/internal/service"
+	"quiz_backend_core/internal/transport"
+	"strconv"
+)
+
+func makeSubmissionsHTTPHandler(s *service.Services, r *mux.Router, options []httptransport.ServerOption) {
+	e := transport.MakeSubmissionsEndpoints(s.Submissions, s.Policy)
+
+	r.Methods("OPTIONS", "POST").Path("/submission").Handler(httptransport.NewServer(
+		e.PostSubmissionEndpoint,
+		decodePostSubmissionRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/submission/{id}").Handler(httptransport.NewServer(
+		e.GetSubmissionEndpoint,
+		decodeGetSubmissionRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("OPTIONS", "GET").Path("/submissions").Handler(httptransport.NewServer(
+		e.GetSubmissionsEndpoint,
+		decodeGetSubmissionsRequest,
+		encodeResponse,
+		options...,
+	))
+}
+
+func decodePostSubmissionRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	var input = struct {
+		QuestionID int64  `json:"question_id,string"`
+		Code       string `json:"code"`
+	}{}
+
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, err
+	}
+
+	var submission dto.Submission
+	submission.QuestionID = input.QuestionID
+	submission.Code = input.Code
+	principal, _ := policy.FromContext(ctx)
+	submission.UserID = principal.UserID
+
+	return transport.PostSubmissionRequest{
+		Submission: submission,
+	}, nil
+}
+
+func decodeGetSubmissionRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	idStr, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, dto.ErrBadRouting
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, _ := policy.FromContext(ctx)
+
+	return transport.GetSubmissionRequest{
+		UserID:   principal.UserID,
+		UserRole: principal.Role,
+		ID:       id,
+	}, nil
+}
+
+func decodeGetSubmissionsRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	sRequest := transport.GetSubmissionsRequest{
+		QuestionID: -1,
+	}
+	principal, _ := policy.FromContext(ctx)
+	sRequest.UserID = principal.UserID
+
+	if questionIdStr := r.URL.Query().Get("question_id"); questionIdStr != "" {
+		if sRequest.QuestionID, err = strconv.ParseInt(questionIdStr, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return sRequest, nil
+}
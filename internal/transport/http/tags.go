@@ -0,0 +1,38 @@
+package http
+
+import (
+	"context"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"net/http"
+	"quiz_backend_core/internal/service"
+	"quiz_backend_core/internal/transport"
+	"strconv"
+)
+
+func makeTagsHTTPHandler(s *service.Services, r *mux.Router, options []httptransport.ServerOption) {
+	e := transport.MakeTagsEndpoints(s.Tags, s.Policy)
+
+	r.Methods("OPTIONS", "GET").Path("/tags").Handler(httptransport.NewServer(
+		e.GetTagsEndpoint,
+		decodeGetTagsRequest,
+		encodeResponse,
+		options...,
+	))
+}
+
+func decodeGetTagsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	query := r.URL.Query()
+
+	dRequest := transport.GetTagsRequest{
+		Prefix: query.Get("prefix"),
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if dRequest.Limit, err = strconv.Atoi(limitStr); err != nil {
+			return nil, err
+		}
+	}
+
+	return dRequest, nil
+}
@@ -9,27 +9,47 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"quiz_backend_core/internal/config"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/etag"
+	"quiz_backend_core/internal/idempotency"
+	"quiz_backend_core/internal/policy"
 	"quiz_backend_core/internal/service"
+	"quiz_backend_core/internal/transport"
 	"quiz_backend_core/pkg/error_handler"
+	"quiz_backend_core/pkg/jwt"
 )
 
-func MakeHTTPHandler(s *service.Services, logger *logrus.Logger, cors bool) http.Handler {
+// MakeHTTPHandler builds the router, verifier is used only in jwt auth mode
+func MakeHTTPHandler(s *service.Services, logger *logrus.Logger, cfg *config.Config, verifier *jwt.Verifier) http.Handler {
 	r := mux.NewRouter()
 
 	options := []httptransport.ServerOption{
 		httptransport.ServerErrorHandler(error_handler.NewUnitLogHandler(logrus.Logger{})), //TODO from deps (service level)?
 		httptransport.ServerErrorEncoder(errorEncoder(logger)),
+		httptransport.ServerBefore(principalToContext, auditActorToContext, idempotencyKeyToContext),
 	}
 
 	//if cors {
+	r.Use(requestIDMiddleware)
+	r.Use(versionHeadersMiddleware)
 	r.Use(quiz_backend_middleware.AccessControlMiddleware)
 	//}
 
-	r.Use(quiz_backend_middleware.FillContextMiddleware)
+	var auth mux.MiddlewareFunc = quiz_backend_middleware.FillContextMiddleware
+	if cfg.AuthMode == config.AuthModeJWT {
+		auth = jwtAuthMiddleware(verifier, cfg)
+	}
+	r.Use(apiTokenAuthMiddleware(s.APITokens, auth))
 
 	makeSubjectsHTTPHandler(s, r.PathPrefix("/subjects").Subrouter(), options)
 	makeQuestionsHTTPHandler(s, r.PathPrefix("/questions").Subrouter(), options)
 	makeQuizzesHTTPHandler(s, r.PathPrefix("/quizzes").Subrouter(), options)
+	makeTagsHTTPHandler(s, r.PathPrefix("/tags").Subrouter(), options)
+	makeAttachmentsHTTPHandler(s, r.PathPrefix("/attachments").Subrouter(), options, cfg.AttachmentsMaxSize)
+	makeSubmissionsHTTPHandler(s, r.PathPrefix("/submissions").Subrouter(), options)
+	makeAPITokensHTTPHandler(s, r.PathPrefix("/tokens").Subrouter(), options)
+	makeAuditHTTPHandler(s, r.PathPrefix("/audit").Subrouter(), options)
 	//makeExamHTTPHandler(s, r.PathPrefix("/examination").Subrouter(), options)
 
 	r.Methods("GET").Path("/metrics").Handler(promhttp.Handler())
@@ -37,7 +57,36 @@ func MakeHTTPHandler(s *service.Services, logger *logrus.Logger, cors bool) http
 	return r
 }
 
+// principalToContext puts the user filled by the auth middleware to the context as the policy principal,
+// requests made with api token are limited by its scopes
+func principalToContext(ctx context.Context, r *http.Request) context.Context {
+	userID, ok1 := r.Context().Value(quiz_backend_middleware.ContextVariablesUserID).(int64)
+	userRole, ok2 := r.Context().Value(quiz_backend_middleware.ContextVariablesUserRole).(string)
+	if !ok1 || !ok2 {
+		return ctx
+	}
+
+	scopes, _ := r.Context().Value(apiTokenScopesKey{}).([]string)
+
+	return policy.NewContext(ctx, policy.Principal{
+		UserID: userID,
+		Role:   dto.Role(userRole),
+		Scopes: scopes,
+	})
+}
+
+// idempotencyKeyToContext puts Idempotency-Key to the context, it is honored by create endpoints
+func idempotencyKeyToContext(ctx context.Context, r *http.Request) context.Context {
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		return idempotency.NewContext(ctx, key)
+	}
+	return ctx
+}
+
 func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if versioned, ok := response.(transport.Versioned); ok {
+		w.Header().Set("ETag", etag.Format(versioned.EntityVersion()))
+	}
 	return json.NewEncoder(w).Encode(response)
 }
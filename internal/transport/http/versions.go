@@ -0,0 +1,49 @@
+package http
+
+import (
+	"context"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"net/http"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/etag"
+)
+
+// ifMatchToContext puts the version expected by If-Match to the context, storages compare it with the current one
+func ifMatchToContext(ctx context.Context, r *http.Request) context.Context {
+	version, ok := etag.Parse(r.Header.Get("If-Match"))
+	if !ok {
+		return ctx
+	}
+	return etag.NewContext(ctx, version)
+}
+
+// requireIfMatch rejects changes of versioned objects made without valid If-Match
+func requireIfMatch(decode httptransport.DecodeRequestFunc) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		if _, ok := etag.FromContext(ctx); !ok {
+			return nil, dto.ErrIfMatchRequired
+		}
+		return decode(ctx, r)
+	}
+}
+
+// versionHeadersMiddleware lets browsers read ETag and send If-Match and Idempotency-Key, it wraps the access control middleware
+// which answers preflight requests itself
+func versionHeadersMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, X-Request-ID")
+
+		h.ServeHTTP(w, r)
+
+		if r.Method == "OPTIONS" {
+			if allowed := w.Header().Get("Access-Control-Allow-Headers"); allowed != "" {
+				w.Header().Set("Access-Control-Allow-Headers", allowed+", If-Match, Idempotency-Key")
+			}
+		}
+	})
+}
+
+// versionedOptions returns server options of routes changing versioned objects
+func versionedOptions(options []httptransport.ServerOption) []httptransport.ServerOption {
+	return append(options[:len(options):len(options)], httptransport.ServerBefore(ifMatchToContext))
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"github.com/go-kit/kit/endpoint"
 	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/idempotency"
 	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+	"quiz_backend_core/internal/validation"
 )
 
 type GetQuestionsRequest struct {
-	SubjectID     int64 `json:"subject_id"`
-	CreatorUserID int64 `json:"creator_user_id"`
-	StatusID      int64 `json:"status_id"`
+	Filter dto.QuestionFilter
 }
 
 type GetQuestionsResponse struct {
@@ -20,6 +21,31 @@ type GetQuestionsResponse struct {
 
 // *********************************************************************************************************************
 
+type GetQuestionRequest struct {
+	ID int64
+}
+
+type GetQuestionResponse struct {
+	Question dto.Question `json:"question"`
+	Err      error        `json:"err,omitempty"`
+}
+
+// *********************************************************************************************************************
+
+type SearchQuestionsRequest struct {
+	Query  string
+	Filter dto.QuestionFilter
+	Limit  int
+	Offset int
+}
+
+type SearchQuestionsResponse struct {
+	Results []dto.QuestionSearchResult `json:"results"`
+	Err     error                      `json:"err,omitempty"`
+}
+
+// *********************************************************************************************************************
+
 type GetQuestionTypesRequest struct{}
 
 type GetQuestionTypesResponse struct {
@@ -40,11 +66,15 @@ type GetQuestionStatusesResponse struct {
 
 type PostQuestionRequest struct {
 	Question dto.InputQuestion `json:"question"`
+	Force    bool              `json:"force"`
 }
 
+func (r PostQuestionRequest) InputQuestion() dto.InputQuestion { return r.Question }
+
 type PostQuestionResponse struct {
-	ID  int64 `json:"id"`
-	Err error `json:"err,omitempty"`
+	ID      int64                 `json:"id"`
+	Similar []dto.SimilarQuestion `json:"similar,omitempty"`
+	Err     error                 `json:"err,omitempty"`
 }
 
 // *********************************************************************************************************************
@@ -52,11 +82,12 @@ type PostQuestionResponse struct {
 type PutQuestionRequest struct {
 	ID       int64
 	Question dto.InputQuestion
-
-	UserID       int64    `json:"user_id"`
-	UserRoleName dto.Role `json:"user_role_name"`
 }
 
+func (r PutQuestionRequest) ResourceID() int64 { return r.ID }
+
+func (r PutQuestionRequest) InputQuestion() dto.InputQuestion { return r.Question }
+
 type PutQuestionResponse struct {
 	Err error `json:"err,omitempty"`
 }
@@ -69,52 +100,184 @@ type PutQuestionModerateRequest struct {
 	Comment string
 }
 
+func (r PutQuestionModerateRequest) ResourceID() int64 { return r.ID }
+
 type PutQuestionModerateResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
 // *********************************************************************************************************************
 
+type CloneQuestionRequest struct {
+	ID int64
+}
+
+type CloneQuestionResponse struct {
+	ID  int64 `json:"id"`
+	Err error `json:"err,omitempty"`
+}
+
 type DeleteQuestionRequest struct {
 	ID int64
 }
 
+func (r DeleteQuestionRequest) ResourceID() int64 { return r.ID }
+
 type DeleteQuestionResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
 // *********************************************************************************************************************
 
+type GetDeletedQuestionsRequest struct {
+	Filter dto.QuestionFilter
+}
+
+type GetDeletedQuestionsResponse struct {
+	Questions []dto.Question `json:"questions"`
+	Err       error          `json:"err,omitempty"`
+}
+
+// *********************************************************************************************************************
+
+type RestoreQuestionRequest struct {
+	ID int64
+}
+
+func (r RestoreQuestionRequest) ResourceID() int64 { return r.ID }
+
+type RestoreQuestionResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// *********************************************************************************************************************
+
+type GetQuestionCodeRequest struct {
+	ID int64
+}
+
+type GetQuestionCodeResponse struct {
+	Code []dto.HighlightedCode `json:"code"`
+	Err  error                 `json:"err,omitempty"`
+}
+
+type GetRenderedQuestionRequest struct {
+	ID   int64
+	Seed int64
+}
+
+type GetRenderedQuestionResponse struct {
+	Question dto.RenderedQuestion `json:"question"`
+	Err      error                `json:"err,omitempty"`
+}
+
+type PostParametricCheckRequest struct {
+	ID    int64
+	Seed  int64
+	Value float64
+}
+
+type PostParametricCheckResponse struct {
+	Result dto.ParametricCheckResult `json:"result"`
+	Err    error                     `json:"err,omitempty"`
+}
+
+type PostGradeAnswerRequest struct {
+	ID       int64
+	Response map[string]interface{}
+}
+
+type PostGradeAnswerResponse struct {
+	Result dto.GradeResult `json:"result"`
+	Err    error           `json:"err,omitempty"`
+}
+
+type GetDuplicateClustersRequest struct {
+	SubjectID int64
+	Threshold float64
+}
+
+type GetDuplicateClustersResponse struct {
+	Clusters []dto.DuplicateCluster `json:"clusters"`
+	Err      error                  `json:"err,omitempty"`
+}
+
+// *********************************************************************************************************************
+
 type QuestionsEndpoints struct {
 	GetQuestionsEndpoint        endpoint.Endpoint
+	GetQuestionEndpoint         endpoint.Endpoint
+	SearchQuestionsEndpoint     endpoint.Endpoint
 	GetQuestionTypesEndpoint    endpoint.Endpoint
 	GetQuestionStatusesEndpoint endpoint.Endpoint
 	PostQuestionEndpoint        endpoint.Endpoint
 	PutQuestionEndpoint         endpoint.Endpoint
 	PutQuestionModerateEndpoint endpoint.Endpoint
 	DeleteQuestionEndpoint      endpoint.Endpoint
+
+	GetQuestionCodeEndpoint      endpoint.Endpoint
+	GetRenderedQuestionEndpoint  endpoint.Endpoint
+	PostParametricCheckEndpoint  endpoint.Endpoint
+	PostGradeAnswerEndpoint      endpoint.Endpoint
+	CloneQuestionEndpoint        endpoint.Endpoint
+	GetDeletedQuestionsEndpoint  endpoint.Endpoint
+	RestoreQuestionEndpoint      endpoint.Endpoint
+	GetDuplicateClustersEndpoint endpoint.Endpoint
 }
 
-func MakeQuestionsEndpoints(s model.Questions) QuestionsEndpoints {
+func MakeQuestionsEndpoints(s model.Questions, p *policy.Policy, v *validation.Validator, k *idempotency.Keeper) QuestionsEndpoints {
 	return QuestionsEndpoints{
-		GetQuestionsEndpoint:        MakeGetQuestionsEndpoint(s),
-		GetQuestionTypesEndpoint:    MakeGetQuestionTypesEndpoint(s),
-		GetQuestionStatusesEndpoint: MakeGetQuestionStatusesEndpoint(s),
-		PostQuestionEndpoint:        MakePostQuestionEndpoint(s),
-		PutQuestionEndpoint:         MakePutQuestionEndpoint(s),
-		PutQuestionModerateEndpoint: MakePutQuestionModerateEndpoint(s),
-		DeleteQuestionEndpoint:      MakeDeleteQuestionEndpoint(s),
+		GetQuestionsEndpoint:        p.Middleware(policy.QuestionsRead)(MakeGetQuestionsEndpoint(s)),
+		GetQuestionEndpoint:         p.Middleware(policy.QuestionsRead)(MakeGetQuestionEndpoint(s)),
+		SearchQuestionsEndpoint:     p.Middleware(policy.QuestionsRead)(MakeSearchQuestionsEndpoint(s)),
+		GetQuestionTypesEndpoint:    p.Middleware(policy.QuestionsRead)(MakeGetQuestionTypesEndpoint(s)),
+		GetQuestionStatusesEndpoint: p.Middleware(policy.QuestionsRead)(MakeGetQuestionStatusesEndpoint(s)),
+		PostQuestionEndpoint:        p.Middleware(policy.QuestionsCreate)(k.Middleware()(v.Middleware()(MakePostQuestionEndpoint(s)))),
+		PutQuestionEndpoint:         p.Middleware(policy.QuestionsUpdate)(v.Middleware()(MakePutQuestionEndpoint(s))),
+		PutQuestionModerateEndpoint: p.Middleware(policy.QuestionsModerate)(MakePutQuestionModerateEndpoint(s)),
+		DeleteQuestionEndpoint:      p.Middleware(policy.QuestionsDelete)(MakeDeleteQuestionEndpoint(s)),
+
+		GetQuestionCodeEndpoint:      p.Middleware(policy.QuestionsRead)(MakeGetQuestionCodeEndpoint(s)),
+		GetRenderedQuestionEndpoint:  p.Middleware(policy.QuestionsRender)(MakeGetRenderedQuestionEndpoint(s)),
+		PostParametricCheckEndpoint:  p.Middleware(policy.QuestionsRead)(MakePostParametricCheckEndpoint(s)),
+		PostGradeAnswerEndpoint:      p.Middleware(policy.QuestionsRead)(MakePostGradeAnswerEndpoint(s)),
+		CloneQuestionEndpoint:        p.Middleware(policy.QuestionsCreate)(MakeCloneQuestionEndpoint(s)),
+		GetDeletedQuestionsEndpoint:  p.Middleware(policy.QuestionsTrash)(MakeGetDeletedQuestionsEndpoint(s)),
+		RestoreQuestionEndpoint:      p.Middleware(policy.QuestionsRestore)(MakeRestoreQuestionEndpoint(s)),
+		GetDuplicateClustersEndpoint: p.Middleware(policy.QuestionsDuplicates)(MakeGetDuplicateClustersEndpoint(s)),
 	}
 }
 
 func MakeGetQuestionsEndpoint(s model.Questions) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetQuestionsRequest) //TODO check everywhere, return internal server error
-		t, err := s.GetQuestions(ctx, req.CreatorUserID, req.SubjectID, req.StatusID)
+		t, err := s.GetQuestions(ctx, req.Filter)
 		return GetQuestionsResponse{t, err}, err
 	}
 }
 
+func MakeGetQuestionEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetQuestionRequest)
+		question, err := s.GetQuestionByID(ctx, req.ID)
+		return GetQuestionResponse{
+			Question: question,
+			Err:      err,
+		}, err
+	}
+}
+
+func MakeSearchQuestionsEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SearchQuestionsRequest)
+		results, err := s.SearchQuestions(ctx, req.Query, req.Filter, req.Limit, req.Offset)
+		return SearchQuestionsResponse{
+			Results: results,
+			Err:     err,
+		}, err
+	}
+}
+
 func MakeGetQuestionTypesEndpoint(s model.Questions) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		t, err := s.GetQuestionTypes(ctx)
@@ -138,10 +301,11 @@ func MakeGetQuestionStatusesEndpoint(s model.Questions) endpoint.Endpoint {
 func MakePostQuestionEndpoint(s model.Questions) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(PostQuestionRequest)
-		id, err := s.AddQuestion(ctx, req.Question)
+		id, similar, err := s.AddQuestion(ctx, req.Question, req.Force)
 		return PostQuestionResponse{
-			ID:  id,
-			Err: err,
+			ID:      id,
+			Similar: similar,
+			Err:     err,
 		}, err
 	}
 }
@@ -169,3 +333,85 @@ func MakeDeleteQuestionEndpoint(s model.Questions) endpoint.Endpoint {
 		return DeleteQuestionResponse{err}, err
 	}
 }
+
+func MakeGetQuestionCodeEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetQuestionCodeRequest)
+		code, err := s.GetQuestionCode(ctx, req.ID)
+		return GetQuestionCodeResponse{
+			Code: code,
+			Err:  err,
+		}, err
+	}
+}
+
+func MakeGetRenderedQuestionEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetRenderedQuestionRequest)
+		question, err := s.RenderQuestion(ctx, req.ID, req.Seed)
+		return GetRenderedQuestionResponse{
+			Question: question,
+			Err:      err,
+		}, err
+	}
+}
+
+func MakePostParametricCheckEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PostParametricCheckRequest)
+		result, err := s.CheckParametricAnswer(ctx, req.ID, req.Seed, req.Value)
+		return PostParametricCheckResponse{
+			Result: result,
+			Err:    err,
+		}, err
+	}
+}
+
+func MakePostGradeAnswerEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PostGradeAnswerRequest)
+		result, err := s.GradeAnswer(ctx, req.ID, req.Response)
+		return PostGradeAnswerResponse{
+			Result: result,
+			Err:    err,
+		}, err
+	}
+}
+
+func MakeCloneQuestionEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CloneQuestionRequest)
+		id, err := s.CloneQuestion(ctx, req.ID)
+		return CloneQuestionResponse{
+			ID:  id,
+			Err: err,
+		}, err
+	}
+}
+
+func MakeGetDuplicateClustersEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetDuplicateClustersRequest)
+		clusters, err := s.GetDuplicateClusters(ctx, req.SubjectID, req.Threshold)
+		return GetDuplicateClustersResponse{
+			Clusters: clusters,
+			Err:      err,
+		}, err
+	}
+}
+
+func MakeGetDeletedQuestionsEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetDeletedQuestionsRequest)
+		questions, err := s.GetDeletedQuestions(ctx, req.Filter)
+		return GetDeletedQuestionsResponse{questions, err}, err
+	}
+}
+
+func MakeRestoreQuestionEndpoint(s model.Questions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RestoreQuestionRequest)
+		err := s.RestoreQuestion(ctx, req.ID)
+		return RestoreQuestionResponse{err}, err
+	}
+}
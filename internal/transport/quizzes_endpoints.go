@@ -4,11 +4,15 @@ import (
 	"context"
 	"github.com/go-kit/kit/endpoint"
 	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/idempotency"
 	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+	"quiz_backend_core/internal/validation"
 )
 
 type GetQuizzesRequest struct {
-	CreatorUserID int64 `json:"creator_user_id"`
+	CreatorUserID int64    `json:"creator_user_id"`
+	Tags          []string `json:"tags"`
 }
 
 type GetQuizzesResponse struct {
@@ -44,6 +48,8 @@ type PostQuizRequest struct {
 	Quiz dto.InputQuiz `json:"quiz"`
 }
 
+func (r PostQuizRequest) InputQuiz() dto.InputQuiz { return r.Quiz }
+
 type PostQuizResponse struct {
 	QuizID int64 `json:"quiz_id"`
 	Err    error `json:"err,omitempty"`
@@ -55,34 +61,78 @@ type DeleteQuizByIDRequest struct {
 	QuizID int64 `json:"quiz_id"`
 }
 
+func (r DeleteQuizByIDRequest) ResourceID() int64 { return r.QuizID }
+
 type DeleteQuizByIDResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
 // *********************************************************************************************************************
 
+type GetDeletedQuizzesRequest struct {
+	CreatorUserID int64
+}
+
+type GetDeletedQuizzesResponse struct {
+	Quizzes []dto.Quiz `json:"quizzes"`
+	Err     error      `json:"err,omitempty"`
+}
+
+// *********************************************************************************************************************
+
+type RestoreQuizRequest struct {
+	QuizID int64
+}
+
+func (r RestoreQuizRequest) ResourceID() int64 { return r.QuizID }
+
+type RestoreQuizResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// *********************************************************************************************************************
+
+type CloneQuizRequest struct {
+	UserID int64
+	QuizID int64
+	Name   string
+}
+
+type CloneQuizResponse struct {
+	QuizID int64 `json:"quiz_id"`
+	Err    error `json:"err,omitempty"`
+}
+
+// *********************************************************************************************************************
+
 type QuizzesEndpoints struct {
 	GetQuizzesEndpoint           endpoint.Endpoint
 	GetQuestionsByQuizIDEndpoint endpoint.Endpoint
 	GetQuizByIDEndpoint          endpoint.Endpoint
 	PostQuizEndpoint             endpoint.Endpoint
 	DeleteQuizEndpoint           endpoint.Endpoint
+	CloneQuizEndpoint            endpoint.Endpoint
+	GetDeletedQuizzesEndpoint    endpoint.Endpoint
+	RestoreQuizEndpoint          endpoint.Endpoint
 }
 
-func MakeQuizzesEndpoints(s model.Quizzes) QuizzesEndpoints {
+func MakeQuizzesEndpoints(s model.Quizzes, p *policy.Policy, v *validation.Validator, k *idempotency.Keeper) QuizzesEndpoints {
 	return QuizzesEndpoints{
-		GetQuizzesEndpoint:           MakeGetQuizzesEndpoint(s),
-		GetQuestionsByQuizIDEndpoint: MakeGetQuestionsByQuizIDEndpoint(s),
-		GetQuizByIDEndpoint:          MakeGetQuizByIDEndpoint(s),
-		PostQuizEndpoint:             MakePostQuizEndpoint(s),
-		DeleteQuizEndpoint:           MakeDeleteQuizEndpoint(s),
+		GetQuizzesEndpoint:           p.Middleware(policy.QuizzesRead)(MakeGetQuizzesEndpoint(s)),
+		GetQuestionsByQuizIDEndpoint: p.Middleware(policy.QuizzesRead)(MakeGetQuestionsByQuizIDEndpoint(s)),
+		GetQuizByIDEndpoint:          p.Middleware(policy.QuizzesRead)(MakeGetQuizByIDEndpoint(s)),
+		PostQuizEndpoint:             p.Middleware(policy.QuizzesCreate)(k.Middleware()(v.Middleware()(MakePostQuizEndpoint(s)))),
+		DeleteQuizEndpoint:           p.Middleware(policy.QuizzesDelete)(MakeDeleteQuizEndpoint(s)),
+		CloneQuizEndpoint:            p.Middleware(policy.QuizzesCreate)(MakeCloneQuizEndpoint(s)),
+		GetDeletedQuizzesEndpoint:    p.Middleware(policy.QuizzesTrash)(MakeGetDeletedQuizzesEndpoint(s)),
+		RestoreQuizEndpoint:          p.Middleware(policy.QuizzesRestore)(MakeRestoreQuizEndpoint(s)),
 	}
 }
 
 func MakeGetQuizzesEndpoint(s model.Quizzes) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetQuizzesRequest) //TODO check everywhere, return internal server error
-		quizzes, err := s.GetQuizzes(ctx, req.CreatorUserID)
+		quizzes, err := s.GetQuizzes(ctx, req.CreatorUserID, req.Tags)
 		return GetQuizzesResponse{
 			Quizzes: quizzes,
 			Err:     err,
@@ -132,3 +182,35 @@ func MakeDeleteQuizEndpoint(s model.Quizzes) endpoint.Endpoint {
 		}, err
 	}
 }
+
+func MakeCloneQuizEndpoint(s model.Quizzes) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CloneQuizRequest)
+		quizID, err := s.CloneQuiz(ctx, req.UserID, req.QuizID, req.Name)
+		return CloneQuizResponse{
+			QuizID: quizID,
+			Err:    err,
+		}, err
+	}
+}
+
+func MakeGetDeletedQuizzesEndpoint(s model.Quizzes) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetDeletedQuizzesRequest)
+		quizzes, err := s.GetDeletedQuizzes(ctx, req.CreatorUserID)
+		return GetDeletedQuizzesResponse{
+			Quizzes: quizzes,
+			Err:     err,
+		}, err
+	}
+}
+
+func MakeRestoreQuizEndpoint(s model.Quizzes) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RestoreQuizRequest)
+		err := s.RestoreQuiz(ctx, req.QuizID)
+		return RestoreQuizResponse{
+			Err: err,
+		}, err
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/go-kit/kit/endpoint"
 	"quiz_backend_core/internal/dto"
 	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+	"quiz_backend_core/internal/validation"
 )
 
 type GetSubjectsRequest struct {
@@ -19,10 +21,44 @@ type GetSubjectsResponse struct {
 
 //**********************************************************************************************************************
 
+type GetSubjectRequest struct {
+	ID int64
+}
+
+type GetSubjectResponse struct {
+	Subject dto.Subject `json:"subject"`
+	Err     error       `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type GetSubjectTreeRequest struct {
+	RootID int64
+	Depth  int
+}
+
+type GetSubjectTreeResponse struct {
+	Tree []dto.SubjectTreeNode `json:"tree"`
+	Err  error                 `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
 type PostSubjectRequest struct {
+	UserID  int64
 	Subject dto.Subject
 }
 
+func (r PostSubjectRequest) InputSubject() dto.Subject { return r.Subject }
+
+// SubjectIDs is the parent of the new subject, 0 for a top level subject which only staff can create
+func (r PostSubjectRequest) SubjectIDs() []int64 {
+	if r.Subject.ParentId <= 0 {
+		return []int64{0}
+	}
+	return []int64{r.Subject.ParentId}
+}
+
 type PostSubjectResponse struct {
 	ID  int64 `json:"id"`
 	Err error `json:"err,omitempty"`
@@ -43,9 +79,15 @@ type GetStatisticResponse struct {
 //**********************************************************************************************************************
 
 type PutSubjectRequest struct {
+	UserID  int64
 	Subject dto.Subject
 }
 
+func (r PutSubjectRequest) InputSubject() dto.Subject { return r.Subject }
+func (r PutSubjectRequest) SubjectIDs() []int64       { return []int64{r.Subject.ID} }
+func (r PutSubjectRequest) MovedSubjectID() int64     { return r.Subject.ID }
+func (r PutSubjectRequest) NewParentID() int64        { return r.Subject.ParentId }
+
 type PutSubjectResponse struct {
 	Err error `json:"err,omitempty"`
 }
@@ -53,32 +95,155 @@ type PutSubjectResponse struct {
 //**********************************************************************************************************************
 
 type DeleteSubjectRequest struct {
-	ID int64
+	UserID          int64
+	ID              int64
+	Mode            dto.SubjectDeleteMode
+	TargetSubjectID int64
+}
+
+func (r DeleteSubjectRequest) SubjectIDs() []int64 {
+	if r.Mode == dto.SubjectDeleteReassign {
+		return []int64{r.ID, r.TargetSubjectID}
+	}
+	return []int64{r.ID}
 }
 
 type DeleteSubjectResponse struct {
+	Affected dto.SubjectDeletePreview `json:"affected"`
+	Err      error                    `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type GetSubjectDeletePreviewRequest struct {
+	ID int64
+}
+
+type GetSubjectDeletePreviewResponse struct {
+	Preview dto.SubjectDeletePreview `json:"preview"`
+	Err     error                    `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type MoveSubjectRequest struct {
+	UserID   int64
+	ID       int64
+	ParentID int64
+}
+
+func (r MoveSubjectRequest) SubjectIDs() []int64   { return []int64{r.ID} }
+func (r MoveSubjectRequest) MovedSubjectID() int64 { return r.ID }
+func (r MoveSubjectRequest) NewParentID() int64    { return r.ParentID }
+
+type MoveSubjectResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type MergeSubjectsRequest struct {
+	UserID   int64
+	SourceID int64
+	TargetID int64
+}
+
+func (r MergeSubjectsRequest) SubjectIDs() []int64 { return []int64{r.SourceID, r.TargetID} }
+
+type MergeSubjectsResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type GetDeletedSubjectsRequest struct{}
+
+type GetDeletedSubjectsResponse struct {
+	Subjects []dto.Subject `json:"subjects,omitempty"`
+	Err      error         `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type RestoreSubjectRequest struct {
+	UserID int64
+	ID     int64
+}
+
+func (r RestoreSubjectRequest) SubjectIDs() []int64 { return []int64{r.ID} }
+
+type RestoreSubjectResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type GetSubjectMaintainersRequest struct {
+	SubjectID int64
+}
+
+type GetSubjectMaintainersResponse struct {
+	Maintainers []dto.SubjectMaintainer `json:"maintainers"`
+	Err         error                   `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type SubjectMaintainerRequest struct {
+	UserID           int64
+	SubjectID        int64
+	MaintainerUserID int64
+}
+
+func (r SubjectMaintainerRequest) SubjectIDs() []int64 { return []int64{r.SubjectID} }
+
+type SubjectMaintainerResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
 //**********************************************************************************************************************
 
 type SubjectsEndpoints struct {
-	GetSubjectsEndpoint   endpoint.Endpoint
-	PostSubjectEndpoint   endpoint.Endpoint
-	PutSubjectEndpoint    endpoint.Endpoint
-	DeleteSubjectEndpoint endpoint.Endpoint
+	GetSubjectsEndpoint    endpoint.Endpoint
+	GetSubjectEndpoint     endpoint.Endpoint
+	GetSubjectTreeEndpoint endpoint.Endpoint
+	PostSubjectEndpoint    endpoint.Endpoint
+	PutSubjectEndpoint     endpoint.Endpoint
+	DeleteSubjectEndpoint  endpoint.Endpoint
+
+	GetSubjectDeletePreviewEndpoint endpoint.Endpoint
+	MoveSubjectEndpoint             endpoint.Endpoint
+	MergeSubjectsEndpoint           endpoint.Endpoint
+	GetDeletedSubjectsEndpoint      endpoint.Endpoint
+	RestoreSubjectEndpoint          endpoint.Endpoint
+
+	GetSubjectMaintainersEndpoint   endpoint.Endpoint
+	GrantSubjectMaintainerEndpoint  endpoint.Endpoint
+	RevokeSubjectMaintainerEndpoint endpoint.Endpoint
 
 	GetStatisticEndpoint endpoint.Endpoint
 }
 
-func MakeSubjectsEndpoints(s model.Subjects) SubjectsEndpoints {
+func MakeSubjectsEndpoints(s model.Subjects, p *policy.Policy, v *validation.Validator) SubjectsEndpoints {
 	return SubjectsEndpoints{
-		GetSubjectsEndpoint:   MakeGetSubjectsEndpoint(s),
-		PostSubjectEndpoint:   MakePostSubjectEndpoint(s),
-		PutSubjectEndpoint:    MakePutSubjectEndpoint(s),
-		DeleteSubjectEndpoint: MakeDeleteSubjectEndpoint(s),
+		GetSubjectsEndpoint:    p.Middleware(policy.SubjectsRead)(MakeGetSubjectsEndpoint(s)),
+		GetSubjectEndpoint:     p.Middleware(policy.SubjectsRead)(MakeGetSubjectEndpoint(s)),
+		GetSubjectTreeEndpoint: p.Middleware(policy.SubjectsRead)(MakeGetSubjectTreeEndpoint(s)),
+		PostSubjectEndpoint:    p.Middleware(policy.SubjectsWrite)(v.Middleware()(MakePostSubjectEndpoint(s))),
+		PutSubjectEndpoint:     p.Middleware(policy.SubjectsWrite)(v.Middleware()(MakePutSubjectEndpoint(s))),
+		DeleteSubjectEndpoint:  p.Middleware(policy.SubjectsWrite)(MakeDeleteSubjectEndpoint(s)),
+
+		GetSubjectDeletePreviewEndpoint: p.Middleware(policy.SubjectsRead)(MakeGetSubjectDeletePreviewEndpoint(s)),
+		MoveSubjectEndpoint:             p.Middleware(policy.SubjectsWrite)(MakeMoveSubjectEndpoint(s)),
+		MergeSubjectsEndpoint:           p.Middleware(policy.SubjectsWrite)(MakeMergeSubjectsEndpoint(s)),
+		GetDeletedSubjectsEndpoint:      p.Middleware(policy.SubjectsTrash)(MakeGetDeletedSubjectsEndpoint(s)),
+		RestoreSubjectEndpoint:          p.Middleware(policy.SubjectsWrite)(MakeRestoreSubjectEndpoint(s)),
 
-		GetStatisticEndpoint: MakeGetStatisticEndpoint(s),
+		GetSubjectMaintainersEndpoint:   p.Middleware(policy.SubjectsRead)(MakeGetSubjectMaintainersEndpoint(s)),
+		GrantSubjectMaintainerEndpoint:  p.Middleware(policy.SubjectsWrite)(MakeGrantSubjectMaintainerEndpoint(s)),
+		RevokeSubjectMaintainerEndpoint: p.Middleware(policy.SubjectsWrite)(MakeRevokeSubjectMaintainerEndpoint(s)),
+
+		GetStatisticEndpoint: p.Middleware(policy.SubjectsStatistic)(MakeGetStatisticEndpoint(s)),
 	}
 }
 
@@ -89,10 +254,29 @@ func MakeGetSubjectsEndpoint(s model.Subjects) endpoint.Endpoint {
 	}
 }
 
+func MakeGetSubjectEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetSubjectRequest)
+		subject, err := s.GetSubjectByID(ctx, req.ID)
+		return GetSubjectResponse{
+			Subject: subject,
+			Err:     err,
+		}, err
+	}
+}
+
+func MakeGetSubjectTreeEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetSubjectTreeRequest)
+		tree, err := s.GetSubjectTree(ctx, req.RootID, req.Depth)
+		return GetSubjectTreeResponse{tree, err}, err
+	}
+}
+
 func MakePostSubjectEndpoint(s model.Subjects) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(PostSubjectRequest)
-		id, err := s.AddSubject(ctx, req.Subject)
+		id, err := s.AddSubject(ctx, req.UserID, req.Subject)
 		return PostSubjectResponse{
 			ID:  id,
 			Err: err,
@@ -103,7 +287,7 @@ func MakePostSubjectEndpoint(s model.Subjects) endpoint.Endpoint {
 func MakePutSubjectEndpoint(s model.Subjects) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(PutSubjectRequest)
-		err = s.UpdateSubject(ctx, req.Subject)
+		err = s.UpdateSubject(ctx, req.UserID, req.Subject)
 		return PutSubjectResponse{err}, err
 	}
 }
@@ -111,8 +295,71 @@ func MakePutSubjectEndpoint(s model.Subjects) endpoint.Endpoint {
 func MakeDeleteSubjectEndpoint(s model.Subjects) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(DeleteSubjectRequest)
-		err = s.DeleteSubjectByID(ctx, req.ID)
-		return DeleteSubjectResponse{err}, err
+		affected, err := s.DeleteSubjectByID(ctx, req.UserID, req.ID, req.Mode, req.TargetSubjectID)
+		return DeleteSubjectResponse{affected, err}, err
+	}
+}
+
+func MakeGetSubjectDeletePreviewEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(GetSubjectDeletePreviewRequest)
+		preview, err := s.GetSubjectDeletePreview(ctx, req.ID)
+		return GetSubjectDeletePreviewResponse{preview, err}, err
+	}
+}
+
+func MakeMoveSubjectEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(MoveSubjectRequest)
+		err = s.MoveSubject(ctx, req.UserID, req.ID, req.ParentID)
+		return MoveSubjectResponse{err}, err
+	}
+}
+
+func MakeMergeSubjectsEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(MergeSubjectsRequest)
+		err = s.MergeSubjects(ctx, req.UserID, req.SourceID, req.TargetID)
+		return MergeSubjectsResponse{err}, err
+	}
+}
+
+func MakeGetDeletedSubjectsEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		t, err := s.GetDeletedSubjects(ctx)
+		return GetDeletedSubjectsResponse{t, err}, err
+	}
+}
+
+func MakeRestoreSubjectEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(RestoreSubjectRequest)
+		err = s.RestoreSubject(ctx, req.UserID, req.ID)
+		return RestoreSubjectResponse{err}, err
+	}
+}
+
+func MakeGetSubjectMaintainersEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(GetSubjectMaintainersRequest)
+		maintainers, err := s.GetSubjectMaintainers(ctx, req.SubjectID)
+		return GetSubjectMaintainersResponse{maintainers, err}, err
+	}
+}
+
+func MakeGrantSubjectMaintainerEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(SubjectMaintainerRequest)
+		err = s.GrantSubjectMaintainer(ctx, req.UserID, req.SubjectID, req.MaintainerUserID)
+		return SubjectMaintainerResponse{err}, err
+	}
+}
+
+func MakeRevokeSubjectMaintainerEndpoint(s model.Subjects) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(SubjectMaintainerRequest)
+		err = s.RevokeSubjectMaintainer(ctx, req.UserID, req.SubjectID, req.MaintainerUserID)
+		return SubjectMaintainerResponse{err}, err
 	}
 }
 
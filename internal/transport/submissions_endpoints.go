@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+)
+
+type PostSubmissionRequest struct {
+	Submission dto.Submission
+}
+
+type PostSubmissionResponse struct {
+	Submission dto.Submission `json:"submission"`
+	Err        error          `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type GetSubmissionRequest struct {
+	UserID   int64
+	UserRole dto.Role
+	ID       int64
+}
+
+type GetSubmissionResponse struct {
+	Submission dto.Submission `json:"submission"`
+	Err        error          `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type GetSubmissionsRequest struct {
+	UserID     int64
+	QuestionID int64
+}
+
+type GetSubmissionsResponse struct {
+	Submissions []dto.Submission `json:"submissions"`
+	Err         error            `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type SubmissionsEndpoints struct {
+	PostSubmissionEndpoint endpoint.Endpoint
+	GetSubmissionEndpoint  endpoint.Endpoint
+	GetSubmissionsEndpoint endpoint.Endpoint
+}
+
+func MakeSubmissionsEndpoints(s model.Submissions, p *policy.Policy) SubmissionsEndpoints {
+	return SubmissionsEndpoints{
+		PostSubmissionEndpoint: p.Middleware(policy.SubmissionsCreate)(MakePostSubmissionEndpoint(s)),
+		GetSubmissionEndpoint:  p.Middleware(policy.SubmissionsRead)(MakeGetSubmissionEndpoint(s)),
+		GetSubmissionsEndpoint: p.Middleware(policy.SubmissionsRead)(MakeGetSubmissionsEndpoint(s)),
+	}
+}
+
+func MakePostSubmissionEndpoint(s model.Submissions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PostSubmissionRequest)
+		submission, err := s.SubmitProgram(ctx, req.Submission)
+		return PostSubmissionResponse{
+			Submission: submission,
+			Err:        err,
+		}, err
+	}
+}
+
+func MakeGetSubmissionEndpoint(s model.Submissions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetSubmissionRequest)
+		submission, err := s.GetSubmissionByID(ctx, req.UserID, req.UserRole, req.ID)
+		return GetSubmissionResponse{
+			Submission: submission,
+			Err:        err,
+		}, err
+	}
+}
+
+func MakeGetSubmissionsEndpoint(s model.Submissions) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetSubmissionsRequest)
+		submissions, err := s.GetSubmissions(ctx, req.UserID, req.QuestionID)
+		return GetSubmissionsResponse{
+			Submissions: submissions,
+			Err:         err,
+		}, err
+	}
+}
@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"quiz_backend_core/internal/policy"
+)
+
+type GetTagsRequest struct {
+	Prefix string
+	Limit  int
+}
+
+type GetTagsResponse struct {
+	Tags []dto.Tag `json:"tags"`
+	Err  error     `json:"err,omitempty"`
+}
+
+//**********************************************************************************************************************
+
+type TagsEndpoints struct {
+	GetTagsEndpoint endpoint.Endpoint
+}
+
+func MakeTagsEndpoints(s model.Tags, p *policy.Policy) TagsEndpoints {
+	return TagsEndpoints{
+		GetTagsEndpoint: p.Middleware(policy.TagsRead)(MakeGetTagsEndpoint(s)),
+	}
+}
+
+func MakeGetTagsEndpoint(s model.Tags) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetTagsRequest)
+		tags, err := s.GetTags(ctx, req.Prefix, req.Limit)
+		return GetTagsResponse{
+			Tags: tags,
+			Err:  err,
+		}, err
+	}
+}
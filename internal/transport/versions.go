@@ -0,0 +1,12 @@
+package transport
+
+// Versioned is implemented by responses of a single versioned object, the version is sent as ETag
+type Versioned interface {
+	EntityVersion() int64
+}
+
+func (r GetQuestionResponse) EntityVersion() int64 { return r.Question.Version }
+
+func (r GetQuizByIDResponse) EntityVersion() int64 { return r.Quiz.Version }
+
+func (r GetSubjectResponse) EntityVersion() int64 { return r.Subject.Version }
@@ -0,0 +1,156 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-kit/kit/endpoint"
+	"quiz_backend_core/internal/dto"
+	"quiz_backend_core/internal/model"
+	"strings"
+)
+
+const (
+	nameMaxLength         = 200
+	descriptionMaxLength  = 2000
+	questionTextMaxLength = 10000
+	quizQuestionsMax      = 200
+	tagsMax               = 20
+)
+
+// QuizRequest is implemented by requests carrying a quiz to create
+type QuizRequest interface {
+	InputQuiz() dto.InputQuiz
+}
+
+// QuestionRequest is implemented by requests carrying a question to create or update
+type QuestionRequest interface {
+	InputQuestion() dto.InputQuestion
+}
+
+// SubjectRequest is implemented by requests carrying a subject to create or update
+type SubjectRequest interface {
+	InputSubject() dto.Subject
+}
+
+// Validator holds the rules of request DTOs, referenced objects are looked up in storages
+type Validator struct {
+	questions model.QuestionsStorage
+	subjects  model.SubjectsStorage
+}
+
+func NewValidator(questions model.QuestionsStorage, subjects model.SubjectsStorage) *Validator {
+	return &Validator{
+		questions: questions,
+		subjects:  subjects,
+	}
+}
+
+// Middleware validates DTOs of requests implementing QuizRequest, QuestionRequest or SubjectRequest,
+// other requests are passed as is
+func (v *Validator) Middleware() endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			var err error
+			switch r := request.(type) {
+			case QuizRequest:
+				err = v.Quiz(ctx, r.InputQuiz())
+			case QuestionRequest:
+				err = v.Question(ctx, r.InputQuestion())
+			case SubjectRequest:
+				err = v.Subject(ctx, r.InputSubject())
+			}
+			if err != nil {
+				return nil, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+func (v *Validator) Quiz(ctx context.Context, quiz dto.InputQuiz) error {
+	fields := NewFields().
+		Field("name", Required(strings.TrimSpace(quiz.Name)), MaxLength(quiz.Name, nameMaxLength)).
+		Field("description", MaxLength(quiz.Description, descriptionMaxLength)).
+		Field("question_ids",
+			When(quiz.Assembly == nil, Items(len(quiz.QuestionIDs), 1, quizQuestionsMax)),
+			Items(len(quiz.QuestionIDs), 0, quizQuestionsMax),
+			PositiveUniqueIDs(quiz.QuestionIDs),
+			v.questionsExist(quiz.QuestionIDs),
+		).
+		Field("tags", Items(len(quiz.Tags), 0, tagsMax))
+
+	if quiz.Assembly != nil {
+		fields.
+			Field("assembly.subject_id", Positive(quiz.Assembly.SubjectID), v.subjectExists(quiz.Assembly.SubjectID)).
+			Field("assembly.count", Items(quiz.Assembly.Count, 1, quizQuestionsMax))
+	}
+
+	return fields.Validate(ctx)
+}
+
+func (v *Validator) Question(ctx context.Context, question dto.InputQuestion) error {
+	return NewFields().
+		Field("text", Required(strings.TrimSpace(question.Text)), MaxLength(question.Text, questionTextMaxLength)).
+		Field("type_id", Positive(question.TypeID), v.questionTypeExists(question.TypeID)).
+		Field("subject_id", Positive(question.SubjectID), v.subjectExists(question.SubjectID)).
+		Field("status_name", When(question.StatusName != "", OneOf(question.StatusName,
+			dto.QuestionStatusNameCreated, dto.QuestionStatusNameApproved, dto.QuestionStatusNameDeclined))).
+		Field("tags", Items(len(question.Tags), 0, tagsMax)).
+		Field("attachment_ids", PositiveUniqueIDs(question.AttachmentIDs)).
+		Validate(ctx)
+}
+
+func (v *Validator) Subject(ctx context.Context, subject dto.Subject) error {
+	return NewFields().
+		Field("name", Required(strings.TrimSpace(subject.Name)), MaxLength(subject.Name, nameMaxLength)).
+		Field("description", MaxLength(subject.Description, descriptionMaxLength)).
+		Field("parent_id", NotNegative(subject.ParentId), When(subject.ParentId > 0, v.subjectExists(subject.ParentId))).
+		Validate(ctx)
+}
+
+// subjectExists passes subjects which are not in the trash
+func (v *Validator) subjectExists(subjectID int64) Check {
+	return Exists(func(ctx context.Context) (bool, error) {
+		subject, err := v.subjects.GetSubjectByID(ctx, subjectID)
+		if errors.Is(err, dto.KindNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return subject.DeletedAt == "", nil
+	})
+}
+
+func (v *Validator) questionTypeExists(typeID int64) Check {
+	return Exists(func(ctx context.Context) (bool, error) {
+		types, err := v.questions.GetQuestionTypes(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, t := range types {
+			if int64(t.ID) == typeID {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// questionsExist reports ids of questions which do not exist or are in the trash
+func (v *Validator) questionsExist(ids []int64) Check {
+	return func(ctx context.Context) error {
+		if len(ids) == 0 {
+			return nil
+		}
+		missing, err := v.questions.GetMissingQuestionIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if len(missing) != 0 {
+			return fmt.Errorf("%w: questions %v", dto.ErrReferenceNotFound, missing)
+		}
+		return nil
+	}
+}
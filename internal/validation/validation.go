@@ -0,0 +1,168 @@
+// Package validation checks request DTOs before they reach services, all violations are reported at once
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"quiz_backend_core/internal/dto"
+	"unicode/utf8"
+)
+
+// Check returns the violation of a field value. Errors of other kinds than validation
+// (e.g. failed storage lookups) stop the validation and are returned as is.
+type Check func(ctx context.Context) error
+
+type field struct {
+	name   string
+	checks []Check
+}
+
+// Fields is a declarative list of field checks, the first failed check of every field is reported
+type Fields struct {
+	fields []field
+}
+
+func NewFields() *Fields {
+	return &Fields{}
+}
+
+// Field adds checks of the field, checks run in order
+func (f *Fields) Field(name string, checks ...Check) *Fields {
+	f.fields = append(f.fields, field{
+		name:   name,
+		checks: checks,
+	})
+	return f
+}
+
+// Validate runs all checks and returns dto.ValidationErrors or nil
+func (f *Fields) Validate(ctx context.Context) error {
+	var violations dto.ValidationErrors
+	for _, field := range f.fields {
+		for _, check := range field.checks {
+			err := check(ctx)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, dto.KindValidation) {
+				return err
+			}
+			violations = append(violations, dto.NewFieldError(field.name, err))
+			break
+		}
+	}
+
+	if len(violations) != 0 {
+		return violations
+	}
+	return nil
+}
+
+// Required fails for empty string
+func Required(value string) Check {
+	return func(context.Context) error {
+		if value == "" {
+			return dto.ErrRequired
+		}
+		return nil
+	}
+}
+
+// MaxLength fails for strings longer than max characters
+func MaxLength(value string, max int) Check {
+	return func(context.Context) error {
+		if utf8.RuneCountInString(value) > max {
+			return fmt.Errorf("%w: at most %d characters", dto.ErrTooLong, max)
+		}
+		return nil
+	}
+}
+
+// Positive fails for zero and negative values
+func Positive(value int64) Check {
+	return func(context.Context) error {
+		if value <= 0 {
+			return fmt.Errorf("%w: must be positive", dto.ErrOutOfRange)
+		}
+		return nil
+	}
+}
+
+// NotNegative fails for negative values
+func NotNegative(value int64) Check {
+	return func(context.Context) error {
+		if value < 0 {
+			return fmt.Errorf("%w: must not be negative", dto.ErrOutOfRange)
+		}
+		return nil
+	}
+}
+
+// Items fails if the number of items is out of [min, max]
+func Items(count int, min int, max int) Check {
+	return func(context.Context) error {
+		if count < min || count > max {
+			return fmt.Errorf("%w: from %d to %d items are allowed", dto.ErrOutOfRange, min, max)
+		}
+		return nil
+	}
+}
+
+// PositiveUniqueIDs fails if one of ids is not positive or ids repeat
+func PositiveUniqueIDs(ids []int64) Check {
+	return func(context.Context) error {
+		seen := make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			if id <= 0 {
+				return fmt.Errorf("%w: id %d", dto.ErrOutOfRange, id)
+			}
+			if seen[id] {
+				return fmt.Errorf("%w: id %d", dto.ErrNotUnique, id)
+			}
+			seen[id] = true
+		}
+		return nil
+	}
+}
+
+// OneOf fails if the value is not among allowed ones
+func OneOf[T comparable](value T, allowed ...T) Check {
+	return func(context.Context) error {
+		for _, a := range allowed {
+			if a == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %v", dto.ErrUnknownValue, value)
+	}
+}
+
+// Exists fails if exists says the referenced object is missing
+func Exists(exists func(ctx context.Context) (bool, error)) Check {
+	return func(ctx context.Context) error {
+		ok, err := exists(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return dto.ErrReferenceNotFound
+		}
+		return nil
+	}
+}
+
+// When runs checks only if cond is true
+func When(cond bool, checks ...Check) Check {
+	return func(ctx context.Context) error {
+		if !cond {
+			return nil
+		}
+		for _, check := range checks {
+			if err := check(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
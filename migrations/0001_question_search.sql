@@ -0,0 +1,11 @@
+-- full-text search over questions (russian + english morphology)
+
+ALTER TABLE question
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', coalesce(text, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(text, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(code, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(code, '')), 'B')
+    ) STORED;
+
+CREATE INDEX question_search_vector_idx ON question USING GIN (search_vector);
@@ -0,0 +1,5 @@
+-- trigram similarity for near-duplicate questions detection
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX question_text_trgm_idx ON question USING GIN (text gin_trgm_ops);
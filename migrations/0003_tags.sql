@@ -0,0 +1,26 @@
+-- cross-cutting labels for questions and quizzes
+
+CREATE TABLE tag (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT      NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- prefix search for autocomplete
+CREATE INDEX tag_name_prefix_idx ON tag (name text_pattern_ops);
+
+CREATE TABLE question_tag (
+    question_id BIGINT NOT NULL REFERENCES question (id) ON DELETE CASCADE,
+    tag_id      BIGINT NOT NULL REFERENCES tag (id) ON DELETE CASCADE,
+    PRIMARY KEY (question_id, tag_id)
+);
+
+CREATE INDEX question_tag_tag_id_idx ON question_tag (tag_id);
+
+CREATE TABLE quiz_tag (
+    quiz_id BIGINT NOT NULL REFERENCES quiz (id) ON DELETE CASCADE,
+    tag_id  BIGINT NOT NULL REFERENCES tag (id) ON DELETE CASCADE,
+    PRIMARY KEY (quiz_id, tag_id)
+);
+
+CREATE INDEX quiz_tag_tag_id_idx ON quiz_tag (tag_id);
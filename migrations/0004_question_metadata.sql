@@ -0,0 +1,9 @@
+-- pedagogical metadata of questions
+
+ALTER TABLE question
+    ADD COLUMN difficulty             SMALLINT CHECK (difficulty BETWEEN 1 AND 5),
+    ADD COLUMN estimated_time_seconds INTEGER CHECK (estimated_time_seconds > 0),
+    ADD COLUMN bloom_level            TEXT CHECK (bloom_level IN ('remember', 'understand', 'apply', 'analyze', 'evaluate', 'create')),
+    ADD COLUMN learning_objectives    TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX question_difficulty_idx ON question (difficulty);
@@ -0,0 +1,14 @@
+-- media attachments (diagrams, audio) of questions
+
+CREATE TABLE attachment (
+    id              BIGSERIAL PRIMARY KEY,
+    question_id     BIGINT    REFERENCES question (id) ON DELETE SET NULL,
+    file_name       TEXT      NOT NULL,
+    mime_type       TEXT      NOT NULL,
+    size            BIGINT    NOT NULL,
+    storage_key     TEXT      NOT NULL UNIQUE,
+    creator_user_id BIGINT    NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX attachment_question_id_idx ON attachment (question_id);
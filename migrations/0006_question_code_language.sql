@@ -0,0 +1,5 @@
+-- language of question code and additional code blocks
+
+ALTER TABLE question
+    ADD COLUMN code_language TEXT,
+    ADD COLUMN code_blocks   JSONB NOT NULL DEFAULT '[]'::JSONB CHECK (jsonb_typeof(code_blocks) = 'array');
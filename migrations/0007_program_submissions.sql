@@ -0,0 +1,22 @@
+-- auto-graded programming questions: learner code is run against hidden tests from question answer
+
+INSERT INTO question_type (name)
+SELECT 'Программа'
+WHERE NOT EXISTS (SELECT 1 FROM question_type WHERE name = 'Программа');
+
+CREATE TABLE submission (
+    id            BIGSERIAL        PRIMARY KEY,
+    question_id   BIGINT           NOT NULL REFERENCES question (id) ON DELETE CASCADE,
+    user_id       BIGINT           NOT NULL,
+    language      TEXT             NOT NULL,
+    code          TEXT             NOT NULL,
+    status        TEXT             NOT NULL,
+    score         DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (score BETWEEN 0 AND 1),
+    passed_tests  INTEGER          NOT NULL DEFAULT 0,
+    total_tests   INTEGER          NOT NULL DEFAULT 0,
+    compile_error TEXT,
+    results       JSONB            NOT NULL DEFAULT '[]'::JSONB,
+    created_at    TIMESTAMP        NOT NULL DEFAULT now()
+);
+
+CREATE INDEX submission_question_user_idx ON submission (question_id, user_id);
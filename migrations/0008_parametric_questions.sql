@@ -0,0 +1,5 @@
+-- parametric questions: text placeholders are filled with random parameters, answer is an expression over them
+
+INSERT INTO question_type (name)
+SELECT 'Параметрический'
+WHERE NOT EXISTS (SELECT 1 FROM question_type WHERE name = 'Параметрический');
@@ -0,0 +1,6 @@
+-- ordering, numeric and fill-in-the-blanks (cloze) question types
+
+INSERT INTO question_type (name)
+SELECT name
+FROM (VALUES ('Упорядочивание'), ('Числовой'), ('Пропуски')) AS t (name)
+WHERE NOT EXISTS (SELECT 1 FROM question_type qt WHERE qt.name = t.name);
@@ -0,0 +1,7 @@
+-- source of cloned questions and quizzes
+
+ALTER TABLE question
+    ADD COLUMN source_question_id BIGINT REFERENCES question (id) ON DELETE SET NULL;
+
+ALTER TABLE quiz
+    ADD COLUMN source_quiz_id BIGINT REFERENCES quiz (id) ON DELETE SET NULL;
@@ -0,0 +1,14 @@
+-- soft delete (trash bin) of questions, quizzes and subjects, rows are purged after retention period
+
+ALTER TABLE question
+    ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE quiz
+    ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE subject
+    ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX question_deleted_at_idx ON question (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX quiz_deleted_at_idx ON quiz (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX subject_deleted_at_idx ON subject (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,19 @@
+-- subject maintainers can edit the subject and moderate its questions, the grant covers the whole subtree
+
+CREATE TABLE subject_maintainer
+(
+    subject_id         BIGINT    NOT NULL REFERENCES subject (id) ON DELETE CASCADE,
+    user_id            BIGINT    NOT NULL,
+    granted_by_user_id BIGINT,
+    created_at         TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (subject_id, user_id)
+);
+
+CREATE INDEX subject_maintainer_user_id_idx ON subject_maintainer (user_id);
+
+-- creators maintain their subjects
+INSERT INTO subject_maintainer (subject_id, user_id)
+SELECT id, creator_user_id
+FROM subject
+WHERE creator_user_id IS NOT NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,19 @@
+-- api tokens of machine integrations, only sha256 of the token is stored
+
+CREATE TABLE api_token
+(
+    id                 BIGSERIAL PRIMARY KEY,
+    name               TEXT      NOT NULL,
+    user_id            BIGINT    NOT NULL,
+    role               TEXT      NOT NULL,
+    scopes             TEXT[]    NOT NULL DEFAULT '{}',
+    token_hash         BYTEA     NOT NULL UNIQUE,
+    prefix             TEXT      NOT NULL, -- first chars of the token to recognize it in lists
+    created_by_user_id BIGINT,
+    created_at         TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at         TIMESTAMP,
+    last_used_at       TIMESTAMP,
+    revoked_at         TIMESTAMP
+);
+
+CREATE INDEX api_token_user_id_idx ON api_token (user_id);
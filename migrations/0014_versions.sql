@@ -0,0 +1,5 @@
+-- versions of editable objects for optimistic concurrency, every update increments the version
+
+ALTER TABLE question ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE quiz ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE subject ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,14 @@
+-- responses of create requests stored by Idempotency-Key, retried requests get the stored response
+
+CREATE TABLE idempotency_key
+(
+    user_id      BIGINT    NOT NULL,
+    key          TEXT      NOT NULL,
+    request_hash BYTEA     NOT NULL, -- sha256 of the request, the same key with other payload is rejected
+    response     JSONB,              -- NULL while the first request is in progress
+    created_at   TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idempotency_key_expires_at_idx ON idempotency_key (expires_at);
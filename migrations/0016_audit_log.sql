@@ -0,0 +1,38 @@
+-- append-only log of content changes, rows are written in the transaction of the change
+
+CREATE TABLE audit_log
+(
+    id            BIGSERIAL PRIMARY KEY,
+    actor_user_id BIGINT,             -- NULL for changes made by the service itself, e.g. trash purge
+    actor_role    TEXT      NOT NULL,
+    action        TEXT      NOT NULL,
+    entity_type   TEXT      NOT NULL,
+    entity_id     BIGINT    NOT NULL,
+    before        JSONB,              -- NULL for created objects
+    after         JSONB,              -- NULL for removed objects
+    request_id    TEXT,
+    created_at    TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id, created_at);
+CREATE INDEX audit_log_actor_user_id_idx ON audit_log (actor_user_id, created_at);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_delete
+    BEFORE UPDATE OR DELETE
+    ON audit_log
+    FOR EACH ROW
+EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE
+    ON audit_log
+    FOR EACH STATEMENT
+EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,19 @@
+-- notifications are written in the transaction of the change and delivered to the notifier by the dispatcher
+
+CREATE TABLE outbox_event
+(
+    id              BIGSERIAL PRIMARY KEY,
+    room            TEXT      NOT NULL,
+    event           TEXT      NOT NULL,
+    data            TEXT      NOT NULL DEFAULT '',
+    attempts        INT       NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT now(), -- also a lease of the claimed event
+    last_error      TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMP,
+    dead_at         TIMESTAMP                         -- dead letter, delivery is not retried any more
+);
+
+CREATE INDEX outbox_event_pending_idx ON outbox_event (next_attempt_at) WHERE delivered_at IS NULL AND dead_at IS NULL;
+CREATE INDEX outbox_event_delivered_at_idx ON outbox_event (delivered_at) WHERE delivered_at IS NOT NULL;
+CREATE INDEX outbox_event_dead_at_idx ON outbox_event (dead_at) WHERE dead_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- version of the object created by the request, it is replayed as ETag with the stored response
+
+ALTER TABLE idempotency_key ADD COLUMN version BIGINT NOT NULL DEFAULT 0; -- 0 if the response has no version
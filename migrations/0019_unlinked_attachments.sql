@@ -0,0 +1,3 @@
+-- uploads which are not linked to a question are purged by age, see AttachmentsStorage.PurgeUnlinkedAttachments
+
+CREATE INDEX attachment_unlinked_created_at_idx ON attachment (created_at) WHERE question_id IS NULL;
@@ -0,0 +1,534 @@
+// Package expr parses and evaluates arithmetic expressions over named float64 variables,
+// e.g. "round(d / t, 2)" or "sqrt(2 * h / g)".
+//
+// Supported: numbers, variables, + - * / % ^ (power, right associative), unary minus,
+// parentheses, constants pi and e, functions from the functions table.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrSyntax          = errors.New("syntax error")
+	ErrUnknownVariable = errors.New("unknown variable")
+	ErrUnknownFunction = errors.New("unknown function")
+	ErrArgumentsCount  = errors.New("wrong number of function arguments")
+	ErrNotANumber      = errors.New("result is not a finite number")
+	ErrTooComplex      = errors.New("expression is too complex")
+)
+
+// maxNodes limits expression size, so stored expressions can not be used to exhaust the service
+const maxNodes = 1000
+
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+type function struct {
+	args int // -1 - one or more
+	fn   func(args []float64) float64
+}
+
+var functions = map[string]function{
+	"abs":   {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt":  {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"cbrt":  {1, func(a []float64) float64 { return math.Cbrt(a[0]) }},
+	"exp":   {1, func(a []float64) float64 { return math.Exp(a[0]) }},
+	"ln":    {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"log10": {1, func(a []float64) float64 { return math.Log10(a[0]) }},
+	"log2":  {1, func(a []float64) float64 { return math.Log2(a[0]) }},
+	"sin":   {1, func(a []float64) float64 { return math.Sin(a[0]) }},
+	"cos":   {1, func(a []float64) float64 { return math.Cos(a[0]) }},
+	"tan":   {1, func(a []float64) float64 { return math.Tan(a[0]) }},
+	"asin":  {1, func(a []float64) float64 { return math.Asin(a[0]) }},
+	"acos":  {1, func(a []float64) float64 { return math.Acos(a[0]) }},
+	"atan":  {1, func(a []float64) float64 { return math.Atan(a[0]) }},
+	"floor": {1, func(a []float64) float64 { return math.Floor(a[0]) }},
+	"ceil":  {1, func(a []float64) float64 { return math.Ceil(a[0]) }},
+	"pow":   {2, func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+	"round": {2, func(a []float64) float64 { return Round(a[0], int(a[1])) }},
+	"min": {-1, func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	}},
+	"max": {-1, func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	}},
+}
+
+// Expression is a parsed expression, it is safe for concurrent use
+type Expression struct {
+	source string
+	root   node
+	vars   []string
+}
+
+// Parse parses the expression, variables are not checked until evaluation (see Variables)
+func Parse(source string) (*Expression, error) {
+	p := &parser{lexer: lexer{src: source}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	root, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+
+	vars := make([]string, 0, len(p.vars))
+	for name := range p.vars {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+
+	return &Expression{source: source, root: root, vars: vars}, nil
+}
+
+// Variables returns sorted names of variables used in the expression
+func (e *Expression) Variables() []string {
+	return e.vars
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression, the result is always a finite number
+func (e *Expression) Eval(vars map[string]float64) (float64, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, ErrNotANumber
+	}
+	return v, nil
+}
+
+// Round rounds x to the given number of decimal places, negative places round to tens, hundreds etc.
+func Round(x float64, places int) float64 {
+	if places > 15 {
+		return x
+	}
+	if places < -15 {
+		places = -15
+	}
+	p := math.Pow(10, float64(places))
+	return math.Round(x*p) / p
+}
+
+// IsIdentifier reports whether name can be used as a variable name
+func IsIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	_, isConstant := constants[name]
+	_, isFunction := functions[name]
+	return !isConstant && !isFunction
+}
+
+// *** AST *************************************************************************************************************
+
+type node interface {
+	eval(vars map[string]float64) (float64, error)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(map[string]float64) (float64, error) {
+	return float64(n), nil
+}
+
+type variableNode string
+
+func (n variableNode) eval(vars map[string]float64) (float64, error) {
+	v, ok := vars[string(n)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownVariable, string(n))
+	}
+	return v, nil
+}
+
+type unaryNode struct {
+	operand node
+}
+
+func (n unaryNode) eval(vars map[string]float64) (float64, error) {
+	v, err := n.operand.eval(vars)
+	return -v, err
+}
+
+type binaryNode struct {
+	op          byte
+	left, right node
+}
+
+func (n binaryNode) eval(vars map[string]float64) (float64, error) {
+	l, err := n.left.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		return l / r, nil
+	case '%':
+		return math.Mod(l, r), nil
+	case '^':
+		return math.Pow(l, r), nil
+	}
+	return 0, fmt.Errorf("%w: unknown operator %q", ErrSyntax, n.op)
+}
+
+type callNode struct {
+	fn   function
+	args []node
+}
+
+func (n callNode) eval(vars map[string]float64) (float64, error) {
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(vars)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	return n.fn.fn(args), nil
+}
+
+// *** lexer ***********************************************************************************************************
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		l.pos += size
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		// exponent: 1e5, 2.5E-3
+		if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+			end := l.pos + 1
+			if end < len(l.src) && (l.src[end] == '+' || l.src[end] == '-') {
+				end++
+			}
+			if end < len(l.src) && isDigit(l.src[end]) {
+				for end < len(l.src) && isDigit(l.src[end]) {
+					end++
+				}
+				l.pos = end
+			}
+		}
+		return token{kind: tokenNumber, text: l.src[start:l.pos], pos: start}, nil
+	case r == '_' || unicode.IsLetter(r):
+		// the same runes as in IsIdentifier
+		for l.pos < len(l.src) {
+			r, size = utf8.DecodeRuneInString(l.src[l.pos:])
+			if !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				break
+			}
+			l.pos += size
+		}
+		return token{kind: tokenIdent, text: l.src[start:l.pos], pos: start}, nil
+	case strings.IndexByte("+-*/%^", c) >= 0:
+		l.pos++
+		return token{kind: tokenOperator, text: string(c), pos: start}, nil
+	case c == '(':
+		l.pos++
+		return token{kind: tokenLParen, text: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokenRParen, text: ")", pos: start}, nil
+	case c == ',':
+		l.pos++
+		return token{kind: tokenComma, text: ",", pos: start}, nil
+	}
+	if r == utf8.RuneError && size <= 1 {
+		return token{}, fmt.Errorf("%w at %d: invalid UTF-8", ErrSyntax, start)
+	}
+	return token{}, fmt.Errorf("%w at %d: unexpected character %q", ErrSyntax, start, r)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// *** parser **********************************************************************************************************
+
+// parser is a recursive descent parser:
+//
+//	expression = term { ("+" | "-") term }
+//	term       = unary { ("*" | "/" | "%") unary }
+//	unary      = "-" unary | "+" unary | power
+//	power      = primary [ "^" unary ]
+//	primary    = number | ident | ident "(" expression { "," expression } ")" | "(" expression ")"
+type parser struct {
+	lexer lexer
+	tok   token
+	nodes int
+	vars  map[string]struct{}
+}
+
+func (p *parser) next() (err error) {
+	p.tok, err = p.lexer.next()
+	return err
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w at %d: %s", ErrSyntax, p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) count() error {
+	p.nodes++
+	if p.nodes > maxNodes {
+		return ErrTooComplex
+	}
+	return nil
+}
+
+func (p *parser) isOperator(ops string) bool {
+	return p.tok.kind == tokenOperator && strings.Contains(ops, p.tok.text)
+}
+
+func (p *parser) parseExpression() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+-") {
+		op := p.tok.text[0]
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.count(); err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseTerm() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*/%") {
+		op := p.tok.text[0]
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.count(); err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("+-") {
+		negative := p.tok.text == "-"
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if !negative {
+			return operand, nil
+		}
+		if err = p.count(); err != nil {
+			return nil, err
+		}
+		return unaryNode{operand: operand}, nil
+	}
+	return p.parsePower()
+}
+
+func (p *parser) parsePower() (node, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("^") {
+		return base, nil
+	}
+	if err = p.next(); err != nil {
+		return nil, err
+	}
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.count(); err != nil {
+		return nil, err
+	}
+	return binaryNode{op: '^', left: base, right: exponent}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if err := p.count(); err != nil {
+		return nil, err
+	}
+
+	tok := p.tok
+	switch tok.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", tok.text)
+		}
+		return numberNode(v), p.next()
+	case tokenLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenRParen {
+			return nil, p.errorf("expected \")\"")
+		}
+		return inner, p.next()
+	case tokenIdent:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenLParen {
+			return p.parseCall(tok)
+		}
+		if v, ok := constants[tok.text]; ok {
+			return numberNode(v), nil
+		}
+		if p.vars == nil {
+			p.vars = map[string]struct{}{}
+		}
+		p.vars[tok.text] = struct{}{}
+		return variableNode(tok.text), nil
+	case tokenEOF:
+		return nil, p.errorf("unexpected end of expression")
+	}
+	return nil, p.errorf("unexpected %q", tok.text)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFunction, name.text)
+	}
+
+	// skip "("
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	var args []node
+	for {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if p.tok.kind != tokenComma {
+			break
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.tok.kind != tokenRParen {
+		return nil, p.errorf("expected \")\"")
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if (fn.args == -1 && len(args) == 0) || (fn.args != -1 && len(args) != fn.args) {
+		return nil, fmt.Errorf("%w: %s", ErrArgumentsCount, name.text)
+	}
+
+	return callNode{fn: fn, args: args}, nil
+}
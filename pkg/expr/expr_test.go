@@ -0,0 +1,183 @@
+package expr
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]float64{"x": 3, "y": -2, "h": 20, "g": 9.8, "д": 10, "t": 4, "скорость_2": 1.5}
+
+	tests := []struct {
+		source string
+		want   float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"24 / 4 / 2", 3},
+		{"7 % 4 * 2", 6},
+		{"2 ^ 3 ^ 2", 512},
+		{"2 * 3 ^ 2", 18},
+		{"-2 ^ 2", -4},
+		{"(-2) ^ 2", 4},
+		{"2 ^ -1", 0.5},
+		{"--x", 3},
+		{"+x - -y", 1},
+		{"x * y + 1", -5},
+		{"1.5e2 + 2E-1", 150.2},
+		{"2 * pi", 2 * math.Pi},
+		{"ln(e)", 1},
+		{"sqrt(2 * h / g)", math.Sqrt(2 * 20 / 9.8)},
+		{"max(x, y, 10) - min(x, y)", 12},
+		{"pow(2, 10)", 1024},
+		{"abs(y) + floor(2.7) + ceil(2.1)", 7},
+		{"round(10 / 3, 2)", 3.33},
+		{"д/t", 2.5},
+		{"скорость_2 * д - t", 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			e, err := Parse(tt.source)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got, err := e.Eval(vars)
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		vars   map[string]float64
+		want   error
+	}{
+		{"1 / 0", nil, ErrNotANumber},
+		{"x / (y - y)", map[string]float64{"x": 1, "y": 2}, ErrNotANumber},
+		{"0 / 0", nil, ErrNotANumber},
+		{"5 % 0", nil, ErrNotANumber},
+		{"sqrt(-1)", nil, ErrNotANumber},
+		{"ln(0)", nil, ErrNotANumber},
+		{"10 ^ 400", nil, ErrNotANumber},
+		{"x + 1", nil, ErrUnknownVariable},
+		{"x + z", map[string]float64{"x": 1}, ErrUnknownVariable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			e, err := Parse(tt.source)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got, err := e.Eval(tt.vars)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, %v, want error %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   error
+	}{
+		{"", ErrSyntax},
+		{"1 +", ErrSyntax},
+		{"(1 + 2", ErrSyntax},
+		{"1 + 2)", ErrSyntax},
+		{"2 3", ErrSyntax},
+		{"1 $ 2", ErrSyntax},
+		{"1..2", ErrSyntax},
+		{"д ∑ 2", ErrSyntax},
+		{"1 + \xff", ErrSyntax},
+		{"max()", ErrSyntax},
+		{"foo(1)", ErrUnknownFunction},
+		{"sqrt(1, 2)", ErrArgumentsCount},
+		{"round(1)", ErrArgumentsCount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if _, err := Parse(tt.source); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTooComplex(t *testing.T) {
+	source := "1"
+	for i := 0; i < maxNodes; i++ {
+		source += "+1"
+	}
+	if _, err := Parse(source); !errors.Is(err, ErrTooComplex) {
+		t.Errorf("got %v, want %v", err, ErrTooComplex)
+	}
+}
+
+func TestVariables(t *testing.T) {
+	e, err := Parse("b * a + sin(a) - pi * c")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got, want := e.Variables(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		x      float64
+		places int
+		want   float64
+	}{
+		{3.14159, 2, 3.14},
+		{3.145, 0, 3},
+		{2.5, 0, 3},
+		{-2.5, 0, -3},
+		{1.005, 1, 1},
+		{0.125, 2, 0.13},
+		{1234.5, -2, 1200},
+		{1250, -2, 1300},
+		{1234.5, -20, 0},
+		{0.1234567890123456789, 20, 0.1234567890123456789},
+	}
+
+	for _, tt := range tests {
+		if got := Round(tt.x, tt.places); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Round(%v, %d) = %v, want %v", tt.x, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestIsIdentifier(t *testing.T) {
+	tests := map[string]bool{
+		"x":          true,
+		"speed_1":    true,
+		"_t":         true,
+		"д":          true,
+		"скорость_2": true,
+		"":           false,
+		"1x":         false,
+		"a-b":        false,
+		"pi":         false,
+		"sqrt":       false,
+	}
+
+	for name, want := range tests {
+		if got := IsIdentifier(name); got != want {
+			t.Errorf("IsIdentifier(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Package highlight splits source code into typed tokens, so clients can render
+// syntax highlighting without shipping their own lexers.
+package highlight
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenType string
+
+const (
+	TokenKeyword     TokenType = "keyword"
+	TokenBuiltin     TokenType = "builtin"
+	TokenIdentifier  TokenType = "identifier"
+	TokenString      TokenType = "string"
+	TokenNumber      TokenType = "number"
+	TokenComment     TokenType = "comment"
+	TokenOperator    TokenType = "operator"
+	TokenPunctuation TokenType = "punctuation"
+	TokenWhitespace  TokenType = "whitespace"
+	TokenText        TokenType = "text"
+)
+
+const (
+	operatorChars    = "+-*/%=&|<>!^~?:@"
+	punctuationChars = "()[]{},;."
+)
+
+type Token struct {
+	Type TokenType `json:"type"`
+	Text string    `json:"text"`
+}
+
+// Tokenize splits code into tokens according to the language rules. Concatenation of
+// token texts is always equal to the code. Unknown languages are tokenized as plain text.
+func Tokenize(language, code string) []Token {
+	name, ok := Normalize(language)
+	if !ok || name == PlainText {
+		if code == "" {
+			return []Token{}
+		}
+		return []Token{{Type: TokenText, Text: code}}
+	}
+
+	l := newLexer(languages[name], code)
+	return l.run()
+}
+
+type lexer struct {
+	lang     Language
+	src      string
+	pos      int
+	tokens   []Token
+	keywords map[string]struct{}
+	builtins map[string]struct{}
+}
+
+func newLexer(lang Language, src string) *lexer {
+	l := &lexer{
+		lang:     lang,
+		src:      src,
+		tokens:   []Token{},
+		keywords: make(map[string]struct{}, len(lang.Keywords)),
+		builtins: make(map[string]struct{}, len(lang.Builtins)),
+	}
+	for _, k := range lang.Keywords {
+		l.keywords[l.fold(k)] = struct{}{}
+	}
+	for _, b := range lang.Builtins {
+		l.builtins[l.fold(b)] = struct{}{}
+	}
+	return l
+}
+
+func (l *lexer) fold(s string) string {
+	if l.lang.CaseInsensitive {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+func (l *lexer) emit(t TokenType, end int) {
+	if end <= l.pos {
+		return
+	}
+	text := l.src[l.pos:end]
+	l.pos = end
+
+	// adjacent text-like tokens of the same type are merged to keep output compact
+	if n := len(l.tokens); n > 0 && l.tokens[n-1].Type == t && (t == TokenText || t == TokenWhitespace) {
+		l.tokens[n-1].Text += text
+		return
+	}
+	l.tokens = append(l.tokens, Token{Type: t, Text: text})
+}
+
+func (l *lexer) run() []Token {
+	for l.pos < len(l.src) {
+		rest := l.src[l.pos:]
+		r, size := utf8.DecodeRuneInString(rest)
+
+		switch {
+		case unicode.IsSpace(r):
+			l.emit(TokenWhitespace, l.pos+l.scanWhile(unicode.IsSpace))
+		case l.lineComment(rest):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			l.emit(TokenComment, l.pos+end)
+		case l.blockComment(rest):
+		case l.stringLiteral(rest):
+		case isDigitByte(rest[0]) || (r == '.' && len(rest) > 1 && isDigitByte(rest[1])):
+			l.emit(TokenNumber, l.pos+l.scanNumber(rest))
+		case l.isIdentStart(r):
+			end := l.pos + l.scanWhile(l.isIdentPart)
+			word := l.src[l.pos:end]
+			switch {
+			case l.isKeyword(word):
+				l.emit(TokenKeyword, end)
+			case l.isBuiltin(word):
+				l.emit(TokenBuiltin, end)
+			default:
+				l.emit(TokenIdentifier, end)
+			}
+		case strings.ContainsRune(operatorChars, r):
+			l.emit(TokenOperator, l.pos+l.scanWhile(func(r rune) bool {
+				return strings.ContainsRune(operatorChars, r)
+			}))
+		case strings.ContainsRune(punctuationChars, r):
+			l.emit(TokenPunctuation, l.pos+size)
+		default:
+			l.emit(TokenText, l.pos+size)
+		}
+	}
+	return l.tokens
+}
+
+func (l *lexer) isKeyword(word string) bool {
+	_, ok := l.keywords[l.fold(word)]
+	return ok
+}
+
+func (l *lexer) isBuiltin(word string) bool {
+	_, ok := l.builtins[l.fold(word)]
+	return ok
+}
+
+func (l *lexer) isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || strings.ContainsRune(l.lang.IdentifierExtraChars, r)
+}
+
+func (l *lexer) isIdentPart(r rune) bool {
+	return l.isIdentStart(r) || unicode.IsDigit(r)
+}
+
+// scanWhile returns length of the longest prefix of the rest of source which runes satisfy f
+func (l *lexer) scanWhile(f func(rune) bool) int {
+	rest := l.src[l.pos:]
+	n := 0
+	for n < len(rest) {
+		r, size := utf8.DecodeRuneInString(rest[n:])
+		if !f(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+func (l *lexer) scanNumber(rest string) int {
+	n := 0
+	for n < len(rest) {
+		c := rest[n]
+		switch {
+		case isDigitByte(c) || c == '_' || c == '.' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+			n++
+		case (c == '+' || c == '-') && n > 0 && (rest[n-1]|0x20 == 'e' || rest[n-1]|0x20 == 'p'):
+			n++
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func (l *lexer) lineComment(rest string) bool {
+	for _, prefix := range l.lang.LineComments {
+		if strings.HasPrefix(rest, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *lexer) blockComment(rest string) bool {
+	for _, delims := range l.lang.BlockComments {
+		if !strings.HasPrefix(rest, delims[0]) {
+			continue
+		}
+		end := strings.Index(rest[len(delims[0]):], delims[1])
+		if end < 0 {
+			end = len(rest)
+		} else {
+			end += len(delims[0]) + len(delims[1])
+		}
+		l.emit(TokenComment, l.pos+end)
+		return true
+	}
+	return false
+}
+
+func (l *lexer) stringLiteral(rest string) bool {
+	for _, delim := range l.lang.StringDelimiters {
+		if strings.HasPrefix(rest, delim) {
+			l.emit(TokenString, l.pos+scanString(rest, delim, true))
+			return true
+		}
+	}
+	for _, delim := range l.lang.RawStringDelimiters {
+		if strings.HasPrefix(rest, delim) {
+			l.emit(TokenString, l.pos+scanString(rest, delim, false))
+			return true
+		}
+	}
+	return false
+}
+
+// scanString returns length of string literal at the beginning of s, unterminated literal lasts until
+// the end of line (or the end of code for multiline delimiters)
+func scanString(s, delim string, escapes bool) int {
+	multiline := len(delim) > 1 || delim == "`"
+	i := len(delim)
+	for i < len(s) {
+		switch {
+		case escapes && s[i] == '\\' && i+1 < len(s):
+			i += 2
+		case strings.HasPrefix(s[i:], delim):
+			return i + len(delim)
+		case s[i] == '\n' && !multiline:
+			return i
+		default:
+			i++
+		}
+	}
+	return len(s)
+}
+
+func isDigitByte(c byte) bool {
+	return c >= '0' && c <= '9'
+}
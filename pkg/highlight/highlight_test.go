@@ -0,0 +1,121 @@
+package highlight
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// tricky is tokenized in every language, tokens must cover it whatever the rules are
+var tricky = []string{
+	"",
+	" \t\n",
+	`"unterminated`,
+	`'unterminated\`,
+	"\"line\nnext",
+	"`raw",
+	`"""triple`,
+	"/* open comment",
+	"// comment without newline",
+	"# comment without newline",
+	"-- comment without newline",
+	"=begin\nopen",
+	"привет := \"мир\" // комментарий",
+	"x = '😀' + `日本語`",
+	"٣ + ३",
+	"1e+ 0x1p-3 .5 1_000",
+	"a\xffb \xe2\x82",
+	"�\xff",
+	"$var @attr ?: -> ::",
+}
+
+func concat(tokens []Token) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteString(t.Text)
+	}
+	return sb.String()
+}
+
+func checkTokens(t *testing.T, language, code string) []Token {
+	t.Helper()
+
+	tokens := Tokenize(language, code)
+	if got := concat(tokens); got != code {
+		t.Errorf("Tokenize(%q, %q) covers %q", language, code, got)
+	}
+	for _, token := range tokens {
+		if token.Text == "" {
+			t.Errorf("Tokenize(%q, %q) has empty %s token", language, code, token.Type)
+		}
+	}
+	return tokens
+}
+
+func TestTokenizeCoversCode(t *testing.T) {
+	names := Languages()
+	sort.Strings(names)
+	names = append(names, "unknown")
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			for _, code := range tricky {
+				checkTokens(t, name, code)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		language string
+		code     string
+		want     []Token // expected tokens in order, others are skipped
+	}{
+		{"go", "func main() { s := \"ok\" } // done", []Token{
+			{TokenKeyword, "func"}, {TokenIdentifier, "main"}, {TokenString, `"ok"`}, {TokenComment, "// done"}}},
+		{"go", "s := \"open\nx", []Token{{TokenString, `"open`}, {TokenIdentifier, "x"}}},
+		{"go", "x /* open", []Token{{TokenIdentifier, "x"}, {TokenComment, "/* open"}}},
+		{"go", "`raw\n\\`", []Token{{TokenString, "`raw\n\\`"}}},
+		{"golang", "имя := len(\"ё\")", []Token{
+			{TokenIdentifier, "имя"}, {TokenBuiltin, "len"}, {TokenString, `"ё"`}}},
+		{"python", "def f(): return '''doc\nmore", []Token{
+			{TokenKeyword, "def"}, {TokenKeyword, "return"}, {TokenString, "'''doc\nmore"}}},
+		{"python", "x = 'it\\'s' # 注释", []Token{{TokenString, `'it\'s'`}, {TokenComment, "# 注释"}}},
+		{"javascript", "const $el = `a\nb", []Token{
+			{TokenKeyword, "const"}, {TokenIdentifier, "$el"}, {TokenString, "`a\nb"}}},
+		{"typescript", "let x: number = 0x1F // 🚀", []Token{
+			{TokenKeyword, "let"}, {TokenNumber, "0x1F"}, {TokenComment, "// 🚀"}}},
+		{"java", "String s = \"a\\\"b\";", []Token{{TokenString, `"a\"b"`}, {TokenPunctuation, ";"}}},
+		{"c", "int x = 1.5e-3; /* end", []Token{{TokenNumber, "1.5e-3"}, {TokenComment, "/* end"}}},
+		{"c++", "auto s = \"λ\";", []Token{{TokenString, `"λ"`}}},
+		{"c#", "var s = \"x\\", []Token{{TokenString, `"x\`}}},
+		{"kotlin", "val s = \"ü\" /* ü */", []Token{{TokenString, `"ü"`}, {TokenComment, "/* ü */"}}},
+		{"rust", "let s = \"ß\"; // ß", []Token{{TokenString, `"ß"`}, {TokenComment, "// ß"}}},
+		{"php", "$x = 'é'; # é", []Token{{TokenString, "'é'"}, {TokenComment, "# é"}}},
+		{"ruby", "x = 1\n=begin\nopen", []Token{{TokenNumber, "1"}, {TokenComment, "=begin\nopen"}}},
+		{"sql", "SELECT 'ä' -- done", []Token{
+			{TokenKeyword, "SELECT"}, {TokenString, "'ä'"}, {TokenComment, "-- done"}}},
+		{"sql", "select 'open", []Token{{TokenKeyword, "select"}, {TokenString, "'open"}}},
+		{"bash", "echo 'raw\\' \"ж", []Token{
+			{TokenBuiltin, "echo"}, {TokenString, `'raw\'`}, {TokenString, `"ж`}}},
+		{"text", "any \"code", []Token{{TokenText, "any \"code"}}},
+		{"cobol", "MOVE 1", []Token{{TokenText, "MOVE 1"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language+" "+tt.code, func(t *testing.T) {
+			tokens := checkTokens(t, tt.language, tt.code)
+
+			want := tt.want
+			for _, token := range tokens {
+				if len(want) > 0 && token == want[0] {
+					want = want[1:]
+				}
+			}
+			if len(want) > 0 {
+				t.Errorf("token %+v is missing in %+v", want[0], tokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package highlight
+
+import "strings"
+
+// Language describes lexical rules of a programming language, which are enough for highlighting
+type Language struct {
+	Name                 string
+	Keywords             []string
+	Builtins             []string // types, constants and builtin functions
+	LineComments         []string
+	BlockComments        [][2]string
+	StringDelimiters     []string // string literal delimiters, escaped with backslash
+	RawStringDelimiters  []string // string literal delimiters without escaping
+	IdentifierExtraChars string   // chars allowed in identifiers besides letters, digits and "_"
+	CaseInsensitive      bool
+}
+
+const PlainText = "plaintext"
+
+var languages = map[string]Language{
+	PlainText: {
+		Name: PlainText,
+	},
+	"go": {
+		Name: "go",
+		Keywords: []string{"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+			"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select",
+			"struct", "switch", "type", "var"},
+		Builtins: []string{"bool", "byte", "complex64", "complex128", "error", "float32", "float64", "int", "int8",
+			"int16", "int32", "int64", "rune", "string", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+			"any", "true", "false", "iota", "nil", "append", "cap", "clear", "close", "complex", "copy", "delete",
+			"imag", "len", "make", "max", "min", "new", "panic", "print", "println", "real", "recover"},
+		LineComments:        []string{"//"},
+		BlockComments:       [][2]string{{"/*", "*/"}},
+		StringDelimiters:    []string{`"`, `'`},
+		RawStringDelimiters: []string{"`"},
+	},
+	"python": {
+		Name: "python",
+		Keywords: []string{"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del",
+			"elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda",
+			"nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield", "match", "case"},
+		Builtins: []string{"True", "False", "None", "int", "float", "str", "bool", "list", "dict", "set", "tuple",
+			"bytes", "object", "print", "len", "range", "enumerate", "zip", "map", "filter", "sum", "min", "max",
+			"abs", "sorted", "reversed", "input", "open", "isinstance", "type", "super", "self"},
+		LineComments:     []string{"#"},
+		StringDelimiters: []string{`"""`, `'''`, `"`, `'`},
+	},
+	"javascript": {
+		Name: "javascript",
+		Keywords: []string{"async", "await", "break", "case", "catch", "class", "const", "continue", "debugger",
+			"default", "delete", "do", "else", "export", "extends", "finally", "for", "function", "if", "import",
+			"in", "instanceof", "let", "new", "of", "return", "static", "super", "switch", "this", "throw", "try",
+			"typeof", "var", "void", "while", "with", "yield"},
+		Builtins: []string{"true", "false", "null", "undefined", "NaN", "Infinity", "console", "Object", "Array",
+			"String", "Number", "Boolean", "Promise", "Map", "Set", "JSON", "Math", "Date", "Error"},
+		LineComments:         []string{"//"},
+		BlockComments:        [][2]string{{"/*", "*/"}},
+		StringDelimiters:     []string{`"`, `'`, "`"},
+		IdentifierExtraChars: "$",
+	},
+	"typescript": {
+		Name: "typescript",
+		Keywords: []string{"abstract", "as", "async", "await", "break", "case", "catch", "class", "const",
+			"continue", "declare", "default", "delete", "do", "else", "enum", "export", "extends", "finally", "for",
+			"function", "if", "implements", "import", "in", "instanceof", "interface", "keyof", "let", "namespace",
+			"new", "of", "private", "protected", "public", "readonly", "return", "static", "super", "switch", "this",
+			"throw", "try", "type", "typeof", "var", "void", "while", "yield"},
+		Builtins: []string{"true", "false", "null", "undefined", "any", "unknown", "never", "string", "number",
+			"boolean", "object", "symbol", "bigint", "console", "Promise", "Array", "Record", "Partial"},
+		LineComments:         []string{"//"},
+		BlockComments:        [][2]string{{"/*", "*/"}},
+		StringDelimiters:     []string{`"`, `'`, "`"},
+		IdentifierExtraChars: "$",
+	},
+	"java": {
+		Name: "java",
+		Keywords: []string{"abstract", "assert", "break", "case", "catch", "class", "const", "continue", "default",
+			"do", "else", "enum", "extends", "final", "finally", "for", "goto", "if", "implements", "import",
+			"instanceof", "interface", "native", "new", "package", "private", "protected", "public", "return",
+			"static", "strictfp", "super", "switch", "synchronized", "this", "throw", "throws", "transient", "try",
+			"var", "void", "volatile", "while", "record", "yield"},
+		Builtins: []string{"boolean", "byte", "char", "double", "float", "int", "long", "short", "true", "false",
+			"null", "String", "Object", "Integer", "Long", "Double", "List", "Map", "Set", "System"},
+		LineComments:     []string{"//"},
+		BlockComments:    [][2]string{{"/*", "*/"}},
+		StringDelimiters: []string{`"""`, `"`, `'`},
+	},
+	"c": {
+		Name: "c",
+		Keywords: []string{"auto", "break", "case", "const", "continue", "default", "do", "else", "enum", "extern",
+			"for", "goto", "if", "inline", "register", "restrict", "return", "sizeof", "static", "struct", "switch",
+			"typedef", "union", "volatile", "while", "#include", "#define", "#ifdef", "#ifndef", "#endif", "#if",
+			"#else", "#pragma"},
+		Builtins: []string{"char", "double", "float", "int", "long", "short", "signed", "unsigned", "void",
+			"size_t", "bool", "true", "false", "NULL", "printf", "scanf", "malloc", "free"},
+		LineComments:         []string{"//"},
+		BlockComments:        [][2]string{{"/*", "*/"}},
+		StringDelimiters:     []string{`"`, `'`},
+		IdentifierExtraChars: "#",
+	},
+	"cpp": {
+		Name: "cpp",
+		Keywords: []string{"alignas", "auto", "break", "case", "catch", "class", "const", "constexpr", "continue",
+			"default", "delete", "do", "else", "enum", "explicit", "extern", "for", "friend", "goto", "if", "inline",
+			"mutable", "namespace", "new", "noexcept", "operator", "private", "protected", "public", "return",
+			"sizeof", "static", "struct", "switch", "template", "this", "throw", "try", "typedef", "typename",
+			"union", "using", "virtual", "volatile", "while", "#include", "#define", "#ifdef", "#ifndef", "#endif",
+			"#if", "#else", "#pragma"},
+		Builtins: []string{"bool", "char", "double", "float", "int", "long", "short", "signed", "unsigned", "void",
+			"size_t", "true", "false", "nullptr", "std", "string", "vector", "map", "cout", "cin", "endl"},
+		LineComments:         []string{"//"},
+		BlockComments:        [][2]string{{"/*", "*/"}},
+		StringDelimiters:     []string{`"`, `'`},
+		IdentifierExtraChars: "#",
+	},
+	"csharp": {
+		Name: "csharp",
+		Keywords: []string{"abstract", "as", "async", "await", "base", "break", "case", "catch", "class", "const",
+			"continue", "default", "delegate", "do", "else", "enum", "event", "explicit", "extern", "finally",
+			"fixed", "for", "foreach", "goto", "if", "implicit", "in", "interface", "internal", "is", "lock",
+			"namespace", "new", "operator", "out", "override", "params", "private", "protected", "public",
+			"readonly", "ref", "return", "sealed", "sizeof", "static", "struct", "switch", "this", "throw", "try",
+			"typeof", "using", "var", "virtual", "void", "while", "yield"},
+		Builtins: []string{"bool", "byte", "char", "decimal", "double", "float", "int", "long", "object", "sbyte",
+			"short", "string", "uint", "ulong", "ushort", "true", "false", "null", "Console", "List", "Dictionary"},
+		LineComments:     []string{"//"},
+		BlockComments:    [][2]string{{"/*", "*/"}},
+		StringDelimiters: []string{`"`, `'`},
+	},
+	"kotlin": {
+		Name: "kotlin",
+		Keywords: []string{"as", "break", "class", "continue", "do", "else", "for", "fun", "if", "in", "interface",
+			"is", "object", "package", "return", "super", "this", "throw", "try", "typealias", "val", "var", "when",
+			"while", "import", "data", "sealed", "enum", "open", "override", "private", "protected", "public",
+			"internal", "companion", "suspend", "lateinit"},
+		Builtins: []string{"true", "false", "null", "Int", "Long", "Double", "Float", "Boolean", "String", "Char",
+			"Unit", "Any", "List", "Map", "Set", "println", "listOf", "mapOf", "setOf"},
+		LineComments:     []string{"//"},
+		BlockComments:    [][2]string{{"/*", "*/"}},
+		StringDelimiters: []string{`"""`, `"`, `'`},
+	},
+	"rust": {
+		Name: "rust",
+		Keywords: []string{"as", "async", "await", "break", "const", "continue", "crate", "dyn", "else", "enum",
+			"extern", "fn", "for", "if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref",
+			"return", "self", "Self", "static", "struct", "super", "trait", "type", "unsafe", "use", "where",
+			"while"},
+		Builtins: []string{"bool", "char", "f32", "f64", "i8", "i16", "i32", "i64", "i128", "isize", "u8", "u16",
+			"u32", "u64", "u128", "usize", "str", "String", "Vec", "Option", "Result", "Some", "None", "Ok", "Err",
+			"true", "false", "println"},
+		LineComments:     []string{"//"},
+		BlockComments:    [][2]string{{"/*", "*/"}},
+		StringDelimiters: []string{`"`},
+	},
+	"php": {
+		Name: "php",
+		Keywords: []string{"abstract", "and", "as", "break", "case", "catch", "class", "clone", "const", "continue",
+			"declare", "default", "do", "echo", "else", "elseif", "extends", "final", "finally", "fn", "for",
+			"foreach", "function", "global", "if", "implements", "include", "instanceof", "interface", "match",
+			"namespace", "new", "or", "print", "private", "protected", "public", "require", "return", "static",
+			"switch", "throw", "trait", "try", "use", "var", "while", "yield"},
+		Builtins:             []string{"true", "false", "null", "array", "int", "float", "string", "bool", "$this"},
+		LineComments:         []string{"//", "#"},
+		BlockComments:        [][2]string{{"/*", "*/"}},
+		StringDelimiters:     []string{`"`, `'`},
+		IdentifierExtraChars: "$",
+	},
+	"ruby": {
+		Name: "ruby",
+		Keywords: []string{"alias", "and", "begin", "break", "case", "class", "def", "defined?", "do", "else",
+			"elsif", "end", "ensure", "for", "if", "in", "module", "next", "not", "or", "redo", "rescue", "retry",
+			"return", "self", "super", "then", "undef", "unless", "until", "when", "while", "yield"},
+		Builtins:             []string{"true", "false", "nil", "puts", "print", "require", "attr_accessor", "new"},
+		LineComments:         []string{"#"},
+		BlockComments:        [][2]string{{"=begin", "=end"}},
+		StringDelimiters:     []string{`"`, `'`},
+		IdentifierExtraChars: "?!@",
+	},
+	"sql": {
+		Name: "sql",
+		Keywords: []string{"select", "from", "where", "and", "or", "not", "insert", "into", "values", "update",
+			"set", "delete", "create", "table", "alter", "drop", "index", "join", "left", "right", "inner", "outer",
+			"full", "on", "group", "by", "order", "having", "limit", "offset", "as", "distinct", "union", "all",
+			"in", "exists", "between", "like", "is", "null", "case", "when", "then", "else", "end", "with",
+			"returning", "primary", "key", "foreign", "references", "default", "asc", "desc"},
+		Builtins: []string{"count", "sum", "avg", "min", "max", "coalesce", "now", "int", "integer", "bigint",
+			"text", "varchar", "boolean", "timestamp", "date", "true", "false"},
+		LineComments:     []string{"--"},
+		BlockComments:    [][2]string{{"/*", "*/"}},
+		StringDelimiters: []string{`'`, `"`},
+		CaseInsensitive:  true,
+	},
+	"bash": {
+		Name: "bash",
+		Keywords: []string{"if", "then", "else", "elif", "fi", "for", "while", "until", "do", "done", "case", "esac",
+			"in", "function", "return", "local", "export", "select"},
+		Builtins:             []string{"echo", "cd", "ls", "read", "printf", "test", "exit", "source", "set", "unset"},
+		LineComments:         []string{"#"},
+		StringDelimiters:     []string{`"`},
+		RawStringDelimiters:  []string{`'`},
+		IdentifierExtraChars: "$-",
+	},
+}
+
+var aliases = map[string]string{
+	"golang": "go",
+	"py":     "python",
+	"js":     "javascript",
+	"ts":     "typescript",
+	"c++":    "cpp",
+	"c#":     "csharp",
+	"cs":     "csharp",
+	"kt":     "kotlin",
+	"rs":     "rust",
+	"rb":     "ruby",
+	"sh":     "bash",
+	"shell":  "bash",
+	"text":   PlainText,
+	"":       PlainText,
+}
+
+// Normalize returns canonical language name (aliases like "golang" or "c++" are resolved)
+// and false if the language is not supported
+func Normalize(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if canonical, ok := aliases[name]; ok {
+		name = canonical
+	}
+	_, ok := languages[name]
+	return name, ok
+}
+
+// Languages returns names of supported languages
+func Languages() []string {
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	return names
+}
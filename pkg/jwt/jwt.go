@@ -0,0 +1,238 @@
+// Package jwt verifies compact JWS tokens signed with HS256 or RS256 and checks
+// registered claims. It covers what the service needs to run without an auth gateway.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+var (
+	ErrMalformed        = errors.New("token is malformed")
+	ErrUnsupportedAlg   = errors.New("token algorithm is not accepted")
+	ErrUnknownKey       = errors.New("token key is unknown")
+	ErrInvalidSignature = errors.New("token signature is invalid")
+	ErrExpired          = errors.New("token is expired")
+	ErrMissingExpiry    = errors.New("token has no expiration time")
+	ErrNotYetValid      = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("token issuer is invalid")
+	ErrInvalidAudience  = errors.New("token audience is invalid")
+)
+
+// Claims is the payload of a token
+type Claims map[string]interface{}
+
+// String returns a string claim, numbers are formatted without exponent
+func (c Claims) String(name string) (string, bool) {
+	switch v := c[name].(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// Time returns a NumericDate claim
+func (c Claims) Time(name string) (time.Time, bool) {
+	v, ok := c[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := v.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(seconds), 0), true
+}
+
+// Audience returns aud claim which may be a string or an array of strings
+func (c Claims) Audience() []string {
+	switch v := c["aud"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		audience := make([]string, 0, len(v))
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				audience = append(audience, s)
+			}
+		}
+		return audience
+	default:
+		return nil
+	}
+}
+
+type Options struct {
+	Algorithm string  // the only accepted alg, HS256 or RS256
+	Keys      *KeySet // secrets for HS256, public keys for RS256
+	Issuer    string  // checked if not empty
+	Audience  string  // checked if not empty
+	Leeway    time.Duration
+	Now       func() time.Time // time.Now by default
+
+	ExpiryOptional bool // accept tokens without exp claim, they are rejected by default since they never expire
+}
+
+type Verifier struct {
+	options Options
+}
+
+func NewVerifier(options Options) (*Verifier, error) {
+	if options.Algorithm != HS256 && options.Algorithm != RS256 {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, options.Algorithm)
+	}
+
+	if options.Keys == nil || options.Keys.Empty(options.Algorithm) {
+		return nil, fmt.Errorf("%w: no keys for %s", ErrUnknownKey, options.Algorithm)
+	}
+
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	return &Verifier{
+		options: options,
+	}, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks signature and time, issuer and audience claims of the token and returns its claims
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+
+	// the algorithm is fixed by configuration, so a token can not switch RS256 key to HMAC secret
+	if h.Alg != v.options.Algorithm {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, h.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	if err = v.verifySignature(h, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err = v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) verifySignature(h header, signingInput string, signature []byte) error {
+	switch h.Alg {
+	case HS256:
+		secret, ok := v.options.Keys.Secret(h.Kid)
+		if !ok {
+			return ErrUnknownKey
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+	case RS256:
+		key, ok := v.options.Keys.PublicKey(h.Kid)
+		if !ok {
+			return ErrUnknownKey
+		}
+		sum := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+	default:
+		return ErrUnsupportedAlg
+	}
+
+	return nil
+}
+
+func (v *Verifier) validateClaims(claims Claims) error {
+	now := v.options.Now()
+
+	exp, ok := claims.Time("exp")
+	switch {
+	case !ok && !v.options.ExpiryOptional:
+		return ErrMissingExpiry
+	case ok && !now.Before(exp.Add(v.options.Leeway)):
+		return ErrExpired
+	}
+
+	if nbf, ok := claims.Time("nbf"); ok && now.Add(v.options.Leeway).Before(nbf) {
+		return ErrNotYetValid
+	}
+
+	if v.options.Issuer != "" {
+		if iss, _ := claims.String("iss"); iss != v.options.Issuer {
+			return ErrInvalidIssuer
+		}
+	}
+
+	if v.options.Audience != "" {
+		found := false
+		for _, aud := range claims.Audience() {
+			if aud == v.options.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrInvalidAudience
+		}
+	}
+
+	return nil
+}
+
+// decodeSegment decodes base64url JSON, numbers are kept as json.Number so big ids are not rounded
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err = decoder.Decode(v); err != nil {
+		return ErrMalformed
+	}
+
+	return nil
+}
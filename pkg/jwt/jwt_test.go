@@ -0,0 +1,305 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Unix(1700000000, 0)
+
+func segment(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret []byte, h map[string]string, claims map[string]interface{}) string {
+	t.Helper()
+
+	input := segment(t, h) + "." + segment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, h map[string]string, claims map[string]interface{}) string {
+	t.Helper()
+
+	input := segment(t, h) + "." + segment(t, claims)
+	sum := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatalf("rsa.SignPKCS1v15: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":  "42",
+		"role": "Admin",
+		"exp":  now.Add(time.Hour).Unix(),
+	}
+}
+
+func newVerifier(t *testing.T, options Options) *Verifier {
+	t.Helper()
+
+	options.Now = func() time.Time { return now }
+	v, err := NewVerifier(options)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return v
+}
+
+func TestNewVerifier(t *testing.T) {
+	keys := NewKeySet()
+	keys.AddSecret("", []byte("secret"))
+
+	if _, err := NewVerifier(Options{Algorithm: "none", Keys: keys}); !errors.Is(err, ErrUnsupportedAlg) {
+		t.Errorf("alg none: got %v, want %v", err, ErrUnsupportedAlg)
+	}
+	if _, err := NewVerifier(Options{Algorithm: RS256, Keys: keys}); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("RS256 without public keys: got %v, want %v", err, ErrUnknownKey)
+	}
+	if _, err := NewVerifier(Options{Algorithm: HS256}); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("no keys: got %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	secret := []byte("secret")
+	keys := NewKeySet()
+	keys.AddSecret("", secret)
+	v := newVerifier(t, Options{Algorithm: HS256, Keys: keys})
+
+	hs256 := map[string]string{"alg": HS256, "typ": "JWT"}
+	token := signHS256(t, secret, hs256, validClaims())
+
+	claims, err := v.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if sub, _ := claims.String("sub"); sub != "42" {
+		t.Errorf("sub = %q, want 42", sub)
+	}
+
+	parts := strings.Split(token, ".")
+	tamperedClaims := validClaims()
+	tamperedClaims["role"] = "Moderator"
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"wrong secret", signHS256(t, []byte("other"), hs256, validClaims()), ErrInvalidSignature},
+		{"tampered payload", parts[0] + "." + segment(t, tamperedClaims) + "." + parts[2], ErrInvalidSignature},
+		{"no signature", parts[0] + "." + parts[1] + ".", ErrInvalidSignature},
+		{"two segments", parts[0] + "." + parts[1], ErrMalformed},
+		{"bad base64", parts[0] + "." + parts[1] + ".!!!", ErrMalformed},
+		{"bad header", "e30K." + parts[1] + "." + parts[2], ErrUnsupportedAlg},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("{")) + "." + parts[1] + "." + parts[2], ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAlgorithmIsPinned(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+
+	keys := NewKeySet()
+	keys.AddPublicKey("", &key.PublicKey)
+	keys.AddSecret("", []byte("secret"))
+	v := newVerifier(t, Options{Algorithm: RS256, Keys: keys})
+
+	if _, err = v.Verify(signRS256(t, key, map[string]string{"alg": RS256}, validClaims())); err != nil {
+		t.Fatalf("RS256 token: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		// HMAC with the configured secret must not be accepted by RS256 verifier
+		{"HS256", signHS256(t, []byte("secret"), map[string]string{"alg": HS256}, validClaims())},
+		{"none", segment(t, map[string]string{"alg": "none"}) + "." + segment(t, validClaims()) + "."},
+		{"lower case", signRS256(t, key, map[string]string{"alg": "rs256"}, validClaims())},
+		{"no alg", signRS256(t, key, map[string]string{"typ": "JWT"}, validClaims())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(tt.token); !errors.Is(err, ErrUnsupportedAlg) {
+				t.Errorf("got %v, want %v", err, ErrUnsupportedAlg)
+			}
+		})
+	}
+}
+
+func TestVerifyRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+
+	keys := NewKeySet()
+	keys.AddPublicKey("", &key.PublicKey)
+	v := newVerifier(t, Options{Algorithm: RS256, Keys: keys})
+
+	rs256 := map[string]string{"alg": RS256}
+	if _, err = v.Verify(signRS256(t, key, rs256, validClaims())); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if _, err = v.Verify(signRS256(t, other, rs256, validClaims())); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("other key: got %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestVerifyKeyID(t *testing.T) {
+	first, second := []byte("first"), []byte("second")
+
+	keys := NewKeySet()
+	keys.AddSecret("first", first)
+	keys.AddSecret("second", second)
+	v := newVerifier(t, Options{Algorithm: HS256, Keys: keys})
+
+	tests := []struct {
+		name   string
+		secret []byte
+		kid    string
+		want   error
+	}{
+		{"first", first, "first", nil},
+		{"second", second, "second", nil},
+		{"key of other kid", first, "second", ErrInvalidSignature},
+		{"unknown kid", first, "third", ErrUnknownKey},
+		// a single key is not guessed when there are several
+		{"no kid", first, "", ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := map[string]string{"alg": HS256}
+			if tt.kid != "" {
+				h["kid"] = tt.kid
+			}
+			if _, err := v.Verify(signHS256(t, tt.secret, h, validClaims())); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// the only key is used for tokens without kid
+	single := NewKeySet()
+	single.AddSecret("only", first)
+	v = newVerifier(t, Options{Algorithm: HS256, Keys: single})
+	if _, err := v.Verify(signHS256(t, first, map[string]string{"alg": HS256}, validClaims())); err != nil {
+		t.Errorf("single key without kid: %v", err)
+	}
+}
+
+func TestVerifyClaims(t *testing.T) {
+	secret := []byte("secret")
+	keys := NewKeySet()
+	keys.AddSecret("", secret)
+
+	leeway := 30 * time.Second
+	strict := newVerifier(t, Options{Algorithm: HS256, Keys: keys, Leeway: leeway, Issuer: "auth", Audience: "quiz"})
+	optional := newVerifier(t, Options{Algorithm: HS256, Keys: keys, ExpiryOptional: true})
+
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := validClaims()
+		c["iss"] = "auth"
+		c["aud"] = "quiz"
+		for name, value := range changes {
+			if value == nil {
+				delete(c, name)
+				continue
+			}
+			c[name] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		claims   map[string]interface{}
+		want     error
+	}{
+		{"valid", strict, claims(nil), nil},
+		{"expired within leeway", strict, claims(map[string]interface{}{"exp": now.Add(-leeway / 2).Unix()}), nil},
+		{"expired", strict, claims(map[string]interface{}{"exp": now.Add(-leeway).Unix()}), ErrExpired},
+		{"no exp", strict, claims(map[string]interface{}{"exp": nil}), ErrMissingExpiry},
+		{"exp is not a number", strict, claims(map[string]interface{}{"exp": "tomorrow"}), ErrMissingExpiry},
+		{"no exp, optional", optional, claims(map[string]interface{}{"exp": nil}), nil},
+		{"expired, optional", optional, claims(map[string]interface{}{"exp": now.Add(-time.Second).Unix()}), ErrExpired},
+		{"nbf passed", strict, claims(map[string]interface{}{"nbf": now.Add(-time.Minute).Unix()}), nil},
+		{"nbf within leeway", strict, claims(map[string]interface{}{"nbf": now.Add(leeway).Unix()}), nil},
+		{"nbf in future", strict, claims(map[string]interface{}{"nbf": now.Add(leeway + time.Second).Unix()}), ErrNotYetValid},
+		{"other issuer", strict, claims(map[string]interface{}{"iss": "other"}), ErrInvalidIssuer},
+		{"no issuer", strict, claims(map[string]interface{}{"iss": nil}), ErrInvalidIssuer},
+		{"audience list", strict, claims(map[string]interface{}{"aud": []string{"other", "quiz"}}), nil},
+		{"other audience", strict, claims(map[string]interface{}{"aud": []string{"other"}}), ErrInvalidAudience},
+		{"no audience", strict, claims(map[string]interface{}{"aud": nil}), ErrInvalidAudience},
+		{"issuer and audience are not checked", optional, claims(map[string]interface{}{"iss": "other", "aud": "other"}), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signHS256(t, secret, map[string]string{"alg": HS256}, tt.claims)
+			if _, err := tt.verifier.Verify(token); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimsNumbers(t *testing.T) {
+	secret := []byte("secret")
+	keys := NewKeySet()
+	keys.AddSecret("", secret)
+	v := newVerifier(t, Options{Algorithm: HS256, Keys: keys})
+
+	c := validClaims()
+	c["sub"] = int64(9007199254740993) // not representable as float64
+	claims, err := v.Verify(signHS256(t, secret, map[string]string{"alg": HS256}, c))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	if sub, _ := claims.String("sub"); sub != "9007199254740993" {
+		t.Errorf("sub = %q, want 9007199254740993", sub)
+	}
+	if exp, ok := claims.Time("exp"); !ok || !exp.Equal(now.Add(time.Hour)) {
+		t.Errorf("exp = %v, want %v", exp, now.Add(time.Hour))
+	}
+}
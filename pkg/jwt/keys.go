@@ -0,0 +1,180 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// KeySet holds verification keys by kid, the key with empty kid is used for tokens without kid
+type KeySet struct {
+	secrets    map[string][]byte
+	publicKeys map[string]*rsa.PublicKey
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{
+		secrets:    make(map[string][]byte),
+		publicKeys: make(map[string]*rsa.PublicKey),
+	}
+}
+
+func (k *KeySet) AddSecret(kid string, secret []byte) {
+	k.secrets[kid] = secret
+}
+
+func (k *KeySet) AddPublicKey(kid string, key *rsa.PublicKey) {
+	k.publicKeys[kid] = key
+}
+
+// Secret returns HS256 secret by kid, a single secret is used for tokens without kid
+func (k *KeySet) Secret(kid string) ([]byte, bool) {
+	if secret, ok := k.secrets[kid]; ok {
+		return secret, true
+	}
+	if len(k.secrets) == 1 && kid == "" {
+		for _, secret := range k.secrets {
+			return secret, true
+		}
+	}
+	return nil, false
+}
+
+// PublicKey returns RS256 key by kid, a single key is used for tokens without kid
+func (k *KeySet) PublicKey(kid string) (*rsa.PublicKey, bool) {
+	if key, ok := k.publicKeys[kid]; ok {
+		return key, true
+	}
+	if len(k.publicKeys) == 1 && kid == "" {
+		for _, key := range k.publicKeys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Empty says whether there are no keys for the algorithm
+func (k *KeySet) Empty(algorithm string) bool {
+	switch algorithm {
+	case HS256:
+		return len(k.secrets) == 0
+	case RS256:
+		return len(k.publicKeys) == 0
+	default:
+		return true
+	}
+}
+
+// LoadPEMPublicKey reads RSA public key from PEM file with PKIX or PKCS1 key or a certificate
+func LoadPEMPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an RSA public key", path)
+	}
+
+	return rsaKey, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// LoadJWKS adds keys from JWKS file to the set: RSA keys for RS256 and oct keys for HS256,
+// keys for other use than signature are skipped
+func (k *KeySet) LoadJWKS(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.Unmarshal(data, &jwks); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		switch key.Kty {
+		case "RSA":
+			publicKey, err := key.rsaPublicKey()
+			if err != nil {
+				return fmt.Errorf("%s: key %q: %w", path, key.Kid, err)
+			}
+			k.AddPublicKey(key.Kid, publicKey)
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return fmt.Errorf("%s: key %q: %w", path, key.Kid, err)
+			}
+			k.AddSecret(key.Kid, secret)
+		}
+	}
+
+	return nil
+}
+
+func (key jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA modulus or exponent")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}